@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nemopss/financial-tracker/config"
+	"github.com/nemopss/financial-tracker/internal/demo"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/storage"
+)
+
+// setupDemo returns an in-memory repository seeded with sample data and a
+// throwaway attachment directory. Nothing outlives the process, so no
+// database or other configuration is needed.
+func setupDemo(cfg *config.Config) (repository.Repository, storage.BlobStorage) {
+	repo := repository.NewMemoryRepo()
+	if _, err := demo.Seed(context.Background(), repo, time.Now().UTC()); err != nil {
+		log.Fatalf("Failed to seed demo data: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "financial-tracker-demo-")
+	if err != nil {
+		log.Fatalf("Failed to create demo attachment directory: %v", err)
+	}
+	blobs, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+
+	// Tokens only have to survive until the process exits
+	if cfg.JWTSecret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate JWT secret: %v", err)
+		}
+		cfg.JWTSecret = hex.EncodeToString(secret)
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8080"
+	}
+
+	log.Printf("Demo mode: data is kept in memory and lost on exit, attachments are stored in %s", dir)
+	log.Printf("Demo mode: log in as %q with password %q", demo.Username, demo.Password)
+	return repo, blobs
+}
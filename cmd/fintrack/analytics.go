@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nemopss/financial-tracker/pkg/client"
+)
+
+func (a *app) analytics(ctx context.Context, args []string) error {
+	if err := a.requireLogin(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("analytics needs summary, categories or tags")
+	}
+
+	fs := newFlagSet("analytics " + args[0])
+	format := outputFlag(fs)
+	from := fs.String("from", "", "first day, YYYY-MM-DD")
+	to := fs.String("to", "", "last day (inclusive), YYYY-MM-DD")
+	if _, err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	var days *client.DateRange
+	switch {
+	case *from != "" && *to != "":
+		days = &client.DateRange{Start: *from, End: *to}
+	case *from != "" || *to != "":
+		return errors.New("analytics needs both -from and -to")
+	}
+
+	switch args[0] {
+	case "summary":
+		totals, err := a.client.IncomeAndExpenses(ctx, days)
+		if err != nil {
+			return err
+		}
+		return render(a.out, *format, totals, func(w io.Writer) {
+			row(w, "INCOME", "EXPENSES", "BALANCE")
+			row(w, money(totals.TotalIncome), money(totals.TotalExpense), money(totals.TotalIncome+totals.TotalExpense))
+		})
+	case "categories":
+		totals, err := a.client.CategoryAnalytics(ctx, days)
+		if err != nil {
+			return err
+		}
+		return render(a.out, *format, totals, func(w io.Writer) {
+			row(w, "CATEGORY", "TOTAL")
+			for _, t := range totals {
+				row(w, t.CategoryName, money(t.TotalAmount))
+			}
+		})
+	case "tags":
+		if days == nil {
+			return errors.New("analytics tags needs -from and -to")
+		}
+		totals, err := a.client.TagAnalytics(ctx, *days)
+		if err != nil {
+			return err
+		}
+		return render(a.out, *format, totals, func(w io.Writer) {
+			row(w, "TAG", "TOTAL")
+			for _, t := range totals {
+				row(w, t.TagName, money(t.TotalAmount))
+			}
+		})
+	default:
+		return fmt.Errorf("unknown analytics command %q", args[0])
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func (a *app) register(ctx context.Context, args []string) error {
+	fs := newFlagSet("register")
+	username := fs.String("u", "", "username")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("register needs -u USERNAME")
+	}
+
+	password, err := a.password()
+	if err != nil {
+		return err
+	}
+	id, err := a.client.Register(ctx, *username, password)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Registered %s (user %d), now run fintrack login -u %s\n", *username, id, *username)
+	return nil
+}
+
+func (a *app) login(ctx context.Context, args []string) error {
+	fs := newFlagSet("login")
+	username := fs.String("u", a.cfg.Username, "username")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("login needs -u USERNAME")
+	}
+
+	password, err := a.password()
+	if err != nil {
+		return err
+	}
+	token, err := a.client.Login(ctx, *username, password)
+	if err != nil {
+		return err
+	}
+
+	a.cfg.Server = a.client.BaseURL
+	a.cfg.Username = *username
+	a.cfg.Token = token
+	if err := a.cfg.save(a.cfgPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Logged in as %s\n", *username)
+	return nil
+}
+
+func (a *app) logout() error {
+	a.cfg.Token = ""
+	if err := a.cfg.save(a.cfgPath); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "Logged out")
+	return nil
+}
+
+// password reads FINTRACK_PASSWORD, or prompts for a line on stdin without
+// echoing it when stdin is a terminal.
+func (a *app) password() (string, error) {
+	if password := os.Getenv("FINTRACK_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	if f, ok := a.in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// requireLogin fails early with a hint instead of a bare 401 from the server.
+func (a *app) requireLogin() error {
+	if a.client.Token == "" {
+		return errors.New("not logged in, run fintrack login -u USERNAME")
+	}
+	return nil
+}
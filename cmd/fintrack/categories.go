@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+func (a *app) categories(ctx context.Context, args []string) error {
+	if err := a.requireLogin(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("category needs add, list, rename or delete")
+	}
+
+	fs := newFlagSet("category " + args[0])
+	format := outputFlag(fs)
+	version, force := versionFlags(fs)
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		if len(positional) != 1 {
+			return errors.New("category add needs NAME")
+		}
+		id, err := a.client.CreateCategory(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Created category %d\n", id)
+	case "list":
+		categories, err := a.client.Categories(ctx)
+		if err != nil {
+			return err
+		}
+		return render(a.out, *format, categories, func(w io.Writer) {
+			row(w, "ID", "NAME")
+			for _, c := range categories {
+				row(w, c.ID, c.Name)
+			}
+		})
+	case "rename":
+		if len(positional) != 2 {
+			return errors.New("category rename needs ID NAME")
+		}
+		id, err := parseID(positional[0])
+		if err != nil {
+			return err
+		}
+		expected, err := expectedVersion(*version, *force, a.categoryVersion(ctx, id))
+		if err != nil {
+			return err
+		}
+		if err := a.client.UpdateCategory(ctx, id, positional[1], expected); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Renamed category %d\n", id)
+	case "delete":
+		if len(positional) != 1 {
+			return errors.New("category delete needs ID")
+		}
+		id, err := parseID(positional[0])
+		if err != nil {
+			return err
+		}
+		expected, err := expectedVersion(*version, *force, a.categoryVersion(ctx, id))
+		if err != nil {
+			return err
+		}
+		if err := a.client.DeleteCategory(ctx, id, expected); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Moved category %d to the trash\n", id)
+	default:
+		return fmt.Errorf("unknown category command %q", args[0])
+	}
+	return nil
+}
+
+// categoryVersion reads the version a category has now.
+func (a *app) categoryVersion(ctx context.Context, id int) func() (int, error) {
+	return func() (int, error) {
+		category, err := a.client.Category(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return category.Version, nil
+	}
+}
+
+// categoryNames maps category IDs to names for display.
+func (a *app) categoryNames(ctx context.Context) (map[int]string, error) {
+	categories, err := a.client.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	return names, nil
+}
+
+func parseID(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid ID %q", value)
+	}
+	return id, nil
+}
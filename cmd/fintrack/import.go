@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nemopss/financial-tracker/pkg/client"
+)
+
+// importFile creates transactions from a CSV or JSON file through the bulk
+// endpoint, MaxBulkOperations at a time.
+//
+// CSV files need a header naming the columns amount, date, description and
+// either category_id or category (a category name). JSON files hold an array
+// of objects with the fields of a transaction.
+func (a *app) importFile(ctx context.Context, args []string) error {
+	if err := a.requireLogin(); err != nil {
+		return err
+	}
+
+	fs := newFlagSet("import")
+	format := fs.String("format", "", "csv or json, detected from the file extension by default")
+	mode := fs.String("mode", client.BulkAtomic, "atomic stops at the first failing batch, best_effort skips failing rows")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("import needs FILE")
+	}
+	path := positional[0]
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var ops []client.BulkOperation
+	switch *format {
+	case "csv":
+		ops, err = a.readCSV(ctx, f)
+	case "json":
+		ops, err = readJSON(f)
+	default:
+		return fmt.Errorf("unknown import format %q, use csv or json", *format)
+	}
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return errors.New("nothing to import")
+	}
+
+	created, failed := 0, 0
+	for start := 0; start < len(ops); start += client.MaxBulkOperations {
+		batch := ops[start:min(start+client.MaxBulkOperations, len(ops))]
+		resp, err := a.client.Bulk(ctx, *mode, batch)
+		if err != nil {
+			return fmt.Errorf("import stopped after %d transactions: %w", created, err)
+		}
+		for _, result := range resp.Results {
+			if result.Error != "" {
+				failed++
+				fmt.Fprintf(a.out, "Row %d: %s\n", start+result.Index+1, result.Error)
+			}
+		}
+		if resp.RolledBack {
+			return fmt.Errorf("import stopped after %d transactions, the batch starting at row %d was rolled back", created, start+1)
+		}
+		created += len(resp.Results) - countErrors(resp.Results)
+	}
+
+	fmt.Fprintf(a.out, "Imported %d transactions", created)
+	if failed > 0 {
+		fmt.Fprintf(a.out, ", %d failed", failed)
+	}
+	fmt.Fprintln(a.out)
+	return nil
+}
+
+func (a *app) readCSV(ctx context.Context, r io.Reader) ([]client.BulkOperation, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("CSV header has no amount column")
+	}
+
+	// Category names are resolved once, only when the file uses them
+	var categoryIDs map[string]int
+	if _, ok := columns["category"]; ok {
+		names, err := a.categoryNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs = make(map[string]int, len(names))
+		for id, name := range names {
+			categoryIDs[strings.ToLower(name)] = id
+		}
+	}
+
+	ops := make([]client.BulkOperation, 0, len(records)-1)
+	for n, record := range records[1:] {
+		line := n + 2
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		op := client.BulkOperation{Op: client.BulkCreate, Date: field("date"), Description: field("description")}
+		if op.Amount, err = strconv.ParseFloat(field("amount"), 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, field("amount"))
+		}
+		if value := field("category_id"); value != "" {
+			if op.CategoryID, err = parseID(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		} else if name := field("category"); name != "" {
+			id, ok := categoryIDs[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown category %q", line, name)
+			}
+			op.CategoryID = id
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func readJSON(r io.Reader) ([]client.BulkOperation, error) {
+	var inputs []client.TransactionInput
+	if err := json.NewDecoder(r).Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+
+	ops := make([]client.BulkOperation, len(inputs))
+	for i, in := range inputs {
+		ops[i] = client.BulkOperation{
+			Op:          client.BulkCreate,
+			Amount:      in.Amount,
+			Date:        in.Date,
+			Description: in.Description,
+			CategoryID:  in.CategoryID,
+		}
+	}
+	return ops, nil
+}
+
+func countErrors(results []client.BulkResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Error != "" {
+			n++
+		}
+	}
+	return n
+}
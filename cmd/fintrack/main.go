@@ -0,0 +1,172 @@
+// Command fintrack is a command-line client for the Financial Tracker API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/nemopss/financial-tracker/pkg/client"
+)
+
+const usage = `Usage: fintrack [-server URL] <command> [arguments]
+
+Commands:
+  register -u USERNAME            create an account
+  login -u USERNAME               log in and remember the token
+  logout                          forget the token
+  tx add -amount N [-date D] [-desc TEXT] [-category ID]
+  tx list [-o table|json]
+  tx show ID [-o table|json]
+  tx edit ID [-amount N] [-date D] [-desc TEXT] [-category ID | -no-category] [-version N | -force]
+  tx delete ID [-version N | -force]
+  category add NAME
+  category list [-o table|json]
+  category rename ID NAME [-version N | -force]
+  category delete ID [-version N | -force]
+  analytics summary|categories|tags [-from YYYY-MM-DD -to YYYY-MM-DD] [-o table|json]
+  import [-format csv|json] [-mode atomic|best_effort] FILE
+
+Dates are RFC 3339 timestamps or YYYY-MM-DD days in your timezone.
+The password is read from FINTRACK_PASSWORD or prompted for.
+Edits and deletes fail when the record no longer has the version given with
+-version (see -o json), or changed since the command read it. -force
+overwrites any version.
+`
+
+// app carries what every command needs.
+type app struct {
+	cfg     *Config
+	cfgPath string
+	client  *client.Client
+	in      io.Reader
+	out     io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "fintrack:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	global := flag.NewFlagSet("fintrack", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(global.Output(), usage) }
+	server := global.String("server", "", "API root, e.g. "+client.DefaultBaseURL)
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		global.Usage()
+		return flag.ErrHelp
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	// The flag wins over the environment, which wins over the saved server
+	baseURL := firstNonEmpty(*server, os.Getenv("FINTRACK_SERVER"), cfg.Server, client.DefaultBaseURL)
+	c := client.New(baseURL)
+	c.Token = cfg.Token
+	a := &app{cfg: cfg, cfgPath: path, client: c, in: in, out: out}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "register":
+		return a.register(ctx, rest)
+	case "login":
+		return a.login(ctx, rest)
+	case "logout":
+		return a.logout()
+	case "tx", "transaction", "transactions":
+		return a.transactions(ctx, rest)
+	case "category", "categories":
+		return a.categories(ctx, rest)
+	case "analytics":
+		return a.analytics(ctx, rest)
+	case "import":
+		return a.importFile(ctx, rest)
+	case "help":
+		global.Usage()
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, run fintrack help", command)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseFlags parses flags placed before, between or after positional
+// arguments, which the flag package alone stops at, and returns the latter.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// versionFlags registers -version and -force for commands that change or
+// delete a record.
+func versionFlags(fs *flag.FlagSet) (*int, *bool) {
+	version := fs.Int("version", 0, "version the record must still have")
+	force := fs.Bool("force", false, "overwrite the record whatever its version")
+	return version, force
+}
+
+// expectedVersion returns the version to send in If-Match: the -version flag,
+// 0 for "*" with -force, or else the version current returns, so that a change
+// made by another client meanwhile fails the write instead of being lost.
+func expectedVersion(version int, force bool, current func() (int, error)) (int, error) {
+	switch {
+	case version < 0:
+		return 0, fmt.Errorf("invalid -version %d", version)
+	case force && version != 0:
+		return 0, errors.New("-version and -force cannot be used together")
+	case force:
+		return 0, nil
+	case version != 0:
+		return version, nil
+	}
+	return current()
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors instead
+// of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("fintrack "+name, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	return fs
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// request is what the test server saw of one call.
+type request struct {
+	Method  string
+	Path    string
+	IfMatch string
+	Body    map[string]any
+}
+
+// testServer records the requests it receives and answers GETs of single
+// records with version 3.
+func testServer(t *testing.T) (*httptest.Server, *[]request) {
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		req := request{Method: r.Method, Path: r.URL.Path, IfMatch: r.Header.Get("If-Match")}
+		json.NewDecoder(r.Body).Decode(&req.Body)
+		requests = append(requests, req)
+
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/transactions/"):
+			w.Write([]byte(`{"id":7,"amount":-12.5,"version":3}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/categories/"):
+			w.Write([]byte(`{"id":4,"name":"Food","version":3}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/categories":
+			w.Write([]byte(`{"categories":[{"id":4,"name":"Food","version":3}]}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":9}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// runCLI runs the command logged in against srv and returns what it printed.
+func runCLI(t *testing.T, srv *httptest.Server, args ...string) (string, error) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, (&Config{Server: srv.URL, Token: "secret"}).save(path))
+	t.Setenv("FINTRACK_CONFIG", path)
+	t.Setenv("FINTRACK_SERVER", "")
+
+	var out bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(""), &out)
+	return out.String(), err
+}
+
+func TestParseFlags(t *testing.T) {
+	fs := newFlagSet("test")
+	amount := fs.Float64("amount", 0, "")
+	force := fs.Bool("force", false, "")
+
+	positional, err := parseFlags(fs, []string{"7", "-amount", "-3.5", "Food", "-force"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"7", "Food"}, positional)
+	assert.Equal(t, -3.5, *amount)
+	assert.True(t, *force)
+
+	fs = newFlagSet("test")
+	fs.SetOutput(&bytes.Buffer{})
+	_, err = parseFlags(fs, []string{"-unknown"})
+	assert.Error(t, err)
+}
+
+func TestExpectedVersion(t *testing.T) {
+	current := func() (int, error) { return 3, nil }
+
+	tests := map[string]struct {
+		version int
+		force   bool
+		want    int
+		err     string
+	}{
+		"read current":  {want: 3},
+		"given version": {version: 2, want: 2},
+		"force":         {force: true, want: 0},
+		"both":          {version: 2, force: true, err: "-version and -force cannot be used together"},
+		"negative":      {version: -1, err: "invalid -version -1"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := expectedVersion(tt.version, tt.force, current)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		out      string
+		requests []request
+	}{
+		"tx add": {
+			args: []string{"tx", "add", "-amount", "-12.50", "-desc", "Lunch", "-category", "4"},
+			out:  "Created transaction 9\n",
+			requests: []request{
+				{Method: "POST", Path: "/transactions", Body: map[string]any{"amount": -12.5, "description": "Lunch", "category_id": 4.0}},
+			},
+		},
+		"tx edit sends the version it read": {
+			args: []string{"tx", "edit", "7", "-amount", "5"},
+			out:  "Updated transaction 7\n",
+			requests: []request{
+				{Method: "GET", Path: "/transactions/7"},
+				{Method: "PATCH", Path: "/transactions/7", IfMatch: `"3"`, Body: map[string]any{"amount": 5.0}},
+			},
+		},
+		"tx edit with version": {
+			args: []string{"tx", "edit", "-version", "2", "7", "-no-category"},
+			out:  "Updated transaction 7\n",
+			requests: []request{
+				{Method: "PATCH", Path: "/transactions/7", IfMatch: `"2"`, Body: map[string]any{"category_id": nil}},
+			},
+		},
+		"tx delete with force": {
+			args: []string{"tx", "delete", "7", "-force"},
+			out:  "Moved transaction 7 to the trash\n",
+			requests: []request{
+				{Method: "DELETE", Path: "/transactions/7", IfMatch: "*"},
+			},
+		},
+		"category rename sends the version it read": {
+			args: []string{"category", "rename", "4", "Groceries"},
+			out:  "Renamed category 4\n",
+			requests: []request{
+				{Method: "GET", Path: "/categories/4"},
+				{Method: "PUT", Path: "/categories/4", IfMatch: `"3"`, Body: map[string]any{"name": "Groceries"}},
+			},
+		},
+		"category delete with version": {
+			args: []string{"categories", "delete", "4", "-version", "3"},
+			out:  "Moved category 4 to the trash\n",
+			requests: []request{
+				{Method: "DELETE", Path: "/categories/4", IfMatch: `"3"`},
+			},
+		},
+		"category list as json": {
+			args: []string{"category", "list", "-o", "json"},
+			out:  "[\n  {\n    \"id\": 4,\n    \"name\": \"Food\",\n    \"version\": 3\n  }\n]\n",
+			requests: []request{
+				{Method: "GET", Path: "/categories"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := testServer(t)
+
+			out, err := runCLI(t, srv, tt.args...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, out)
+			assert.Equal(t, tt.requests, *requests)
+		})
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		err  string
+	}{
+		"unknown command":     {args: []string{"budget"}, err: `unknown command "budget", run fintrack help`},
+		"unknown tx command":  {args: []string{"tx", "move", "7"}, err: `unknown tx command "move"`},
+		"missing amount":      {args: []string{"tx", "add", "-desc", "Lunch"}, err: "tx add needs -amount"},
+		"missing ID":          {args: []string{"tx", "delete"}, err: "tx delete needs ID"},
+		"invalid ID":          {args: []string{"category", "delete", "food"}, err: `invalid ID "food"`},
+		"version and force":   {args: []string{"tx", "delete", "7", "-version", "2", "-force"}, err: "-version and -force cannot be used together"},
+		"missing rename name": {args: []string{"category", "rename", "4"}, err: "category rename needs ID NAME"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := testServer(t)
+
+			_, err := runCLI(t, srv, tt.args...)
+			assert.EqualError(t, err, tt.err)
+			assert.Empty(t, *requests)
+		})
+	}
+}
+
+func TestCommandNeedsLogin(t *testing.T) {
+	t.Setenv("FINTRACK_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+
+	err := run(context.Background(), []string{"tx", "list"}, strings.NewReader(""), &bytes.Buffer{})
+	assert.EqualError(t, err, "not logged in, run fintrack login -u USERNAME")
+}
+
+func TestLoginSavesToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "/auth/login", r.URL.Path)
+		assert.Equal(t, map[string]string{"username": "alice", "password": "password123"}, body)
+		w.Write([]byte(`{"token":"secret"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("FINTRACK_CONFIG", path)
+	t.Setenv("FINTRACK_PASSWORD", "")
+
+	// The password is read from stdin when it is not a terminal
+	var out bytes.Buffer
+	err := run(context.Background(), []string{"-server", srv.URL, "login", "-u", "alice"}, strings.NewReader("password123\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "Logged in as alice\n", out.String())
+
+	cfg, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{Server: srv.URL, Username: "alice", Token: "secret"}, cfg)
+}
+
+func TestHelp(t *testing.T) {
+	t.Setenv("FINTRACK_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stderr = stderr }()
+
+	err := run(context.Background(), nil, strings.NewReader(""), &bytes.Buffer{})
+	assert.ErrorIs(t, err, flag.ErrHelp)
+}
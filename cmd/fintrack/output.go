@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// outputFlag registers -o for commands that print records.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", "table", "output format: table or json")
+}
+
+// render prints v as indented JSON, or calls table with a tab-separated writer.
+func render(out io.Writer, format string, v any, table func(w io.Writer)) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table":
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", format)
+	}
+}
+
+// row writes one tab-separated table row.
+func row(w io.Writer, cells ...any) {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		parts[i] = fmt.Sprint(cell)
+	}
+	fmt.Fprintln(w, strings.Join(parts, "\t"))
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/nemopss/financial-tracker/pkg/client"
+)
+
+func (a *app) transactions(ctx context.Context, args []string) error {
+	if err := a.requireLogin(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("tx needs add, list, show, edit or delete")
+	}
+
+	fs := newFlagSet("tx " + args[0])
+	format := outputFlag(fs)
+	amount := fs.Float64("amount", 0, "amount, negative for expenses")
+	date := fs.String("date", "", "RFC 3339 timestamp or YYYY-MM-DD day")
+	description := fs.String("desc", "", "description")
+	categoryID := fs.Int("category", 0, "category ID")
+	noCategory := fs.Bool("no-category", false, "remove the category")
+	version, force := versionFlags(fs)
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		if !isSet(fs, "amount") {
+			return errors.New("tx add needs -amount")
+		}
+		id, err := a.client.CreateTransaction(ctx, client.TransactionInput{
+			Amount:      *amount,
+			Date:        *date,
+			Description: *description,
+			CategoryID:  *categoryID,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Created transaction %d\n", id)
+	case "list":
+		transactions, err := a.client.Transactions(ctx)
+		if err != nil {
+			return err
+		}
+		return a.printTransactions(ctx, *format, transactions, transactions)
+	case "show":
+		id, err := singleID("tx show", positional)
+		if err != nil {
+			return err
+		}
+		txn, err := a.client.Transaction(ctx, id)
+		if err != nil {
+			return err
+		}
+		return a.printTransactions(ctx, *format, txn, []client.Transaction{*txn})
+	case "edit":
+		id, err := singleID("tx edit", positional)
+		if err != nil {
+			return err
+		}
+		var patch client.TransactionPatch
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "amount":
+				patch.Amount = amount
+			case "date":
+				patch.Date = date
+			case "desc":
+				patch.Description = description
+			case "category":
+				patch.CategoryID = categoryID
+			}
+		})
+		patch.ClearCategory = *noCategory
+		expected, err := expectedVersion(*version, *force, a.transactionVersion(ctx, id))
+		if err != nil {
+			return err
+		}
+		if err := a.client.PatchTransaction(ctx, id, patch, expected); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Updated transaction %d\n", id)
+	case "delete":
+		id, err := singleID("tx delete", positional)
+		if err != nil {
+			return err
+		}
+		expected, err := expectedVersion(*version, *force, a.transactionVersion(ctx, id))
+		if err != nil {
+			return err
+		}
+		if err := a.client.DeleteTransaction(ctx, id, expected); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Moved transaction %d to the trash\n", id)
+	default:
+		return fmt.Errorf("unknown tx command %q", args[0])
+	}
+	return nil
+}
+
+// transactionVersion reads the version a transaction has now.
+func (a *app) transactionVersion(ctx context.Context, id int) func() (int, error) {
+	return func() (int, error) {
+		txn, err := a.client.Transaction(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return txn.Version, nil
+	}
+}
+
+// printTransactions renders v as JSON, or transactions as a table with
+// category names.
+func (a *app) printTransactions(ctx context.Context, format string, v any, transactions []client.Transaction) error {
+	names := map[int]string{}
+	if format == "table" {
+		var err error
+		if names, err = a.categoryNames(ctx); err != nil {
+			return err
+		}
+	}
+
+	return render(a.out, format, v, func(w io.Writer) {
+		row(w, "ID", "DATE", "AMOUNT", "CATEGORY", "DESCRIPTION")
+		for _, t := range transactions {
+			category := names[t.CategoryID]
+			if category == "" && t.CategoryID != 0 {
+				category = fmt.Sprint(t.CategoryID)
+			}
+			row(w, t.ID, t.Date.Local().Format("2006-01-02 15:04"), money(t.Amount), category, t.Description)
+		}
+	})
+}
+
+func singleID(command string, positional []string) (int, error) {
+	if len(positional) != 1 {
+		return 0, fmt.Errorf("%s needs ID", command)
+	}
+	return parseID(positional[0])
+}
+
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -18,79 +18,179 @@
 package main
 
 import (
+	"context"
+	"expvar"
+	"flag"
+	"io/fs"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // Timezones of users on hosts without a zoneinfo database
 
-	"github.com/gin-gonic/gin"
 	"github.com/nemopss/financial-tracker/config"
-	_ "github.com/nemopss/financial-tracker/docs" // Swagger docs
 	"github.com/nemopss/financial-tracker/internal/handlers"
-	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/jobs"
+	"github.com/nemopss/financial-tracker/internal/metrics"
+	"github.com/nemopss/financial-tracker/internal/migrate"
 	"github.com/nemopss/financial-tracker/internal/repository"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/nemopss/financial-tracker/internal/router"
+	"github.com/nemopss/financial-tracker/internal/storage"
+	"github.com/nemopss/financial-tracker/migrations"
 )
 
 func main() {
+	demo := flag.Bool("demo", false, "serve seeded sample data from memory instead of the database")
+	flag.Parse()
+
 	// Load configuration
 	cfg := config.LoadConfig()
-	if cfg.JWTSecret == "" || cfg.Port == "" || cfg.DBHost == "" {
-		log.Fatal("Missing critical configuration values")
+
+	var repo repository.Repository
+	var blobs storage.BlobStorage
+	var db *repository.DB
+	var checks []handlers.HealthCheck
+	if *demo {
+		if flag.NArg() > 0 {
+			log.Fatal("Commands need a database and cannot be combined with -demo")
+		}
+		repo, blobs = setupDemo(cfg)
+	} else {
+		var migrator *migrate.Migrator
+		db, migrator = openDB(cfg)
+
+		if flag.NArg() > 0 {
+			err := runCommand(context.Background(), migrator, flag.Args())
+			db.Close()
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if cfg.JWTSecret == "" || cfg.Port == "" {
+			log.Fatal("Missing critical configuration values")
+		}
+
+		// Refuse to serve against a schema the handlers were not written for
+		if cfg.AutoMigrate {
+			if _, err := migrator.Up(context.Background()); err != nil {
+				log.Fatalf("Failed to migrate database: %v", err)
+			}
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			log.Fatalf("Database schema check failed: %v; run \"migrate up\" or set AUTO_MIGRATE=true", err)
+		}
+
+		repo, blobs = db, newStorage(cfg)
+		checks = append(checks,
+			handlers.HealthCheck{Name: "database", Check: db.Ping},
+			handlers.HealthCheck{Name: "migrations", Check: migrator.Check},
+		)
 	}
 
-	// Connect to the database
-	db, err := repository.NewDB(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	// Time every repository call for /metrics
+	repo = repository.Instrumented(repo, metrics.ObserveRepository)
+
+	// SIGINT and SIGTERM cancel ctx, which stops the server and the background
+	// jobs; a second signal kills the process without waiting for them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+
+	// Purge the trash in the background
+	workers := &jobs.Workers{}
+	purger := &jobs.TrashPurger{Repo: repo, Storage: blobs, Retention: cfg.TrashRetention, Interval: cfg.PurgeInterval}
+	workers.Go(ctx, "trash purger", purger.Run)
+	checks = append(checks, handlers.HealthCheck{Name: "workers", Check: workers.Check})
+
+	r := router.New(router.Config{
+		Repo:              repo,
+		Storage:           blobs,
+		JWTSecret:         cfg.JWTSecret,
+		MaxAttachmentSize: cfg.MaxAttachmentSize,
+		DebugVars:         cfg.DebugVars,
+		HealthChecks:      checks,
+	})
+
+	// Close the pool only once the handlers and jobs using it are done
+	err := serve(ctx, newServer(cfg, r), workers, cfg.ShutdownTimeout)
+	if db != nil {
+		db.Close()
+	}
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatal(err)
 	}
-	defer db.Conn.Close()
-
-	// Initialize handlers
-	authHandler := &handlers.AuthHandler{Repo: db, JWTSecret: cfg.JWTSecret}
-	categoryHandler := &handlers.CategoryHandler{Repo: db}
-	transactionHandler := &handlers.TransactionHandler{Repo: db}
-	analyticsHandler := &handlers.AnalyticsHandler{Repo: db}
-
-	// Initialize Gin
-	r := gin.Default()
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
-
-	// Swagger
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// API routes
-	api := r.Group("/api/v1")
-	{
-		// Auth routes
-		api.POST("/auth/register", authHandler.RegisterGin)
-		api.POST("/auth/login", authHandler.LoginGin)
-
-		// Protected routes
-		protected := api.Group("/", middleware.AuthGin(cfg.JWTSecret))
-		{
-			// Categories
-			protected.POST("/categories", categoryHandler.CreateCategoryGin)
-			protected.GET("/categories/list", categoryHandler.GetCategoriesGin)
-			protected.PUT("/categories/update", categoryHandler.UpdateCategoryGin)
-			protected.DELETE("/categories/delete", categoryHandler.DeleteCategoryGin)
-
-			// Transactions
-			protected.POST("/transactions", transactionHandler.CreateTransactionGin)
-			protected.GET("/transactions/list", transactionHandler.GetTransactionsGin)
-			protected.PUT("/transactions/update", transactionHandler.UpdateTransactionGin)
-			protected.DELETE("/transactions/delete", transactionHandler.DeleteTransactionGin)
-
-			// Analytics
-			protected.GET("/analytics/income-expenses", analyticsHandler.GetIncomeAndExpensesGin)
-			protected.GET("/analytics/categories", analyticsHandler.GetCategoryAnalyticsGin)
-			protected.GET("/analytics/income-expenses-filtered", analyticsHandler.GetIncomeAndExpensesFilteredGin)
-			protected.GET("/analytics/categories-filtered", analyticsHandler.GetCategoryAnalyticsFilteredGin)
+}
+
+// openDB connects to the database selected by DB_DRIVER and loads the
+// migrations written for it.
+func openDB(cfg *config.Config) (*repository.DB, *migrate.Migrator) {
+	var db *repository.DB
+	var files fs.FS
+	var err error
+	switch cfg.DBDriver {
+	case "postgres":
+		if cfg.DatabaseURL == "" && cfg.DBHost == "" {
+			log.Fatal("Missing critical configuration values")
 		}
+		db, err = repository.NewDB(context.Background(), repository.PostgresConfig{
+			URL:             cfg.DatabaseURL,
+			Host:            cfg.DBHost,
+			Port:            cfg.DBPort,
+			User:            cfg.DBUser,
+			Password:        cfg.DBPassword,
+			Name:            cfg.DBName,
+			SSLMode:         cfg.DBSSLMode,
+			SSLRootCert:     cfg.DBSSLRootCert,
+			SSLCert:         cfg.DBSSLCert,
+			SSLKey:          cfg.DBSSLKey,
+			MaxConns:        cfg.DBMaxConns,
+			MinConns:        cfg.DBMinConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+			ConnectTimeout:  cfg.DBConnectTimeout,
+		})
+		files = migrations.FS
+	case "sqlite":
+		db, err = repository.NewSQLiteDB(cfg.DBPath)
+		files = migrations.SQLiteFS
+	default:
+		log.Fatalf("Unknown database driver %q", cfg.DBDriver)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Schema migrations embedded into the binary
+	migrator, err := migrate.New(db.Conn, files)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
+	migrator.Dialect = string(db.Dialect())
+
+	// Pool stats for monitoring, served at /metrics and at /debug/vars when
+	// DEBUG_VARS is set
+	expvar.Publish("db_pool", expvar.Func(func() any { return db.PoolStats() }))
+	metrics.RegisterPool(db)
+	return db, migrator
+}
 
-	// Start server
-	log.Printf("Starting server on port %s", cfg.Port)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// newStorage returns the attachment storage selected by STORAGE_DRIVER.
+func newStorage(cfg *config.Config) storage.BlobStorage {
+	switch cfg.StorageDriver {
+	case "local":
+		blobs, err := storage.NewLocalStorage(cfg.StoragePath)
+		if err != nil {
+			log.Fatalf("Failed to initialize attachment storage: %v", err)
+		}
+		return blobs
+	case "s3":
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			log.Fatal("S3_ENDPOINT and S3_BUCKET are required for the s3 storage driver")
+		}
+		return storage.NewS3Storage(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey)
+	default:
+		log.Fatalf("Unknown storage driver %q", cfg.StorageDriver)
+		return nil
 	}
 }
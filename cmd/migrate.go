@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/migrate"
+)
+
+const usage = "usage: financial-tracker [-demo | migrate up|down|status|redo]"
+
+// runCommand handles the command line subcommands; without any the server starts.
+func runCommand(ctx context.Context, m *migrate.Migrator, args []string) error {
+	if len(args) != 2 || args[0] != "migrate" {
+		return errors.New(usage)
+	}
+
+	switch args[1] {
+	case "up":
+		count, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations, schema is at version %d", count, version)
+	case "down":
+		if _, err := m.Down(ctx); err != nil {
+			return err
+		}
+	case "redo":
+		if _, err := m.Redo(ctx); err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Applied At\tMigration")
+		for _, s := range statuses {
+			appliedAt := "Pending"
+			if !s.AppliedAt.IsZero() {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\n", appliedAt, s.Name)
+		}
+		return w.Flush()
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
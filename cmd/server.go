@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nemopss/financial-tracker/config"
+	"github.com/nemopss/financial-tracker/internal/jobs"
+)
+
+// newServer returns the HTTP server for handler with the timeouts from cfg.
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serve runs srv until ctx is cancelled, then stops accepting connections and
+// waits up to timeout for in-flight requests and the background jobs of
+// workers, which run on ctx too, to finish.
+func serve(ctx context.Context, srv *http.Server, workers *jobs.Workers, timeout time.Duration) error {
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on %s", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %s for requests and background jobs to finish", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to finish in-flight requests: %w", err)
+	}
+	if err := workers.Wait(shutdownCtx); err != nil {
+		return err
+	}
+	log.Printf("Server stopped")
+	return nil
+}
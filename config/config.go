@@ -4,16 +4,68 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	Port       string
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	JWTSecret  string
+	Port string
+
+	// HTTP server timeouts, and how long in-flight requests and background jobs
+	// are given to finish on SIGINT or SIGTERM
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	// DBDriver is "postgres", configured by the DB* fields below, or "sqlite",
+	// which keeps everything in the file at DBPath
+	DBDriver string
+	DBPath   string
+
+	// DatabaseURL, when set, replaces DBHost through DBName
+	DatabaseURL string
+	DBHost      string
+	DBPort      string
+	DBUser      string
+	DBPassword  string
+	DBName      string
+
+	// TLS to Postgres; the certificate paths are optional
+	DBSSLMode     string
+	DBSSLRootCert string
+	DBSSLCert     string
+	DBSSLKey      string
+
+	// Connection pool limits, and how long to retry an unreachable database on start
+	DBMaxConns        int
+	DBMinConns        int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	DBConnectTimeout  time.Duration
+
+	// DebugVars serves runtime and connection pool stats at /debug/vars
+	DebugVars bool
+
+	JWTSecret string
+
+	// Attachment storage: "local" keeps files under StoragePath, "s3" uses an S3-compatible bucket
+	StorageDriver     string
+	StoragePath       string
+	S3Endpoint        string
+	S3Bucket          string
+	S3Region          string
+	S3AccessKey       string
+	S3SecretKey       string
+	MaxAttachmentSize int64
+
+	// Trashed transactions and categories are purged once they are older than TrashRetention
+	TrashRetention time.Duration
+	PurgeInterval  time.Duration
+
+	// AutoMigrate applies pending migrations on start instead of refusing to serve
+	AutoMigrate bool
 }
 
 func LoadConfig() *Config {
@@ -22,13 +74,52 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		Port:       getEnv("PORT", "8080"),
-		DBHost:     getEnv("DB_HOST", "postgres"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", ""),
-		JWTSecret:  getEnv("JWT_SECRET", ""),
+		Port: getEnv("PORT", "8080"),
+
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		DBDriver: getEnv("DB_DRIVER", "postgres"),
+		DBPath:   getEnv("DB_PATH", "./data/financial-tracker.db"),
+
+		DatabaseURL: getEnv("DATABASE_URL", ""),
+		DBHost:      getEnv("DB_HOST", "postgres"),
+		DBPort:      getEnv("DB_PORT", "5432"),
+		DBUser:      getEnv("DB_USER", "postgres"),
+		DBPassword:  getEnv("DB_PASSWORD", ""),
+		DBName:      getEnv("DB_NAME", ""),
+
+		DBSSLMode:     getEnv("DB_SSLMODE", ""),
+		DBSSLRootCert: getEnv("DB_SSLROOTCERT", ""),
+		DBSSLCert:     getEnv("DB_SSLCERT", ""),
+		DBSSLKey:      getEnv("DB_SSLKEY", ""),
+
+		DBMaxConns:        getEnvInt("DB_MAX_CONNS", 25),
+		DBMinConns:        getEnvInt("DB_MIN_CONNS", 2),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		DBConnectTimeout:  getEnvDuration("DB_CONNECT_TIMEOUT", 30*time.Second),
+
+		DebugVars: getEnvBool("DEBUG_VARS", false),
+
+		JWTSecret: getEnv("JWT_SECRET", ""),
+
+		StorageDriver:     getEnv("STORAGE_DRIVER", "local"),
+		StoragePath:       getEnv("STORAGE_PATH", "./data/attachments"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+		MaxAttachmentSize: getEnvInt64("MAX_ATTACHMENT_SIZE", 10<<20),
+
+		TrashRetention: getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval:  getEnvDuration("PURGE_INTERVAL", time.Hour),
+
+		AutoMigrate: getEnvBool("AUTO_MIGRATE", false),
 	}
 }
 
@@ -38,3 +129,55 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Invalid value for %s, using default %d", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvInt64(key string, fallback int64) int64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %d", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Invalid value for %s, using default %s", key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s, using default %t", key, fallback)
+		return fallback
+	}
+	return parsed
+}
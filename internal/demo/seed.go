@@ -0,0 +1,127 @@
+// Package demo fills a repository with sample data, so the API can be tried
+// out without a database.
+package demo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Credentials of the user created by Seed.
+const (
+	Username = "demo"
+	Password = "demo1234"
+)
+
+// Months is how many calendar months of transactions Seed creates, the current
+// one included.
+const Months = 3
+
+// splitDay is the day of the monthly purchase that Seed splits.
+const splitDay = 24
+
+// entry is a transaction repeated every seeded month on the given day.
+type entry struct {
+	day         int
+	amount      float64
+	description string
+	category    string
+	tags        []string
+}
+
+var (
+	categories = []string{"Salary", "Rent", "Groceries", "Transport", "Eating out", "Entertainment"}
+	tags       = []string{"family", "work", "subscription"}
+
+	monthly = []entry{
+		{1, 2500, "Salary", "Salary", []string{"work"}},
+		{2, -900, "Rent", "Rent", []string{"family"}},
+		{3, -64.30, "Supermarket", "Groceries", []string{"family"}},
+		{5, -11.99, "Streaming service", "Entertainment", []string{"subscription"}},
+		{8, -45, "Monthly transit pass", "Transport", []string{"work"}},
+		{10, -82.15, "Supermarket", "Groceries", []string{"family"}},
+		{12, -23.50, "Lunch with colleagues", "Eating out", []string{"work"}},
+		{17, -71.40, "Supermarket", "Groceries", []string{"family"}},
+		{20, -38, "Cinema", "Entertainment", nil},
+		{24, -58.75, "Supermarket", "Groceries", []string{"family"}},
+		{27, -46.20, "Pizza night", "Eating out", []string{"family"}},
+	}
+)
+
+// Seed creates the demo user with categories, tags and Months of transactions
+// up to now; entries dated after now are left out. One purchase a month is
+// split between groceries and household entertainment. It returns the ID of
+// the demo user.
+func Seed(ctx context.Context, repo repository.Repository, now time.Time) (int, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID int
+	err = repo.WithTx(ctx, func(r repository.Repository) error {
+		userID, err = r.CreateUser(ctx, Username, string(hash))
+		if err != nil {
+			return err
+		}
+
+		categoryIDs := map[string]int{}
+		for _, name := range categories {
+			if categoryIDs[name], err = r.CreateCategory(ctx, userID, name); err != nil {
+				return err
+			}
+		}
+		tagIDs := map[string]int{}
+		for _, name := range tags {
+			if tagIDs[name], err = r.CreateTag(ctx, userID, name); err != nil {
+				return err
+			}
+		}
+
+		year, month, _ := now.Date()
+		for i := Months - 1; i >= 0; i-- {
+			first := time.Date(year, month-time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+			for _, e := range monthly {
+				date := first.AddDate(0, 0, e.day-1)
+				if date.After(now) {
+					break
+				}
+
+				var ids []int
+				for _, tag := range e.tags {
+					ids = append(ids, tagIDs[tag])
+				}
+				id, err := r.CreateTransaction(ctx, repository.Transaction{
+					Amount:      e.amount,
+					Date:        date,
+					Description: e.description,
+					CategoryID:  categoryIDs[e.category],
+					UserID:      userID,
+					TagIDs:      ids,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to seed %s: %w", e.description, err)
+				}
+
+				if e.day == splitDay {
+					err := r.SetSplits(ctx, userID, id, []repository.Split{
+						{CategoryID: categoryIDs["Groceries"], Amount: e.amount + 15, Note: "Food"},
+						{CategoryID: categoryIDs["Entertainment"], Amount: -15, Note: "Board game"},
+					}, 0)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,56 @@
+package demo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepo()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	userID, err := Seed(ctx, repo, now)
+	assert.NoError(t, err)
+
+	user, err := repo.GetUserByUsername(ctx, Username)
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, userID, user.ID)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(Password)))
+	}
+
+	categories, err := repo.GetCategories(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, categories, 6)
+	tags, err := repo.GetTags(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, tags, 3)
+
+	// August and September in full, October up to the 15th
+	transactions, err := repo.GetTransactions(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 2*len(monthly)+7)
+	for _, txn := range transactions {
+		assert.False(t, txn.Date.After(now), "transaction %d is dated after now", txn.ID)
+		assert.False(t, txn.Date.Before(time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)), "transaction %d is dated too early", txn.ID)
+	}
+
+	october, err := repo.GetIncomeAndExpensesFiltered(ctx, userID, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), now)
+	assert.NoError(t, err)
+	assert.Equal(t, &repository.Analytics{TotalIncome: 2500, TotalExpense: -1126.94}, october)
+
+	september, err := repo.GetCategoryAnalyticsFiltered(ctx, userID, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Contains(t, september, repository.CategoryAnalytics{CategoryName: "Entertainment", TotalAmount: -64.99})
+	assert.Contains(t, september, repository.CategoryAnalytics{CategoryName: "Groceries", TotalAmount: -261.6})
+
+	// The demo user exists already
+	_, err = Seed(ctx, repo, now)
+	assert.ErrorIs(t, err, repository.ErrConflict)
+}
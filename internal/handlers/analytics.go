@@ -26,7 +26,7 @@ func (h *AnalyticsHandler) GetIncomeAndExpensesGin(c *gin.Context) {
 
 	analytics, err := h.Repo.GetIncomeAndExpenses(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+		fail(c, err, "Failed to fetch analytics")
 		return
 	}
 
@@ -39,24 +39,21 @@ func (h *AnalyticsHandler) GetIncomeAndExpensesGin(c *gin.Context) {
 // @Tags Analytics
 // @Produce json
 // @Security BearerAuth
-// @Param start_date query string true "Start date in YYYY-MM-DD format"
-// @Param end_date query string true "End date in YYYY-MM-DD format"
+// @Param start_date query string true "First day in YYYY-MM-DD format, in the timezone of the user"
+// @Param end_date query string true "Last day (inclusive) in YYYY-MM-DD format, in the timezone of the user"
 // @Success 200 {object} repository.Analytics
 // @Router /analytics/income-expenses-filtered [get]
 func (h *AnalyticsHandler) GetIncomeAndExpensesFilteredGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-
-	if startDate == "" || endDate == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Start date and end date are required"})
+	from, to, ok := dayRange(c, h.Repo, userID)
+	if !ok {
 		return
 	}
 
-	analytics, err := h.Repo.GetIncomeAndExpensesFiltered(c.Request.Context(), userID, startDate, endDate)
+	analytics, err := h.Repo.GetIncomeAndExpensesFiltered(c.Request.Context(), userID, from, to)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch filtered analytics"})
+		fail(c, err, "Failed to fetch filtered analytics")
 		return
 	}
 
@@ -65,7 +62,7 @@ func (h *AnalyticsHandler) GetIncomeAndExpensesFilteredGin(c *gin.Context) {
 
 // GetCategoryAnalyticsGin handles fetching category-based analytics.
 // @Summary Get category analytics
-// @Description Fetch total expenses per category for the authenticated user
+// @Description Fetch total expenses per category for the authenticated user. Split transactions are counted per split line.
 // @Tags Analytics
 // @Produce json
 // @Security BearerAuth
@@ -76,7 +73,7 @@ func (h *AnalyticsHandler) GetCategoryAnalyticsGin(c *gin.Context) {
 
 	analytics, err := h.Repo.GetCategoryAnalytics(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category analytics"})
+		fail(c, err, "Failed to fetch category analytics")
 		return
 	}
 
@@ -85,28 +82,52 @@ func (h *AnalyticsHandler) GetCategoryAnalyticsGin(c *gin.Context) {
 
 // GetCategoryAnalyticsFilteredGin handles fetching category-based analytics within a date range.
 // @Summary Get category analytics (filtered)
-// @Description Fetch total expenses per category within a specific date range for the authenticated user
+// @Description Fetch total expenses per category within a specific date range for the authenticated user. Split transactions are counted per split line.
 // @Tags Analytics
 // @Produce json
 // @Security BearerAuth
-// @Param start_date query string true "Start date in YYYY-MM-DD format"
-// @Param end_date query string true "End date in YYYY-MM-DD format"
+// @Param start_date query string true "First day in YYYY-MM-DD format, in the timezone of the user"
+// @Param end_date query string true "Last day (inclusive) in YYYY-MM-DD format, in the timezone of the user"
 // @Success 200 {array} repository.CategoryAnalytics
 // @Router /analytics/categories-filtered [get]
 func (h *AnalyticsHandler) GetCategoryAnalyticsFilteredGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
+	from, to, ok := dayRange(c, h.Repo, userID)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.Repo.GetCategoryAnalyticsFiltered(c.Request.Context(), userID, from, to)
+	if err != nil {
+		fail(c, err, "Failed to fetch category analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetTagAnalyticsFilteredGin handles fetching tag-based analytics within a date range.
+// @Summary Get tag analytics (filtered)
+// @Description Fetch total amounts per tag within a specific date range for the authenticated user. A transaction with several tags counts towards each of them.
+// @Tags Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string true "First day in YYYY-MM-DD format, in the timezone of the user"
+// @Param end_date query string true "Last day (inclusive) in YYYY-MM-DD format, in the timezone of the user"
+// @Success 200 {array} repository.TagAnalytics
+// @Router /analytics/tags [get]
+func (h *AnalyticsHandler) GetTagAnalyticsFilteredGin(c *gin.Context) {
+	userID := c.GetInt("userID")
 
-	if startDate == "" || endDate == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Start date and end date are required"})
+	from, to, ok := dayRange(c, h.Repo, userID)
+	if !ok {
 		return
 	}
 
-	analytics, err := h.Repo.GetCategoryAnalyticsFiltered(c.Request.Context(), userID, startDate, endDate)
+	analytics, err := h.Repo.GetTagAnalyticsFiltered(c.Request.Context(), userID, from, to)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category analytics"})
+		fail(c, err, "Failed to fetch tag analytics")
 		return
 	}
 
@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/nemopss/financial-tracker/internal/middleware"
 	"github.com/nemopss/financial-tracker/internal/repository"
 	"github.com/stretchr/testify/assert"
@@ -22,25 +23,24 @@ func TestGetIncomeAndExpensesHandler(t *testing.T) {
 	handler := &AnalyticsHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/income-expenses", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.GetIncomeAndExpenses(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetIncomeAndExpensesGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int                  `json:"status_code"`
-		Data       repository.Analytics `json:"data"`
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data repository.Analytics
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Equal(t, 1000.0, wrappedResponse.Data.TotalIncome)
-	assert.Equal(t, -500.0, wrappedResponse.Data.TotalExpense)
+	assert.Equal(t, 1000.0, data.TotalIncome)
+	assert.Equal(t, -500.0, data.TotalExpense)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -48,31 +48,34 @@ func TestGetIncomeAndExpensesHandler(t *testing.T) {
 func TestGetIncomeAndExpensesFilteredHandler(t *testing.T) {
 	mockRepo := &repository.MockRepo{}
 
-	mockRepo.On("GetIncomeAndExpensesFiltered", mock.Anything, 1, "2024-01-01", "2024-12-31").
+	// Whole days in Moscow time, end day included
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("Europe/Moscow", nil)
+	mockRepo.On("GetIncomeAndExpensesFiltered", mock.Anything, 1,
+		mock.MatchedBy(func(from time.Time) bool { return from.Equal(time.Date(2023, 12, 31, 21, 0, 0, 0, time.UTC)) }),
+		mock.MatchedBy(func(to time.Time) bool { return to.Equal(time.Date(2024, 12, 31, 21, 0, 0, 0, time.UTC)) })).
 		Return(&repository.Analytics{TotalIncome: 5000, TotalExpense: -2500}, nil)
 
 	handler := &AnalyticsHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/income-expenses-filtered?start_date=2024-01-01&end_date=2024-12-31", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.GetIncomeAndExpensesFiltered(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetIncomeAndExpensesFilteredGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int                  `json:"status_code"`
-		Data       repository.Analytics `json:"data"`
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data repository.Analytics
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Equal(t, 5000.0, wrappedResponse.Data.TotalIncome)
-	assert.Equal(t, -2500.0, wrappedResponse.Data.TotalExpense)
+	assert.Equal(t, 5000.0, data.TotalIncome)
+	assert.Equal(t, -2500.0, data.TotalExpense)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -91,28 +94,27 @@ func TestGetCategoryAnalyticsHandler(t *testing.T) {
 	handler := &AnalyticsHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/categories", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.GetCategoryAnalytics(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoryAnalyticsGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int                            `json:"status_code"`
-		Data       []repository.CategoryAnalytics `json:"data"`
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data []repository.CategoryAnalytics
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Len(t, wrappedResponse.Data, 2)
-	assert.Equal(t, "Groceries", wrappedResponse.Data[0].CategoryName)
-	assert.Equal(t, -200.50, wrappedResponse.Data[0].TotalAmount)
-	assert.Equal(t, "Entertainment", wrappedResponse.Data[1].CategoryName)
-	assert.Equal(t, -100.00, wrappedResponse.Data[1].TotalAmount)
+	assert.Len(t, data, 2)
+	assert.Equal(t, "Groceries", data[0].CategoryName)
+	assert.Equal(t, -200.50, data[0].TotalAmount)
+	assert.Equal(t, "Entertainment", data[1].CategoryName)
+	assert.Equal(t, -100.00, data[1].TotalAmount)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -125,34 +127,94 @@ func TestGetCategoryAnalyticsFilteredHandler(t *testing.T) {
 		{CategoryName: "Entertainment", TotalAmount: -200.00},
 	}
 
-	mockRepo.On("GetCategoryAnalyticsFiltered", mock.Anything, 1, "2024-01-01", "2024-12-31").
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("UTC", nil)
+	mockRepo.On("GetCategoryAnalyticsFiltered", mock.Anything, 1,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).
 		Return(mockAnalytics, nil)
 
 	handler := &AnalyticsHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/categories-filtered?start_date=2024-01-01&end_date=2024-12-31", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.GetCategoryAnalyticsFiltered(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoryAnalyticsFilteredGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int                            `json:"status_code"`
-		Data       []repository.CategoryAnalytics `json:"data"`
+	var data []repository.CategoryAnalytics
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data, 2)
+	assert.Equal(t, "Groceries", data[0].CategoryName)
+	assert.Equal(t, -500.00, data[0].TotalAmount)
+	assert.Equal(t, "Entertainment", data[1].CategoryName)
+	assert.Equal(t, -200.00, data[1].TotalAmount)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTagAnalyticsFilteredHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockAnalytics := []repository.TagAnalytics{
+		{TagName: "reimbursable", TotalAmount: -80.00},
+		{TagName: "vacation-2025", TotalAmount: -1200.00},
 	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("UTC", nil)
+	mockRepo.On("GetTagAnalyticsFiltered", mock.Anything, 1,
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).
+		Return(mockAnalytics, nil)
+
+	handler := &AnalyticsHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/tags?start_date=2024-01-01&end_date=2024-12-31", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTagAnalyticsFilteredGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data []repository.TagAnalytics
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Len(t, wrappedResponse.Data, 2)
-	assert.Equal(t, "Groceries", wrappedResponse.Data[0].CategoryName)
-	assert.Equal(t, -500.00, wrappedResponse.Data[0].TotalAmount)
-	assert.Equal(t, "Entertainment", wrappedResponse.Data[1].CategoryName)
-	assert.Equal(t, -200.00, wrappedResponse.Data[1].TotalAmount)
+	assert.Len(t, data, 2)
+	assert.Equal(t, "vacation-2025", data[1].TagName)
+	assert.Equal(t, -1200.00, data[1].TotalAmount)
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetIncomeAndExpensesFilteredHandlerInvalidRange(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("UTC", nil)
+
+	handler := &AnalyticsHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics/income-expenses-filtered?start_date=2024-12-31&end_date=2024-01-01", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetIncomeAndExpensesFilteredGin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "GetIncomeAndExpensesFiltered", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
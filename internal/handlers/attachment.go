@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/storage"
+)
+
+// DefaultMaxAttachmentSize is used when AttachmentHandler.MaxSize is not set.
+const DefaultMaxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes lists the sniffed content types accepted for upload.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+}
+
+type AttachmentHandler struct {
+	Repo    repository.Repository
+	Storage storage.BlobStorage
+	MaxSize int64
+}
+
+// Models for Swagger documentation
+
+type AttachmentListResponse struct {
+	Attachments []repository.Attachment `json:"attachments"`
+}
+
+// Handlers
+
+// UploadAttachmentGin handles uploading a file for a transaction.
+// @Summary Upload an attachment
+// @Description Attach a receipt or document to a transaction of the authenticated user. The content type is detected from the file contents; PDF, JPEG, PNG, GIF, WebP and plain text are accepted. Also served by the deprecated POST /transactions/attachments?id=.
+// @Tags Attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} repository.Attachment
+// @Failure 404 {object} response.Problem
+// @Failure 413 {object} response.Problem
+// @Failure 415 {object} response.Problem
+// @Router /transactions/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachmentGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	// Refuse before storing a blob that no transaction would own
+	ctx := c.Request.Context()
+	txn, err := h.Repo.GetTransaction(ctx, userID, txnID)
+	if err != nil {
+		fail(c, err, "Failed to fetch transaction")
+		return
+	}
+	if txn == nil {
+		abort(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	maxSize := h.maxSize()
+	// Leave some room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			abort(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d byte limit", maxSize))
+			return
+		}
+		abort(c, http.StatusBadRequest, "File is required")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid file")
+		return
+	}
+	if int64(len(data)) > maxSize {
+		abort(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File exceeds the %d byte limit", maxSize))
+		return
+	}
+	if len(data) == 0 {
+		abort(c, http.StatusBadRequest, "File is empty")
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !allowedAttachmentTypes[contentType] {
+		abort(c, http.StatusUnsupportedMediaType, fmt.Sprintf("Files of type %s are not allowed", contentType))
+		return
+	}
+
+	key, err := attachmentKey(userID, txnID)
+	if err != nil {
+		fail(c, err, "Internal server error")
+		return
+	}
+
+	if err := h.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		fail(c, err, "Failed to store attachment")
+		return
+	}
+
+	attachment := repository.Attachment{
+		TransactionID: txnID,
+		UserID:        userID,
+		Filename:      fileHeader.Filename,
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		StorageKey:    key,
+	}
+	attachment.ID, err = h.Repo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		if err := h.Storage.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove orphaned attachment %s: %v", key, err)
+		}
+		fail(c, err, "Failed to save attachment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachmentsGin handles listing the attachments of a transaction.
+// @Summary Get attachments
+// @Description Fetch the attachments of a transaction owned by the authenticated user. Also served by the deprecated GET /transactions/attachments?id=.
+// @Tags Attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} AttachmentListResponse
+// @Failure 404 {object} response.Problem
+// @Router /transactions/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachmentsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	attachments, err := h.Repo.GetAttachments(c.Request.Context(), userID, txnID)
+	if err != nil {
+		fail(c, err, "Failed to fetch attachments")
+		return
+	}
+
+	c.JSON(http.StatusOK, AttachmentListResponse{Attachments: attachments})
+}
+
+// DownloadAttachmentGin handles downloading an attachment.
+// @Summary Download an attachment
+// @Description Download the file of an attachment owned by the authenticated user. Also served by the deprecated GET /attachments/download?id=.
+// @Tags Attachments
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} response.Problem
+// @Router /attachments/{id} [get]
+func (h *AttachmentHandler) DownloadAttachmentGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	attachmentID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	attachment, err := h.Repo.GetAttachment(c.Request.Context(), userID, attachmentID)
+	if err != nil {
+		fail(c, err, "Failed to fetch attachment")
+		return
+	}
+	if attachment == nil {
+		abort(c, http.StatusNotFound, "Attachment not found")
+		return
+	}
+
+	blob, err := h.Storage.Get(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		abort(c, http.StatusNotFound, "Attachment file not found")
+		return
+	}
+	if err != nil {
+		fail(c, err, "Failed to read attachment")
+		return
+	}
+	defer blob.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachmentGin handles deleting an attachment.
+// @Summary Delete an attachment
+// @Description Delete an attachment and its file for the authenticated user. Also served by the deprecated DELETE /attachments/delete?id=.
+// @Tags Attachments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Attachment ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} response.Problem
+// @Router /attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachmentGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	attachmentID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	ctx := c.Request.Context()
+	attachment, err := h.Repo.GetAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		fail(c, err, "Failed to fetch attachment")
+		return
+	}
+	if attachment == nil {
+		abort(c, http.StatusNotFound, "Attachment not found")
+		return
+	}
+
+	if err := h.Repo.DeleteAttachment(ctx, userID, attachmentID); err != nil {
+		fail(c, err, "Failed to delete attachment")
+		return
+	}
+
+	removeAttachmentBlobs(ctx, h.Storage, []repository.Attachment{*attachment})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AttachmentHandler) maxSize() int64 {
+	if h.MaxSize > 0 {
+		return h.MaxSize
+	}
+	return DefaultMaxAttachmentSize
+}
+
+// attachmentKey builds a unique, user-scoped storage key for a new attachment.
+func attachmentKey(userID, txnID int) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("users/%d/transactions/%d/%s", userID, txnID, hex.EncodeToString(buf)), nil
+}
+
+// removeAttachmentBlobs deletes the stored files of attachments whose rows are
+// already gone. Failures only leave orphaned blobs behind, so they are logged.
+func removeAttachmentBlobs(ctx context.Context, blobs storage.BlobStorage, attachments []repository.Attachment) {
+	if blobs == nil {
+		return
+	}
+	for _, a := range attachments {
+		if err := blobs.Delete(ctx, a.StorageKey); err != nil {
+			log.Printf("Failed to delete attachment file %s: %v", a.StorageKey, err)
+		}
+	}
+}
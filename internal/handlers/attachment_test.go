@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newUploadRequest(t *testing.T, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/5/attachments", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadAttachmentHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	blobs, _ := storage.NewLocalStorage(t.TempDir())
+
+	mockRepo.On("GetTransaction", mock.Anything, 1, 5).Return(&repository.Transaction{ID: 5, UserID: 1}, nil)
+	var storedKey string
+	mockRepo.On("CreateAttachment", mock.Anything, mock.MatchedBy(func(a repository.Attachment) bool {
+		storedKey = a.StorageKey
+		return a.TransactionID == 5 && a.UserID == 1 &&
+			a.Filename == "receipt.pdf" && a.ContentType == "application/pdf" &&
+			strings.HasPrefix(a.StorageKey, "users/1/transactions/5/")
+	})).Return(3, nil)
+
+	handler := &AttachmentHandler{Repo: mockRepo, Storage: blobs}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newUploadRequest(t, "receipt.pdf", []byte("%PDF-1.4\n%receipt"))
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UploadAttachmentGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var data repository.Attachment
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, 3, data.ID)
+
+	stored, err := blobs.Get(context.Background(), storedKey)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(stored)
+	stored.Close()
+	assert.Equal(t, "%PDF-1.4\n%receipt", string(content))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUploadAttachmentHandlerRejectsType(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetTransaction", mock.Anything, 1, 5).Return(&repository.Transaction{ID: 5, UserID: 1}, nil)
+	blobs, _ := storage.NewLocalStorage(t.TempDir())
+	handler := &AttachmentHandler{Repo: mockRepo, Storage: blobs}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	// The file name claims PDF but the content is a Windows executable
+	c.Request = newUploadRequest(t, "receipt.pdf", append([]byte("MZ"), make([]byte, 64)...))
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UploadAttachmentGin)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Result().StatusCode)
+}
+
+func TestUploadAttachmentHandlerTooLarge(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetTransaction", mock.Anything, 1, 5).Return(&repository.Transaction{ID: 5, UserID: 1}, nil)
+	blobs, _ := storage.NewLocalStorage(t.TempDir())
+	handler := &AttachmentHandler{Repo: mockRepo, Storage: blobs, MaxSize: 16}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newUploadRequest(t, "notes.txt", []byte(strings.Repeat("a", 17)))
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UploadAttachmentGin)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode)
+}
+
+func TestUploadAttachmentHandlerTransactionNotFound(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetTransaction", mock.Anything, 2, 5).Return(nil, nil)
+
+	dir := t.TempDir()
+	blobs, _ := storage.NewLocalStorage(dir)
+	handler := &AttachmentHandler{Repo: mockRepo, Storage: blobs}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = newUploadRequest(t, "receipt.pdf", []byte("%PDF-1.4\n%receipt"))
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 2)
+	serve(c, handler.UploadAttachmentGin)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	stored, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, stored, "no file is stored for a missing transaction")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetAttachmentsHandlerNotFound(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetAttachments", mock.Anything, 2, 5).
+		Return(nil, &repository.NotFoundError{Entity: repository.EntityTransaction})
+
+	handler := &AttachmentHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/transactions/5/attachments", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 2)
+	serve(c, handler.GetAttachmentsGin)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDownloadAttachmentHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	blobs, _ := storage.NewLocalStorage(t.TempDir())
+	blobs.Put(context.Background(), "users/1/transactions/5/abc", strings.NewReader("hello"), 5, "text/plain")
+
+	mockRepo.On("GetAttachment", mock.Anything, 1, 3).Return(&repository.Attachment{
+		ID: 3, TransactionID: 5, UserID: 1, Filename: "notes.txt",
+		ContentType: "text/plain", Size: 5, StorageKey: "users/1/transactions/5/abc",
+	}, nil)
+
+	handler := &AttachmentHandler{Repo: mockRepo, Storage: blobs}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/attachments/3", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DownloadAttachmentGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=notes.txt`, resp.Header.Get("Content-Disposition"))
+	content, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello", string(content))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDownloadAttachmentHandlerOtherUser(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetAttachment", mock.Anything, 2, 3).Return(nil, nil)
+
+	handler := &AttachmentHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/attachments/3", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Set(middleware.UserIDKey, 2)
+	serve(c, handler.DownloadAttachmentGin)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
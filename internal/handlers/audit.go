@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+// Page size limits of the audit log.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+type AuditHandler struct {
+	Repo repository.Repository
+}
+
+// Models for Swagger documentation
+
+type AuditLogResponse struct {
+	Entries []repository.AuditEntry `json:"entries"`
+}
+
+// Handlers
+
+// GetAuditLogGin handles fetching the audit log of the user.
+// @Summary Get audit log
+// @Description Fetch the changes made to the data of the authenticated user, newest first
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param entity query string false "Entity kind" Enums(user, category, transaction, split, tag, transaction_tags, attachment)
+// @Param entity_id query int false "Entity ID"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge, undo)
+// @Param request_id query string false "ID of the request that made the change"
+// @Param from query string false "First day in YYYY-MM-DD format, in the timezone of the user"
+// @Param to query string false "Last day (inclusive) in YYYY-MM-DD format, in the timezone of the user"
+// @Param limit query int false "Page size (default 100, max 1000)"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} AuditLogResponse
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditLogGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	filter := repository.AuditFilter{
+		Entity:    c.Query("entity"),
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+		Limit:     DefaultAuditLimit,
+	}
+
+	var err error
+	if v := c.Query("entity_id"); v != "" {
+		if filter.EntityID, err = strconv.Atoi(v); err != nil {
+			abort(c, http.StatusBadRequest, "Invalid entity ID")
+			return
+		}
+	}
+	if from, to := c.Query("from"), c.Query("to"); from != "" || to != "" {
+		loc, err := userLocation(c.Request.Context(), h.Repo, userID)
+		if err != nil {
+			fail(c, err, "Failed to load user timezone")
+			return
+		}
+		if from != "" {
+			if filter.From, err = time.ParseInLocation(validation.DateLayout, from, loc); err != nil {
+				abort(c, http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+				return
+			}
+		}
+		if to != "" {
+			if filter.To, err = time.ParseInLocation(validation.DateLayout, to, loc); err != nil {
+				abort(c, http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+				return
+			}
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 || filter.Limit > MaxAuditLimit {
+			abort(c, http.StatusBadRequest, "Limit must be between 1 and 1000")
+			return
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil || filter.Offset < 0 {
+			abort(c, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	entries, err := h.Repo.GetAuditLog(c.Request.Context(), userID, filter)
+	if err != nil {
+		fail(c, err, "Failed to fetch audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditLogResponse{Entries: entries})
+}
+
+// GetTransactionAuditGin handles fetching the change history of a transaction.
+// @Summary Get transaction audit history
+// @Description Fetch the audit entries of a transaction, its splits, tags and attachments, newest first. Also served by the deprecated GET /transactions/audit?id=.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} AuditLogResponse
+// @Router /transactions/{id}/audit [get]
+func (h *AuditHandler) GetTransactionAuditGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	entries, err := h.Repo.GetTransactionAudit(c.Request.Context(), userID, txnID)
+	if err != nil {
+		fail(c, err, "Failed to fetch transaction history")
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditLogResponse{Entries: entries})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetAuditLogHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	filter := repository.AuditFilter{
+		Entity: repository.EntityTransaction,
+		Action: repository.AuditDelete,
+		From:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+		Limit:  20,
+	}
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("UTC", nil)
+	mockRepo.On("GetAuditLog", mock.Anything, 1, filter).
+		Return([]repository.AuditEntry{
+			{ID: 4, Entity: repository.EntityTransaction, EntityID: 7, Action: repository.AuditDelete, Before: json.RawMessage(`{"id":7}`)},
+		}, nil)
+
+	handler := &AuditHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?entity=transaction&action=delete&from=2026-10-01&to=2026-10-19&limit=20", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetAuditLogGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data AuditLogResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data.Entries, 1)
+	assert.Equal(t, 7, data.Entries[0].EntityID)
+	assert.JSONEq(t, `{"id":7}`, string(data.Entries[0].Before))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetAuditLogHandlerInvalidLimit(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &AuditHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?limit=5000", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetAuditLogGin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "GetAuditLog", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetTransactionAuditHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTransactionAudit", mock.Anything, 1, 7).
+		Return([]repository.AuditEntry{
+			{ID: 2, Entity: repository.EntityTransaction, EntityID: 7, Action: repository.AuditUpdate},
+			{ID: 1, Entity: repository.EntityTransaction, EntityID: 7, Action: repository.AuditCreate},
+		}, nil)
+
+	handler := &AuditHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/7/audit", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionAuditGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data AuditLogResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data.Entries, 2)
+	assert.Equal(t, repository.AuditCreate, data.Entries[1].Action)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -3,9 +3,9 @@ package handlers
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/nemopss/financial-tracker/internal/metrics"
 	"github.com/nemopss/financial-tracker/internal/repository"
 	"golang.org/x/crypto/bcrypt"
-	"log"
 	"net/http"
 	"time"
 )
@@ -18,7 +18,7 @@ type AuthHandler struct {
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,name"`
 	Password string `json:"password" binding:"required"`
 }
 
@@ -41,29 +41,27 @@ type LoginResponse struct {
 // @Produce json
 // @Param registerRequest body RegisterRequest true "Registration data"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.Problem
+// @Failure 500 {object} response.Problem
 // @Router /auth/register [post]
 func (h *AuthHandler) RegisterGin(c *gin.Context) {
 	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Failed to hash password: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		fail(c, err, "Internal server error")
 		return
 	}
 
 	id, err := h.Repo.CreateUser(c.Request.Context(), req.Username, string(hashedPassword))
 	if err != nil {
-		log.Printf("Failed to create user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		fail(c, err, "Failed to create user")
 		return
 	}
+	metrics.UsersRegistered.Inc()
 
 	c.JSON(http.StatusCreated, gin.H{
 		"id":       id,
@@ -79,32 +77,32 @@ func (h *AuthHandler) RegisterGin(c *gin.Context) {
 // @Produce json
 // @Param loginRequest body LoginRequest true "Login data"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 500 {object} response.Problem
 // @Router /auth/login [post]
 func (h *AuthHandler) LoginGin(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	user, err := h.Repo.GetUserByUsername(c.Request.Context(), req.Username)
 	if err != nil || user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		metrics.LoginsFailed.Inc()
+		abort(c, http.StatusUnauthorized, "Invalid username or password")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		metrics.LoginsFailed.Inc()
+		abort(c, http.StatusUnauthorized, "Invalid username or password")
 		return
 	}
 
 	token, err := generateJWT(user.ID, h.JWTSecret)
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		fail(c, err, "Internal server error")
 		return
 	}
 
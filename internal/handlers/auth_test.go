@@ -7,7 +7,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/metrics"
 	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -32,22 +36,21 @@ func TestRegisterHandler(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	handler.Register(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	serve(c, handler.RegisterGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int
-		Data       map[string]interface{}
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data map[string]interface{}
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Equal(t, float64(1), wrappedResponse.Data["id"].(float64))
-	assert.Equal(t, "testuser", wrappedResponse.Data["username"])
+	assert.Equal(t, float64(1), data["id"].(float64))
+	assert.Equal(t, "testuser", data["username"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -65,10 +68,24 @@ func TestRegisterHandlerInvalidPayload(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	handler.Register(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	serve(c, handler.RegisterGin)
 
 	resp := w.Result()
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, response.ProblemContentType, resp.Header.Get("Content-Type"))
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Every violation is reported, not just the first one
+	assert.Len(t, data.InvalidParams, 2)
+	assert.Equal(t, "username", data.InvalidParams[0].Name)
+	assert.Equal(t, "password", data.InvalidParams[1].Name)
 }
 
 func TestLoginHandler(t *testing.T) {
@@ -99,23 +116,22 @@ func TestLoginHandler(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	handler.Login(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	serve(c, handler.LoginGin)
 
 	resp := w.Result()
 
 	// Проверяем статус-код
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int
-		Data       map[string]string
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data map[string]string
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.NotEmpty(t, wrappedResponse.Data["token"])
+	assert.NotEmpty(t, data["token"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -146,11 +162,16 @@ func TestLoginHandlerInvalidPassword(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
 
+	failed := testutil.ToFloat64(metrics.LoginsFailed)
+
 	w := httptest.NewRecorder()
-	handler.Login(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	serve(c, handler.LoginGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, failed+1, testutil.ToFloat64(metrics.LoginsFailed))
 
 	mockRepo.AssertExpectations(t)
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/metrics"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+// Bulk execution modes
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// MaxBulkOperations limits the number of operations accepted in one bulk
+// request.
+const MaxBulkOperations = 1000
+
+// Models for Swagger documentation
+
+type BulkOperationRequest struct {
+	Op          string  `json:"op" binding:"required,oneof=create update delete recategorise" example:"create" enums:"create,update,delete,recategorise"`
+	ID          int     `json:"id,omitempty" example:"1"`
+	Amount      float64 `json:"amount,omitempty" example:"100.50"`
+	Date        string  `json:"date,omitempty" binding:"omitempty,date" example:"2024-12-01T15:04:05Z"`
+	Description string  `json:"description,omitempty" example:"Grocery shopping"`
+	CategoryID  int     `json:"category_id,omitempty" example:"1"`
+	// ExpectedVersion makes update, delete and recategorise operations fail
+	// unless the transaction is still at that version; 0 matches any version.
+	ExpectedVersion int `json:"expected_version,omitempty" example:"3"`
+}
+
+type BulkTransactionsRequest struct {
+	Mode       string                 `json:"mode,omitempty" binding:"omitempty,oneof=atomic best_effort" example:"atomic" enums:"atomic,best_effort"`
+	Operations []BulkOperationRequest `json:"operations" binding:"required,min=1,dive"`
+}
+
+type BulkTransactionsResponse struct {
+	Mode       string                  `json:"mode" example:"atomic"`
+	RolledBack bool                    `json:"rolled_back"`
+	Results    []repository.BulkResult `json:"results"`
+}
+
+// Handlers
+
+// BulkTransactionsGin applies several transaction operations in one request.
+// @Summary Bulk transaction operations
+// @Description Create, update, delete or recategorise many transactions at once. In "atomic" mode (default) either every operation is applied or none is; in "best_effort" mode each operation is applied independently and reported in its own result.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkTransactionsRequest true "Bulk operations"
+// @Success 200 {object} BulkTransactionsResponse
+// @Failure 400 {object} response.Problem
+// @Failure 422 {object} BulkTransactionsResponse
+// @Router /transactions/bulk [post]
+func (h *TransactionHandler) BulkTransactionsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	ctx := c.Request.Context()
+	var req BulkTransactionsRequest
+	if !bindJSON(c, &req, func(v *validation.Validator) {
+		v.Var("operations", req.Operations, "max="+strconv.Itoa(MaxBulkOperations))
+		for i, item := range req.Operations {
+			field := fmt.Sprintf("operations[%d]", i)
+			switch item.Op {
+			case repository.BulkOpCreate, repository.BulkOpUpdate:
+				v.Check(item.Amount != 0, field+".amount", "must not be zero")
+				v.Category(ctx, h.Repo, userID, field+".category_id", item.CategoryID)
+			case repository.BulkOpRecategorise:
+				v.Category(ctx, h.Repo, userID, field+".category_id", item.CategoryID)
+			}
+		}
+	}) {
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = BulkModeAtomic
+	}
+
+	ops := make([]repository.BulkOperation, len(req.Operations))
+	for i, item := range req.Operations {
+		// New transactions default to now, updates keep the stored date
+		var date time.Time
+		if item.Op == repository.BulkOpCreate {
+			date = time.Now()
+		}
+		if item.Date != "" {
+			parsed, err := parseDate(ctx, h.Repo, userID, item.Date)
+			if err != nil {
+				writeDateError(c, err)
+				return
+			}
+			date = parsed
+		}
+
+		ops[i] = repository.BulkOperation{
+			Op: item.Op,
+			Transaction: repository.Transaction{
+				ID:          item.ID,
+				Amount:      item.Amount,
+				Date:        date,
+				Description: item.Description,
+				CategoryID:  item.CategoryID,
+				UserID:      userID,
+				Version:     item.ExpectedVersion,
+			},
+		}
+	}
+
+	results, err := repository.ApplyBulk(ctx, h.Repo, userID, ops, req.Mode == BulkModeAtomic)
+	if errors.Is(err, repository.ErrBulkRolledBack) {
+		c.JSON(http.StatusUnprocessableEntity, BulkTransactionsResponse{Mode: req.Mode, RolledBack: true, Results: results})
+		return
+	}
+	if err != nil {
+		fail(c, err, "Failed to apply bulk operations")
+		return
+	}
+	for _, result := range results {
+		if result.Op == repository.BulkOpCreate && result.Error == "" {
+			metrics.TransactionsCreated.Inc()
+		}
+	}
+
+	c.JSON(http.StatusOK, BulkTransactionsResponse{Mode: req.Mode, Results: results})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBulkTransactionsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 1}}, nil)
+
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
+		return txn.Amount == 100.50 && txn.Description == "Groceries" && txn.UserID == 1
+	})).Return(10, nil)
+	mockRepo.On("DeleteTransaction", mock.Anything, 1, 3, 4).Return(nil)
+	mockRepo.On("RecategoriseTransaction", mock.Anything, 1, 5, 1, 2).Return(nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := map[string]interface{}{
+		"operations": []map[string]interface{}{
+			{"op": "create", "amount": 100.50, "description": "Groceries", "category_id": 1},
+			{"op": "delete", "id": 3, "expected_version": 4},
+			{"op": "recategorise", "id": 5, "category_id": 1, "expected_version": 2},
+		},
+	}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/bulk", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.BulkTransactionsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data BulkTransactionsResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, BulkModeAtomic, data.Mode)
+	assert.False(t, data.RolledBack)
+	assert.Len(t, data.Results, 3)
+	assert.Equal(t, 10, data.Results[0].ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkTransactionsHandlerRolledBack(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
+		return txn.ID == 99
+	})).Return(fmt.Errorf("no transaction found or not authorized"))
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := map[string]interface{}{
+		"mode":       "atomic",
+		"operations": []map[string]interface{}{{"op": "update", "id": 99, "amount": 10}},
+	}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/bulk", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.BulkTransactionsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var data BulkTransactionsResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.True(t, data.RolledBack)
+	assert.Len(t, data.Results, 1)
+	assert.NotEmpty(t, data.Results[0].Error)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkTransactionsHandlerInvalidMode(t *testing.T) {
+	handler := &TransactionHandler{}
+
+	body := map[string]interface{}{
+		"mode":       "sometimes",
+		"operations": []map[string]interface{}{{"op": "delete", "id": 1}},
+	}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/bulk", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.BulkTransactionsGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestBulkTransactionsHandlerTooMany(t *testing.T) {
+	handler := &TransactionHandler{}
+
+	ops := make([]map[string]interface{}, MaxBulkOperations+1)
+	for i := range ops {
+		ops[i] = map[string]interface{}{"op": "delete", "id": i + 1}
+	}
+	b, _ := json.Marshal(map[string]interface{}{"operations": ops})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/bulk", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.BulkTransactionsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var data response.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	assert.Equal(t, []response.InvalidParam{{Name: "operations", Reason: fmt.Sprintf("must have at most %d items", MaxBulkOperations)}}, data.InvalidParams)
+}
@@ -15,7 +15,7 @@ type CategoryHandler struct {
 // Models for Swagger documentation
 
 type CreateCategoryRequest struct {
-	Name string `json:"name" binding:"required" example:"Groceries"`
+	Name string `json:"name" binding:"required,name" example:"Groceries"`
 }
 
 type CreateCategoryResponse struct {
@@ -23,13 +23,18 @@ type CreateCategoryResponse struct {
 }
 
 type UpdateCategoryRequest struct {
-	Name string `json:"name" binding:"required" example:"Updated Category"`
+	Name string `json:"name" binding:"required,name" example:"Updated Category"`
 }
 
 type CategoryListResponse struct {
 	Categories []repository.Category `json:"categories"`
 }
 
+type MergeCategoriesRequest struct {
+	SourceID int `json:"source_id" binding:"required" example:"2"`
+	TargetID int `json:"target_id" binding:"required" example:"1"`
+}
+
 // Handlers
 
 // CreateCategoryGin handles category creation using Gin framework.
@@ -46,14 +51,13 @@ func (h *CategoryHandler) CreateCategoryGin(c *gin.Context) {
 	userID := c.GetInt("userID") // Получаем userID из middleware
 
 	var req CreateCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	id, err := h.Repo.CreateCategory(c.Request.Context(), userID, req.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		fail(c, err, "Failed to create category")
 		return
 	}
 
@@ -62,52 +66,94 @@ func (h *CategoryHandler) CreateCategoryGin(c *gin.Context) {
 
 // GetCategoriesGin handles fetching all categories for the user.
 // @Summary Get categories
-// @Description Fetch all categories for the authenticated user
+// @Description Fetch all categories for the authenticated user. Also served by the deprecated GET /categories/list.
 // @Tags Categories
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} CategoryListResponse
-// @Router /categories/list [get]
+// @Header 200 {string} ETag "Weak entity tag of the list"
+// @Router /categories [get]
 func (h *CategoryHandler) GetCategoriesGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
 	categories, err := h.Repo.GetCategories(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		fail(c, err, "Failed to fetch categories")
 		return
 	}
 
-	c.JSON(http.StatusOK, CategoryListResponse{Categories: categories})
+	jsonWithETag(c, CategoryListResponse{Categories: categories})
+}
+
+// GetCategoryGin handles fetching a single category of the user.
+// @Summary Get a category
+// @Description Fetch a category by ID for the authenticated user
+// @Tags Categories
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} repository.Category
+// @Header 200 {string} ETag "Current version of the category"
+// @Failure 404 {object} response.Problem
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetCategoryGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	category, err := h.Repo.GetCategory(c.Request.Context(), userID, categoryID)
+	if err != nil {
+		fail(c, err, "Failed to fetch category")
+		return
+	}
+	if category == nil {
+		abort(c, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	jsonWithVersion(c, category.Version, category)
 }
 
 // UpdateCategoryGin handles updating a category for the user.
 // @Summary Update a category
-// @Description Update a category by ID for the authenticated user
+// @Description Update a category by ID for the authenticated user. If-Match must carry the version the change is based on, or "*". Also served by the deprecated PUT /categories/update?id=.
 // @Tags Categories
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id query int true "Category ID"
+// @Param id path int true "Category ID"
+// @Param If-Match header string true "Category version as an entity tag, e.g. \"3\""
 // @Param category body UpdateCategoryRequest true "Category data"
 // @Success 204 "No Content"
-// @Router /categories/update [put]
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /categories/{id} [put]
 func (h *CategoryHandler) UpdateCategoryGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	categoryID, err := strconv.Atoi(c.Query("id"))
+	categoryID, err := pathOrQueryID(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		abort(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
 	var req UpdateCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.Repo.UpdateCategory(c.Request.Context(), userID, categoryID, req.Name); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+	err = h.Repo.UpdateCategory(c.Request.Context(), userID, categoryID, req.Name, version)
+	if err != nil {
+		fail(c, err, "Failed to update category")
 		return
 	}
 
@@ -116,24 +162,87 @@ func (h *CategoryHandler) UpdateCategoryGin(c *gin.Context) {
 
 // DeleteCategoryGin handles deleting a category for the user.
 // @Summary Delete a category
-// @Description Delete a category by ID for the authenticated user
+// @Description Move a category to the trash. It can be restored until it is purged after the retention period. The strategy decides what happens to its transactions: "block" (default) refuses while the category is in use, even by transactions in the trash, "reassign" moves them to target_id and "cascade" moves them to the trash too, refusing with 409 while split lines of active transactions use the category. If-Match must carry the current version of the category, or "*". Also served by the deprecated DELETE /categories/delete?id=.
 // @Tags Categories
 // @Produce json
 // @Security BearerAuth
-// @Param id query int true "Category ID"
+// @Param id path int true "Category ID"
+// @Param strategy query string false "What to do with the transactions of the category" Enums(block, reassign, cascade)
+// @Param target_id query int false "Category that receives the transactions when strategy is reassign"
+// @Param If-Match header string true "Category version as an entity tag, e.g. \"3\""
 // @Success 204 "No Content"
-// @Router /categories/delete [delete]
+// @Failure 409 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /categories/{id} [delete]
 func (h *CategoryHandler) DeleteCategoryGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	categoryID, err := strconv.Atoi(c.Query("id"))
+	categoryID, err := pathOrQueryID(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		abort(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	strategy := c.DefaultQuery("strategy", repository.CategoryDeleteBlock)
+	var targetID int
+	switch strategy {
+	case repository.CategoryDeleteBlock, repository.CategoryDeleteCascade:
+	case repository.CategoryDeleteReassign:
+		targetID, err = strconv.Atoi(c.Query("target_id"))
+		if err != nil || targetID == categoryID {
+			abort(c, http.StatusBadRequest, "Invalid target category ID")
+			return
+		}
+	default:
+		abort(c, http.StatusBadRequest, "Strategy must be block, reassign or cascade")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.Repo.DeleteCategory(c.Request.Context(), userID, categoryID, strategy, targetID, version)
+	if err != nil {
+		fail(c, err, "Failed to delete category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MergeCategoriesGin handles merging two categories of the user.
+// @Summary Merge categories
+// @Description Move all transactions and split lines of the source category, including trashed ones, into the target category and move the source to the trash. Runs atomically. If-Match must carry the current version of the source category, or "*".
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param If-Match header string true "Source category version as an entity tag, e.g. \"3\""
+// @Param merge body MergeCategoriesRequest true "Categories to merge"
+// @Success 204 "No Content"
+// @Failure 404 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /categories/merge [post]
+func (h *CategoryHandler) MergeCategoriesGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req MergeCategoriesRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.Repo.DeleteCategory(c.Request.Context(), userID, categoryID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+	if err := h.Repo.MergeCategories(c.Request.Context(), userID, req.SourceID, req.TargetID, version); err != nil {
+		fail(c, err, "Failed to merge categories")
 		return
 	}
 
@@ -2,14 +2,15 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/nemopss/financial-tracker/internal/middleware"
 	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -28,24 +29,23 @@ func TestCreateCategoryHandler(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.CreateCategory(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateCategoryGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int
-		Data       map[string]int
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data map[string]int
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Equal(t, 1, wrappedResponse.Data["id"])
+	assert.Equal(t, 1, data["id"])
 
 	mockRepo.AssertExpectations(t)
 }
@@ -67,30 +67,29 @@ func TestGetCategoriesHandler(t *testing.T) {
 
 	// Создаём запрос
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/list", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	// Эмулируем HTTP-запрос
 	w := httptest.NewRecorder()
-	handler.GetCategories(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoriesGin)
 
 	// Проверяем ответ
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	// Декодируем обёрнутый ответ
-	var wrappedResponse struct {
-		StatusCode int                   `json:"status_code"`
-		Data       []repository.Category `json:"data"`
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	// Декодируем ответ
+	var data CategoryListResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	// Проверяем содержимое "data"
-	assert.Len(t, wrappedResponse.Data, 2)
-	assert.Equal(t, "Groceries", wrappedResponse.Data[0].Name)
-	assert.Equal(t, "Entertainment", wrappedResponse.Data[1].Name)
+	// Проверяем содержимое "categories"
+	assert.Len(t, data.Categories, 2)
+	assert.Equal(t, "Groceries", data.Categories[0].Name)
+	assert.Equal(t, "Entertainment", data.Categories[1].Name)
 
 	// Проверяем, что mock-методы были вызваны
 	mockRepo.AssertExpectations(t)
@@ -99,7 +98,7 @@ func TestGetCategoriesHandler(t *testing.T) {
 func TestUpdateCategoryHandler(t *testing.T) {
 	mockRepo := &repository.MockRepo{}
 
-	mockRepo.On("UpdateCategory", mock.Anything, 1, 1, "Updated Category").
+	mockRepo.On("UpdateCategory", mock.Anything, 1, 1, "Updated Category", 2).
 		Return(nil)
 
 	handler := &CategoryHandler{Repo: mockRepo}
@@ -109,10 +108,14 @@ func TestUpdateCategoryHandler(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPut, "/api/v1/categories/update?id=1", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	req.Header.Set("If-Match", `"2"`)
 
 	w := httptest.NewRecorder()
-	handler.UpdateCategory(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateCategoryGin)
+	c.Writer.WriteHeaderNow()
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
@@ -123,19 +126,197 @@ func TestUpdateCategoryHandler(t *testing.T) {
 func TestDeleteCategoryHandler(t *testing.T) {
 	mockRepo := &repository.MockRepo{}
 
-	mockRepo.On("DeleteCategory", mock.Anything, 1, 1).
+	mockRepo.On("DeleteCategory", mock.Anything, 1, 1, repository.CategoryDeleteBlock, 0, 0).
 		Return(nil)
 
 	handler := &CategoryHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/delete?id=1", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	req.Header.Set("If-Match", "*")
 
 	w := httptest.NewRecorder()
-	handler.DeleteCategory(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteCategoryGin)
+	c.Writer.WriteHeaderNow()
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestDeleteCategoryHandlerInUse(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("DeleteCategory", mock.Anything, 1, 1, repository.CategoryDeleteBlock, 0, 0).
+		Return(&repository.CategoryInUseError{CategoryID: 1, Count: 12})
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/delete?id=1&strategy=block", nil)
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteCategoryGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, 12, data.UsageCount)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteCategoryHandlerReassign(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("DeleteCategory", mock.Anything, 1, 1, repository.CategoryDeleteReassign, 2, 0).
+		Return(nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/delete?id=1&strategy=reassign&target_id=2", nil)
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteCategoryGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteCategoryHandlerReassignWithoutTarget(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/categories/delete?id=1&strategy=reassign", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteCategoryGin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "DeleteCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMergeCategoriesHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("MergeCategories", mock.Anything, 1, 2, 1, 4).
+		Return(nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	body := map[string]int{"source_id": 2, "target_id": 1}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories/merge", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"4"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.MergeCategoriesGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetCategoryHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategory", mock.Anything, 1, 2).
+		Return(&repository.Category{ID: 2, Name: "Groceries", Version: 3}, nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/2", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoryGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+
+	var data repository.Category
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "Groceries", data.Name)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetCategoryHandlerNotFound(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategory", mock.Anything, 1, 2).Return(nil, nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/categories/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoryGin)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateCategoryHandlerPathID(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UpdateCategory", mock.Anything, 1, 4, "Food", 0).Return(nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]string{"name": "Food"})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/categories/4", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateCategoryGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
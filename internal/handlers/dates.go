@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+var errInvalidDate = errors.New("date must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+
+// Helpers
+
+// userLocation loads the timezone the dates of the user are read in.
+func userLocation(ctx context.Context, repo repository.Repository, userID int) (*time.Location, error) {
+	name, err := repo.GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(name)
+}
+
+// parseDate reads a transaction date. An RFC 3339 timestamp is taken as is, a
+// YYYY-MM-DD date as midnight in the timezone of the user. Malformed input
+// yields errInvalidDate.
+func parseDate(ctx context.Context, repo repository.Repository, userID int, value string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	if _, err := time.Parse(validation.DateLayout, value); err != nil {
+		return time.Time{}, errInvalidDate
+	}
+
+	loc, err := userLocation(ctx, repo, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(validation.DateLayout, value, loc)
+}
+
+// writeDateError answers with 400 for malformed dates and 500 otherwise.
+func writeDateError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidDate) {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	fail(c, err, "Failed to load user timezone")
+}
+
+// dayRange reads the start_date and end_date query parameters as whole days in
+// the timezone of the user and returns the half-open range [from, to) covering
+// both of them. It writes the error response and returns false on invalid input.
+func dayRange(c *gin.Context, repo repository.Repository, userID int) (from, to time.Time, ok bool) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	if startDate == "" || endDate == "" {
+		abort(c, http.StatusBadRequest, "Start date and end date are required")
+		return from, to, false
+	}
+
+	loc, err := userLocation(c.Request.Context(), repo, userID)
+	if err != nil {
+		fail(c, err, "Failed to load user timezone")
+		return from, to, false
+	}
+
+	from, err = time.ParseInLocation(validation.DateLayout, startDate, loc)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid start date, expected YYYY-MM-DD")
+		return from, to, false
+	}
+	to, err = time.ParseInLocation(validation.DateLayout, endDate, loc)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid end date, expected YYYY-MM-DD")
+		return from, to, false
+	}
+	if to.Before(from) {
+		abort(c, http.StatusBadRequest, "End date must not be before start date")
+		return from, to, false
+	}
+
+	// AddDate keeps the wall clock, so days with a DST change stay whole
+	return from, to.AddDate(0, 0, 1), true
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+// Helpers
+
+// abort records a client error with the given status. The Errors middleware
+// renders it as problem details once the handler returns.
+func abort(c *gin.Context, status int, detail string) {
+	c.Error(response.NewProblem(status, detail))
+}
+
+// fail records an error returned by the repository. Domain errors are mapped
+// to their status by the Errors middleware; anything else becomes a 500 with
+// detail as the message.
+func fail(c *gin.Context, err error, detail string) {
+	c.Error(err).SetMeta(detail)
+}
+
+// bindJSON decodes the request body into obj and validates it against its
+// binding tags and the given checks. A malformed body is recorded as a 400;
+// otherwise every violation is recorded at once.
+func bindJSON(c *gin.Context, obj any, checks ...func(v *validation.Validator)) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		abort(c, http.StatusBadRequest, "Invalid request payload")
+		return false
+	}
+
+	v := validation.New()
+	v.Struct(obj)
+	for _, check := range checks {
+		check(v)
+	}
+	if err := v.Err(); err != nil {
+		c.Error(err)
+		return false
+	}
+	return true
+}
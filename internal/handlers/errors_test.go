@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotFoundErrorProblem(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UpdateTag", mock.Anything, 1, 3, "travel", 0).Return(&repository.NotFoundError{Entity: repository.EntityTag})
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/tags/update?id=3", strings.NewReader(`{"name": "travel"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateTagGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, response.ProblemContentType, resp.Header.Get("Content-Type"))
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "about:blank", data.Type)
+	assert.Equal(t, "Not Found", data.Title)
+	assert.Equal(t, http.StatusNotFound, data.Status)
+	assert.Equal(t, "no tag found or not authorized", data.Detail)
+	assert.Equal(t, "/api/v1/tags/update", data.Instance)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestConflictErrorProblem(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("CreateTag", mock.Anything, 1, "travel").
+		Return(0, &repository.ConflictError{Entity: repository.EntityTag, Reason: "a tag with this name already exists"})
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tags", strings.NewReader(`{"name": "travel"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTagGin)
+
+	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidationErrorProblem(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("MergeCategories", mock.Anything, 1, 2, 2, 0).
+		Return(&repository.ValidationError{Fields: []repository.FieldError{{Field: "target_id", Message: "cannot merge a category into itself"}}})
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories/merge", strings.NewReader(`{"source_id": 2, "target_id": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.MergeCategoriesGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, []response.InvalidParam{{Name: "target_id", Reason: "cannot merge a category into itself"}}, data.InvalidParams)
+}
+
+func TestUnexpectedErrorProblemHidesCause(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTags", mock.Anything, 1).Return(nil, errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/tags/list", nil)
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTagsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "Failed to fetch tags", data.Detail)
+}
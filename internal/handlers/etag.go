@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helpers
+
+// etag formats the version of a record as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the version a conditional update or delete is based on
+// from the If-Match header. "*" matches any version and yields 0. It writes the
+// error response and returns false when the header is missing or malformed.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		abort(c, http.StatusPreconditionRequired, "If-Match header is required")
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 {
+		abort(c, http.StatusBadRequest, "Invalid If-Match header")
+		return 0, false
+	}
+	return version, true
+}
+
+// jsonWithVersion writes a single record as JSON with the strong entity tag of
+// its version, and answers 304 when the client already has that version.
+func jsonWithVersion(c *gin.Context, version int, obj any) {
+	tag := etag(version)
+	c.Header("ETag", tag)
+	if c.GetHeader("If-None-Match") == tag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, obj)
+}
+
+// jsonWithETag writes obj as JSON with a weak entity tag of the body, and
+// answers 304 when the client already has that representation.
+func jsonWithETag(c *gin.Context, obj any) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		fail(c, err, "Failed to encode response")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	tag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("ETag", tag)
+	if c.GetHeader("If-None-Match") == tag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
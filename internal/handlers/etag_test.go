@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdateTransactionHandlerRequiresIfMatch(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]interface{}{"id": 1, "amount": 10})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/transactions/update", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateTransactionGin)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestDeleteTransactionHandlerVersionConflict(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("DeleteTransaction", mock.Anything, 1, 5, 2).
+		Return(&repository.VersionConflictError{Entity: repository.EntityTransaction, ID: 5, Expected: 2, Current: 4})
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/transactions/delete?id=5", nil)
+	req.Header.Set("If-Match", `"2"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteTransactionGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, 4, data.CurrentVersion)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteTransactionHandlerInvalidIfMatch(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/transactions/delete?id=5", nil)
+	req.Header.Set("If-Match", `W/"abc"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteTransactionGin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "DeleteTransaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetCategoriesHandlerNotModified(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).
+		Return([]repository.Category{{ID: 1, Name: "Groceries", Version: 2}}, nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/categories/list", nil)
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoriesGin)
+
+	tag := w.Result().Header.Get("ETag")
+	assert.NotEmpty(t, tag)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/categories/list", nil)
+	c.Request.Header.Set("If-None-Match", tag)
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetCategoriesGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNotModified, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
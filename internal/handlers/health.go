@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds all checks of one readiness probe together.
+const healthCheckTimeout = 3 * time.Second
+
+// Health statuses of the service and of each check.
+const (
+	HealthPass = "pass"
+	HealthFail = "fail"
+)
+
+// healthCheckFailed is reported for a failing check; the cause is only
+// logged, since the probe is reachable without authentication.
+const healthCheckFailed = "check failed, see the server log"
+
+// HealthCheck is a dependency the service needs to handle requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	Checks []HealthCheck
+}
+
+// Models for the health endpoints
+
+// HealthStatus is the body of the health endpoints. Checks is only reported
+// by the readiness probe.
+type HealthStatus struct {
+	Status string                 `json:"status" example:"pass"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the result of one readiness check.
+type CheckStatus struct {
+	Status string `json:"status" example:"fail"`
+	Error  string `json:"error,omitempty" example:"check failed, see the server log"`
+}
+
+// Handlers
+
+// LivenessGin reports that the process is up and serving requests. It checks
+// no dependencies, so an orchestrator only restarts a process that is stuck.
+func (h *HealthHandler) LivenessGin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, HealthStatus{Status: HealthPass})
+}
+
+// ReadinessGin runs every check and responds 200 when all of them pass, or
+// 503 so that no traffic is routed to the instance otherwise.
+func (h *HealthHandler) ReadinessGin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	status := HealthStatus{Status: HealthPass, Checks: make(map[string]CheckStatus, len(h.Checks))}
+	for _, check := range h.Checks {
+		if err := check.Check(ctx); err != nil {
+			log.Printf("Readiness check %s failed: %v", check.Name, err)
+			status.Status = HealthFail
+			status.Checks[check.Name] = CheckStatus{Status: HealthFail, Error: healthCheckFailed}
+			continue
+		}
+		status.Checks[check.Name] = CheckStatus{Status: HealthPass}
+	}
+
+	code := http.StatusOK
+	if status.Status == HealthFail {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, status)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	handler := &HealthHandler{Checks: []HealthCheck{
+		{Name: "database", Check: func(context.Context) error { return errors.New("connection refused") }},
+	}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	serve(c, handler.LivenessGin)
+
+	// Failing dependencies do not make the process unhealthy
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "pass"}`, w.Body.String())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	pass := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		migrations func(context.Context) error
+		wantCode   int
+		wantStatus HealthStatus
+	}{
+		{
+			name:       "Ready",
+			migrations: pass,
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatus{Status: HealthPass, Checks: map[string]CheckStatus{
+				"database":   {Status: HealthPass},
+				"migrations": {Status: HealthPass},
+			}},
+		},
+		{
+			name:       "Failing check",
+			migrations: func(context.Context) error { return errors.New("2 migrations pending") },
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatus{Status: HealthFail, Checks: map[string]CheckStatus{
+				"database":   {Status: HealthPass},
+				"migrations": {Status: HealthFail, Error: "check failed, see the server log"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &HealthHandler{Checks: []HealthCheck{
+				{Name: "database", Check: pass},
+				{Name: "migrations", Check: tt.migrations},
+			}}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			serve(c, handler.ReadinessGin)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+			var status HealthStatus
+			if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
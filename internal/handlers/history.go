@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Models for Swagger documentation
+
+// UndoTransactionRequest represents the request body for undoing a transaction change.
+// Version is the version to go back to and defaults to the one before the
+// version given in If-Match.
+type UndoTransactionRequest struct {
+	Version int `json:"version"`
+}
+
+// UndoTransactionResponse represents the response body after undoing a transaction change.
+type UndoTransactionResponse struct {
+	Version int `json:"version"`
+}
+
+// Handlers
+
+// GetTransactionHistoryGin handles fetching the previous versions of a transaction.
+// @Summary Get transaction history
+// @Description Fetch the current state and the previous versions of a transaction of the authenticated user, newest first
+// @Tags Transactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} repository.TransactionHistory
+// @Header 200 {string} ETag "Current version of the transaction"
+// @Failure 404 {object} response.Problem
+// @Router /transactions/{id}/history [get]
+func (h *TransactionHandler) GetTransactionHistoryGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	history, err := h.Repo.GetTransactionHistory(c.Request.Context(), userID, txnID)
+	if err != nil {
+		fail(c, err, "Failed to fetch transaction history")
+		return
+	}
+	if history == nil {
+		abort(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	c.Header("ETag", etag(history.Current.Version))
+	c.JSON(http.StatusOK, history)
+}
+
+// UndoTransactionGin handles reverting a transaction to a previous version.
+// @Summary Undo a transaction change
+// @Description Restore a previous version of a transaction of the authenticated user together with its split lines and tags, undeleting it if that version was not deleted. If-Match must carry the current version of the transaction, or "*". Fails with 409 if a category or tag of that version is no longer active, or if that version was saved without its split lines and tags.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
+// @Param request body UndoTransactionRequest false "Undo request"
+// @Success 200 {object} UndoTransactionResponse
+// @Header 200 {string} ETag "New version of the transaction"
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id}/undo [post]
+func (h *TransactionHandler) UndoTransactionGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+	expected, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional and only picks a version other than the last one
+	var req UndoTransactionRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	version, err := h.Repo.UndoTransaction(c.Request.Context(), userID, txnID, expected, req.Version)
+	if err != nil {
+		fail(c, err, "Failed to undo transaction")
+		return
+	}
+
+	c.Header("ETag", etag(version))
+	c.JSON(http.StatusOK, UndoTransactionResponse{Version: version})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTransactionHistoryHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTransactionHistory", mock.Anything, 1, 5).
+		Return(&repository.TransactionHistory{
+			Current:  repository.Transaction{ID: 5, Amount: -30, Version: 2},
+			Versions: []repository.TransactionVersion{{Version: 1, Amount: -25}},
+		}, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/5/history", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionHistoryGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data repository.TransactionHistory
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, 2, data.Current.Version)
+	assert.Len(t, data.Versions, 1)
+	assert.Equal(t, -25.0, data.Versions[0].Amount)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTransactionHistoryHandlerNotFound(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTransactionHistory", mock.Anything, 1, 5).Return(nil, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/5/history", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionHistoryGin)
+
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUndoTransactionHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UndoTransaction", mock.Anything, 1, 5, 3, 0).Return(4, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/5/undo", nil)
+	req.Header.Set("If-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UndoTransactionGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+
+	var data UndoTransactionResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, 4, data.Version)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUndoTransactionHandlerVersionConflict(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UndoTransaction", mock.Anything, 1, 5, 3, 1).
+		Return(0, &repository.VersionConflictError{Entity: repository.EntityTransaction, ID: 5, Expected: 3, Current: 4})
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body, _ := json.Marshal(UndoTransactionRequest{Version: 1})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/5/undo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UndoTransactionGin)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+	var problem response.Problem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, 4, problem.CurrentVersion)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUndoTransactionHandlerRequiresIfMatch(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/5/undo", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UndoTransactionGin)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Result().StatusCode)
+	mockRepo.AssertNotCalled(t, "UndoTransaction")
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// serve runs a handler the way the router does, rendering recorded errors as
+// problem details.
+func serve(c *gin.Context, h gin.HandlerFunc) {
+	h(c)
+	middleware.RenderErrors(c)
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Helpers
+
+// pathOrQueryID reads the resource ID from the :id path parameter, or from the
+// id query parameter of the deprecated routes.
+func pathOrQueryID(c *gin.Context) (int, error) {
+	if id := c.Param("id"); id != "" {
+		return strconv.Atoi(id)
+	}
+	return strconv.Atoi(c.Query("id"))
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// Models for Swagger documentation
+
+// TransactionMergePatch documents the members accepted by PATCH /transactions/{id}.
+// Omitted members are left untouched; category_id null removes the category,
+// description null clears it and tag_ids null removes all tags.
+type TransactionMergePatch struct {
+	Amount      *float64 `json:"amount,omitempty" example:"-12.50"`
+	Date        *string  `json:"date,omitempty" example:"2024-12-01T15:04:05Z"`
+	Description *string  `json:"description,omitempty" example:"Lunch"`
+	CategoryID  *int     `json:"category_id,omitempty" example:"2"`
+	TagIDs      []int    `json:"tag_ids,omitempty" example:"1,2"`
+}
+
+// CategoryMergePatch documents the members accepted by PATCH /categories/{id}.
+type CategoryMergePatch struct {
+	Name *string `json:"name,omitempty" example:"Food"`
+}
+
+// Handlers
+
+// PatchTransactionGin handles partially updating a transaction.
+// @Summary Patch a transaction
+// @Description Apply a JSON Merge Patch (RFC 7396) to a transaction of the authenticated user, changing only the supplied members. If-Match must carry the version the change is based on, or "*".
+// @Tags Transactions
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
+// @Param patch body TransactionMergePatch true "Members to change"
+// @Success 204 "No Content"
+// @Failure 400 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 415 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id} [patch]
+func (h *TransactionHandler) PatchTransactionGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	members, ok := mergePatch(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	v := validation.New()
+	var patch repository.TransactionPatch
+	var rawDate string
+	for _, name := range memberNames(members) {
+		value := members[name]
+		null := isNull(value)
+		switch name {
+		case "amount":
+			if null || json.Unmarshal(value, &patch.Amount) != nil {
+				v.Add(name, "must be a number")
+			} else {
+				v.Check(*patch.Amount != 0, name, "must not be zero")
+			}
+		case "date":
+			if null || json.Unmarshal(value, &rawDate) != nil {
+				v.Add(name, "must be a string")
+			} else {
+				v.Var(name, rawDate, "date")
+			}
+		case "description":
+			description := ""
+			if !null && json.Unmarshal(value, &description) != nil {
+				v.Add(name, "must be a string or null")
+			}
+			patch.Description = &description
+		case "category_id":
+			if null {
+				patch.ClearCategory = true
+			} else if json.Unmarshal(value, &patch.CategoryID) != nil || *patch.CategoryID < 1 {
+				v.Add(name, "must be a category ID or null")
+			} else {
+				v.Category(ctx, h.Repo, userID, name, *patch.CategoryID)
+			}
+		case "tag_ids":
+			patch.TagIDs = []int{}
+			if !null && json.Unmarshal(value, &patch.TagIDs) != nil {
+				v.Add(name, "must be a list of tag IDs or null")
+			}
+		default:
+			v.Add(name, "is unknown or read-only")
+		}
+	}
+	if err := v.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if rawDate != "" {
+		date, err := parseDate(ctx, h.Repo, userID, rawDate)
+		if err != nil {
+			writeDateError(c, err)
+			return
+		}
+		patch.Date = &date
+	}
+
+	if err := h.Repo.PatchTransaction(ctx, userID, txnID, patch, version); err != nil {
+		fail(c, err, "Failed to update transaction")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PatchCategoryGin handles partially updating a category.
+// @Summary Patch a category
+// @Description Apply a JSON Merge Patch (RFC 7396) to a category of the authenticated user. If-Match must carry the version the change is based on, or "*".
+// @Tags Categories
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param If-Match header string true "Category version as an entity tag, e.g. \"3\""
+// @Param patch body CategoryMergePatch true "Members to change"
+// @Success 204 "No Content"
+// @Failure 400 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 415 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /categories/{id} [patch]
+func (h *CategoryHandler) PatchCategoryGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	members, ok := mergePatch(c)
+	if !ok {
+		return
+	}
+
+	v := validation.New()
+	var name *string
+	for _, member := range memberNames(members) {
+		if member != "name" {
+			v.Add(member, "is unknown or read-only")
+			continue
+		}
+		if isNull(members[member]) || json.Unmarshal(members[member], &name) != nil {
+			v.Add(member, "must be a string")
+			continue
+		}
+		v.Var(member, *name, "name")
+	}
+	if err := v.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// An empty patch leaves the category as it is
+	if name == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	err = h.Repo.UpdateCategory(c.Request.Context(), userID, categoryID, *name, version)
+	if err != nil {
+		fail(c, err, "Failed to update category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helpers
+
+// mergePatch reads the members of an RFC 7396 merge patch document. It writes
+// the error response and returns false when the content type is unsupported or
+// the body is not a JSON object.
+func mergePatch(c *gin.Context) (map[string]json.RawMessage, bool) {
+	switch c.ContentType() {
+	case mergePatchContentType, "application/json":
+	default:
+		abort(c, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType)
+		return nil, false
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&members); err != nil || members == nil {
+		abort(c, http.StatusBadRequest, "Merge patch must be a JSON object")
+		return nil, false
+	}
+	return members, true
+}
+
+// memberNames lists the members of a patch in a stable order, so violations
+// are reported the same way every time.
+func memberNames(members map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
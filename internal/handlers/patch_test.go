@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchTransactionHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("PatchTransaction", mock.Anything, 1, 7, mock.MatchedBy(func(patch repository.TransactionPatch) bool {
+		return patch.Description != nil && *patch.Description == "Dinner" &&
+			patch.ClearCategory && patch.Amount == nil && patch.Date == nil &&
+			patch.TagIDs != nil && len(patch.TagIDs) == 0
+	}), 3).Return(nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := `{"description": "Dinner", "category_id": null, "tag_ids": null}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/transactions/7", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchTransactionGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchTransactionHandlerRejectsReadOnlyMember(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/transactions/7", strings.NewReader(`{"user_id": 2}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchTransactionGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "PatchTransaction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchTransactionHandlerNullAmount(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/transactions/7", strings.NewReader(`{"amount": null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchTransactionGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestPatchTransactionHandlerUnsupportedMediaType(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/transactions/7", strings.NewReader(`description=Dinner`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchTransactionGin)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Result().StatusCode)
+}
+
+func TestPatchCategoryHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("UpdateCategory", mock.Anything, 1, 2, "Food", 4).Return(nil)
+
+	handler := &CategoryHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/categories/2", strings.NewReader(`{"name": "Food"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"4"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchCategoryGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchTransactionHandlerReportsEveryViolation(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := `{"amount": 0, "date": "soon", "user_id": 2}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/transactions/7", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.PatchTransactionGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, []response.InvalidParam{
+		{Name: "amount", Reason: "must not be zero"},
+		{Name: "date", Reason: "must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{Name: "user_id", Reason: "is unknown or read-only"},
+	}, data.InvalidParams)
+}
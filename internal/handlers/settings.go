@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+)
+
+// Models for Swagger documentation
+
+type SettingsHandler struct {
+	Repo repository.Repository
+}
+
+// UserSettings represents the per-user settings. Timezone is an IANA timezone
+// name used to read date-only input and analytics date ranges.
+type UserSettings struct {
+	Timezone string `json:"timezone" binding:"required,timezone" example:"Europe/Moscow"`
+}
+
+// Handlers
+
+// GetSettingsGin handles fetching the settings of the user.
+// @Summary Get settings
+// @Description Fetch the settings of the authenticated user
+// @Tags Settings
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} UserSettings
+// @Router /settings [get]
+func (h *SettingsHandler) GetSettingsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	timezone, err := h.Repo.GetUserTimezone(c.Request.Context(), userID)
+	if err != nil {
+		fail(c, err, "Failed to fetch settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, UserSettings{Timezone: timezone})
+}
+
+// UpdateSettingsGin handles changing the settings of the user.
+// @Summary Update settings
+// @Description Change the settings of the authenticated user. The timezone must be an IANA timezone name such as Europe/Moscow or UTC.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param settings body UserSettings true "New settings"
+// @Success 200 {object} UserSettings
+// @Failure 422 {object} response.Problem
+// @Router /settings [put]
+func (h *SettingsHandler) UpdateSettingsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	var req UserSettings
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.Repo.SetUserTimezone(c.Request.Context(), userID, req.Timezone); err != nil {
+		fail(c, err, "Failed to update settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSettingsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("Europe/Moscow", nil)
+
+	handler := &SettingsHandler{Repo: mockRepo}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/settings", nil)
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetSettingsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data UserSettings
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "Europe/Moscow", data.Timezone)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateSettingsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("SetUserTimezone", mock.Anything, 1, "America/New_York").Return(nil)
+
+	handler := &SettingsHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/settings", strings.NewReader(`{"timezone": "America/New_York"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateSettingsGin)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateSettingsHandlerUnknownTimezone(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &SettingsHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/settings", strings.NewReader(`{"timezone": "Mars/Olympus_Mons"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateSettingsGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "SetUserTimezone", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
+)
+
+// Models for Swagger documentation
+
+type SplitRequest struct {
+	CategoryID int     `json:"category_id" binding:"required" example:"1"`
+	Amount     float64 `json:"amount" binding:"nonzero" example:"-30.25"`
+	Note       string  `json:"note,omitempty" example:"Household items"`
+}
+
+type SetSplitsRequest struct {
+	Splits []SplitRequest `json:"splits" binding:"dive"`
+}
+
+type SplitListResponse struct {
+	Splits []repository.Split `json:"splits"`
+}
+
+// Handlers
+
+// GetSplitsGin handles fetching the split lines of a transaction.
+// @Summary Get transaction splits
+// @Description Fetch the split lines of a transaction owned by the authenticated user. Also served by the deprecated GET /transactions/splits?id=.
+// @Tags Transactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} SplitListResponse
+// @Failure 404 {object} response.Problem
+// @Router /transactions/{id}/splits [get]
+func (h *TransactionHandler) GetSplitsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	splits, err := h.Repo.GetSplits(c.Request.Context(), userID, txnID)
+	if err != nil {
+		fail(c, err, "Failed to fetch splits")
+		return
+	}
+
+	c.JSON(http.StatusOK, SplitListResponse{Splits: splits})
+}
+
+// SetSplitsGin handles replacing the split lines of a transaction.
+// @Summary Set transaction splits
+// @Description Replace the split lines of a transaction. Split amounts must sum to the transaction amount; an empty list removes the splits. If-Match must carry the current version of the transaction, or "*". Also served by the deprecated PUT /transactions/splits?id=.
+// @Tags Transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
+// @Param splits body SetSplitsRequest true "Split lines"
+// @Success 204 "No Content"
+// @Failure 412 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id}/splits [put]
+func (h *TransactionHandler) SetSplitsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var req SetSplitsRequest
+	if !bindJSON(c, &req, func(v *validation.Validator) {
+		for i, split := range req.Splits {
+			v.Category(ctx, h.Repo, userID, fmt.Sprintf("splits[%d].category_id", i), split.CategoryID)
+		}
+	}) {
+		return
+	}
+
+	splits := make([]repository.Split, len(req.Splits))
+	for i, split := range req.Splits {
+		splits[i] = repository.Split{
+			TransactionID: txnID,
+			CategoryID:    split.CategoryID,
+			Amount:        split.Amount,
+			Note:          split.Note,
+		}
+	}
+
+	if err := h.Repo.SetSplits(ctx, userID, txnID, splits, version); err != nil {
+		fail(c, err, "Failed to update splits")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteSplitsGin handles removing all split lines of a transaction.
+// @Summary Delete transaction splits
+// @Description Remove all split lines of a transaction owned by the authenticated user. If-Match must carry the current version of the transaction, or "*". Also served by the deprecated DELETE /transactions/splits?id=.
+// @Tags Transactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
+// @Success 204 "No Content"
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id}/splits [delete]
+func (h *TransactionHandler) DeleteSplitsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.DeleteSplits(c.Request.Context(), userID, txnID, version); err != nil {
+		fail(c, err, "Failed to delete splits")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
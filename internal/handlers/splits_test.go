@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSplitsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetSplits", mock.Anything, 1, 5).
+		Return([]repository.Split{
+			{ID: 1, TransactionID: 5, CategoryID: 2, Amount: -70, Note: "Groceries"},
+			{ID: 2, TransactionID: 5, CategoryID: 3, Amount: -30, Note: "Household"},
+		}, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/5/splits", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetSplitsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data SplitListResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data.Splits, 2)
+	assert.Equal(t, "Household", data.Splits[1].Note)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetSplitsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 2}, {ID: 3}}, nil)
+
+	mockRepo.On("SetSplits", mock.Anything, 1, 5, []repository.Split{
+		{TransactionID: 5, CategoryID: 2, Amount: -70, Note: "Groceries"},
+		{TransactionID: 5, CategoryID: 3, Amount: -30},
+	}, 3).Return(nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := map[string]interface{}{
+		"splits": []map[string]interface{}{
+			{"category_id": 2, "amount": -70, "note": "Groceries"},
+			{"category_id": 3, "amount": -30},
+		},
+	}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/transactions/5/splits", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.SetSplitsGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetSplitsHandlerSumMismatch(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 2}}, nil)
+
+	mockRepo.On("SetSplits", mock.Anything, 1, 5, mock.Anything, 0).
+		Return(repository.ErrSplitSumMismatch)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := map[string]interface{}{
+		"splits": []map[string]interface{}{{"category_id": 2, "amount": -70}},
+	}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/transactions/5/splits", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.SetSplitsGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
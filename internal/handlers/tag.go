@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+)
+
+type TagHandler struct {
+	Repo repository.Repository
+}
+
+// Models for Swagger documentation
+
+type CreateTagRequest struct {
+	Name string `json:"name" binding:"required,name" example:"vacation-2025"`
+}
+
+type CreateTagResponse struct {
+	ID int `json:"id" example:"1"`
+}
+
+type UpdateTagRequest struct {
+	Name string `json:"name" binding:"required,name" example:"reimbursable"`
+}
+
+type TagListResponse struct {
+	Tags []repository.Tag `json:"tags"`
+}
+
+// Handlers
+
+// CreateTagGin handles tag creation using Gin framework.
+// @Summary Create a new tag
+// @Description Create a tag for the authenticated user
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tag body CreateTagRequest true "Tag data"
+// @Success 201 {object} CreateTagResponse
+// @Router /tags [post]
+func (h *TagHandler) CreateTagGin(c *gin.Context) {
+	userID := c.GetInt("userID") // Получаем userID из middleware
+
+	var req CreateTagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	id, err := h.Repo.CreateTag(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		fail(c, err, "Failed to create tag")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateTagResponse{ID: id})
+}
+
+// GetTagsGin handles fetching all tags for the user.
+// @Summary Get tags
+// @Description Fetch all tags for the authenticated user. Also served by the deprecated GET /tags/list.
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TagListResponse
+// @Router /tags [get]
+func (h *TagHandler) GetTagsGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tags, err := h.Repo.GetTags(c.Request.Context(), userID)
+	if err != nil {
+		fail(c, err, "Failed to fetch tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, TagListResponse{Tags: tags})
+}
+
+// UpdateTagGin handles updating a tag for the user.
+// @Summary Update a tag
+// @Description Update a tag by ID for the authenticated user. If-Match must carry the version the change is based on, or "*". Also served by the deprecated PUT /tags/update?id=.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Param If-Match header string true "Tag version as an entity tag, e.g. \"3\""
+// @Param tag body UpdateTagRequest true "Tag data"
+// @Success 204 "No Content"
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /tags/{id} [put]
+func (h *TagHandler) UpdateTagGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tagID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.Repo.UpdateTag(c.Request.Context(), userID, tagID, req.Name, version); err != nil {
+		fail(c, err, "Failed to update tag")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteTagGin handles deleting a tag for the user.
+// @Summary Delete a tag
+// @Description Delete a tag by ID for the authenticated user and remove it from every transaction. If-Match must carry the current version of the tag, or "*". Also served by the deprecated DELETE /tags/delete?id=.
+// @Tags Tags
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Tag ID"
+// @Param If-Match header string true "Tag version as an entity tag, e.g. \"3\""
+// @Success 204 "No Content"
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTagGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	tagID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.DeleteTag(c.Request.Context(), userID, tagID, version); err != nil {
+		fail(c, err, "Failed to delete tag")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateTagHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("CreateTag", mock.Anything, 1, "vacation-2025").
+		Return(1, nil)
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	body := map[string]string{"name": "vacation-2025"}
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tags", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTagGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var data CreateTagResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, 1, data.ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTagsHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTags", mock.Anything, 1).
+		Return([]repository.Tag{{ID: 1, Name: "reimbursable"}, {ID: 2, Name: "vacation-2025"}}, nil)
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tags", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTagsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data TagListResponse
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data.Tags, 2)
+	assert.Equal(t, "vacation-2025", data.Tags[1].Name)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteTagHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("DeleteTag", mock.Anything, 1, 2, 0).
+		Return(nil)
+
+	handler := &TagHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/tags/2", nil)
+	req.Header.Set("If-Match", "*")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.DeleteTagGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/metrics"
 	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/validation"
 )
 
 // Models for Swagger documentation
@@ -15,11 +18,26 @@ type TransactionHandler struct {
 	Repo repository.Repository
 }
 
+// CreateTransactionRequest represents the request body for creating a transaction.
+// Date is an RFC 3339 timestamp or a YYYY-MM-DD date, read in the timezone of
+// the user, and defaults to now.
 type CreateTransactionRequest struct {
-	Amount      float64 `json:"amount" example:"100.50"`
-	Date        string  `json:"date,omitempty" example:"2024-12-01T15:04:05Z"`
+	Amount      float64 `json:"amount" binding:"nonzero" example:"100.50"`
+	Date        string  `json:"date,omitempty" binding:"omitempty,date" example:"2024-12-01T15:04:05Z"`
 	Description string  `json:"description" example:"Grocery shopping"`
 	CategoryID  int     `json:"category_id" example:"1"`
+	TagIDs      []int   `json:"tag_ids,omitempty" example:"1,2"`
+}
+
+// UpdateTransactionRequest represents the request body for updating a
+// transaction. An omitted date keeps the stored one.
+type UpdateTransactionRequest struct {
+	ID          int     `json:"id,omitempty" example:"1"`
+	Amount      float64 `json:"amount" binding:"nonzero" example:"100.50"`
+	Date        string  `json:"date,omitempty" binding:"omitempty,date" example:"2024-12-01"`
+	Description string  `json:"description" example:"Grocery shopping"`
+	CategoryID  int     `json:"category_id" example:"1"`
+	TagIDs      []int   `json:"tag_ids,omitempty" example:"1,2"`
 }
 
 type CreateTransactionResponse struct {
@@ -37,72 +55,192 @@ type CreateTransactionResponse struct {
 // @Security BearerAuth
 // @Param transaction body CreateTransactionRequest true "Transaction data (date is optional, defaults to now)"
 // @Success 201 {object} CreateTransactionResponse
+// @Failure 400 {object} response.Problem
+// @Failure 422 {object} response.Problem
 // @Router /transactions [post]
 func (h *TransactionHandler) CreateTransactionGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	var txn repository.Transaction
-	if err := c.ShouldBindJSON(&txn); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	ctx := c.Request.Context()
+	var req CreateTransactionRequest
+	if !bindJSON(c, &req, func(v *validation.Validator) {
+		v.Category(ctx, h.Repo, userID, "category_id", req.CategoryID)
+	}) {
 		return
 	}
 
-	txn.UserID = userID
-	txn.Date = time.Now()
+	txn := repository.Transaction{
+		Amount:      req.Amount,
+		Date:        time.Now(),
+		Description: req.Description,
+		CategoryID:  req.CategoryID,
+		UserID:      userID,
+		TagIDs:      req.TagIDs,
+	}
+	if req.Date != "" {
+		date, err := parseDate(ctx, h.Repo, userID, req.Date)
+		if err != nil {
+			writeDateError(c, err)
+			return
+		}
+		txn.Date = date
+	}
 
-	id, err := h.Repo.CreateTransaction(c.Request.Context(), txn)
+	id, err := h.Repo.CreateTransaction(ctx, txn)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		fail(c, err, "Failed to create transaction")
 		return
 	}
+	metrics.TransactionsCreated.Inc()
 
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
 // GetTransactionsGin handles fetching all transactions for the user.
 // @Summary Get transactions
-// @Description Fetch all transactions for the authenticated user
+// @Description Fetch all transactions for the authenticated user, optionally only those carrying the given tags. Also served by the deprecated GET /transactions/list.
 // @Tags Transactions
 // @Produce json
 // @Security BearerAuth
+// @Param tags query string false "Comma-separated tag IDs"
+// @Param tag_match query string false "Whether transactions must carry any (default) or all of the tags" Enums(any, all)
 // @Success 200 {array} repository.Transaction
-// @Router /transactions/list [get]
+// @Header 200 {string} ETag "Weak entity tag of the list"
+// @Router /transactions [get]
 func (h *TransactionHandler) GetTransactionsGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	transactions, err := h.Repo.GetTransactions(c.Request.Context(), userID)
+	var filter repository.TransactionFilter
+	if tags := c.Query("tags"); tags != "" {
+		for _, part := range strings.Split(tags, ",") {
+			tagID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				abort(c, http.StatusBadRequest, "Invalid tag ID")
+				return
+			}
+			filter.TagIDs = append(filter.TagIDs, tagID)
+		}
+	}
+
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		abort(c, http.StatusBadRequest, "tag_match must be either any or all")
+		return
+	}
+
+	var transactions []repository.Transaction
+	var err error
+	if len(filter.TagIDs) > 0 {
+		transactions, err = h.Repo.GetTransactionsFiltered(c.Request.Context(), userID, filter)
+	} else {
+		transactions, err = h.Repo.GetTransactions(c.Request.Context(), userID)
+	}
+	if err != nil {
+		fail(c, err, "Failed to fetch transactions")
+		return
+	}
+
+	jsonWithETag(c, transactions)
+}
+
+// GetTransactionGin handles fetching a single transaction of the user.
+// @Summary Get a transaction
+// @Description Fetch a transaction by ID for the authenticated user
+// @Tags Transactions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} repository.Transaction
+// @Header 200 {string} ETag "Current version of the transaction"
+// @Failure 404 {object} response.Problem
+// @Router /transactions/{id} [get]
+func (h *TransactionHandler) GetTransactionGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	txn, err := h.Repo.GetTransaction(c.Request.Context(), userID, txnID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		fail(c, err, "Failed to fetch transaction")
+		return
+	}
+	if txn == nil {
+		abort(c, http.StatusNotFound, "Transaction not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	jsonWithVersion(c, txn.Version, txn)
 }
 
 // UpdateTransactionGin handles updating a transaction.
 // @Summary Update a transaction
-// @Description Update a transaction for the authenticated user
+// @Description Update a transaction for the authenticated user. If-Match must carry the version the change is based on, or "*". Also served by the deprecated PUT /transactions/update, which takes the ID from the body.
 // @Tags Transactions
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param transaction body repository.Transaction true "Transaction data (include transaction ID)"
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
+// @Param transaction body UpdateTransactionRequest true "Transaction data (date is optional, defaults to the stored one)"
 // @Success 204 "No Content"
-// @Router /transactions/update [put]
+// @Failure 400 {object} response.Problem
+// @Failure 412 {object} response.Problem
+// @Failure 422 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id} [put]
 func (h *TransactionHandler) UpdateTransactionGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	var txn repository.Transaction
-	if err := c.ShouldBindJSON(&txn); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var req UpdateTransactionRequest
+	if !bindJSON(c, &req, func(v *validation.Validator) {
+		v.Category(ctx, h.Repo, userID, "category_id", req.CategoryID)
+	}) {
 		return
 	}
 
-	txn.UserID = userID
-	txn.Date = time.Now()
+	if id := c.Param("id"); id != "" {
+		txnID, err := strconv.Atoi(id)
+		if err != nil {
+			abort(c, http.StatusBadRequest, "Invalid transaction ID")
+			return
+		}
+		req.ID = txnID
+	}
+
+	txn := repository.Transaction{
+		ID:          req.ID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		CategoryID:  req.CategoryID,
+		UserID:      userID,
+		TagIDs:      req.TagIDs,
+		Version:     version,
+	}
+	if req.Date != "" {
+		date, err := parseDate(ctx, h.Repo, userID, req.Date)
+		if err != nil {
+			writeDateError(c, err)
+			return
+		}
+		txn.Date = date
+	}
 
-	if err := h.Repo.UpdateTransaction(c.Request.Context(), txn); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+	// A missing tag_ids field leaves the tags untouched, an empty list clears them
+	if err := h.Repo.UpdateTransaction(ctx, txn); err != nil {
+		fail(c, err, "Failed to update transaction")
 		return
 	}
 
@@ -111,24 +249,33 @@ func (h *TransactionHandler) UpdateTransactionGin(c *gin.Context) {
 
 // DeleteTransactionGin handles deleting a transaction.
 // @Summary Delete a transaction
-// @Description Delete a transaction by ID for the authenticated user
+// @Description Move a transaction to the trash. It can be restored until it is purged after the retention period. If-Match must carry the current version of the transaction, or "*". Also served by the deprecated DELETE /transactions/delete?id=.
 // @Tags Transactions
 // @Produce json
 // @Security BearerAuth
-// @Param id query int true "Transaction ID"
+// @Param id path int true "Transaction ID"
+// @Param If-Match header string true "Transaction version as an entity tag, e.g. \"3\""
 // @Success 204 "No Content"
-// @Router /transactions/delete [delete]
+// @Failure 412 {object} response.Problem
+// @Failure 428 {object} response.Problem
+// @Router /transactions/{id} [delete]
 func (h *TransactionHandler) DeleteTransactionGin(c *gin.Context) {
 	userID := c.GetInt("userID")
 
-	txnID, err := strconv.Atoi(c.Query("id"))
+	txnID, err := pathOrQueryID(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
 		return
 	}
 
-	if err := h.Repo.DeleteTransaction(c.Request.Context(), userID, txnID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.Repo.DeleteTransaction(c.Request.Context(), userID, txnID, version)
+	if err != nil {
+		fail(c, err, "Failed to delete transaction")
 		return
 	}
 
@@ -4,12 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/migrate"
 	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/nemopss/financial-tracker/migrations"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,6 +27,8 @@ import (
 func TestCreateTransactionHandler(t *testing.T) {
 	mockRepo := &repository.MockRepo{}
 
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 1}}, nil)
+
 	// Проверка транзакции без точного сравнения даты
 	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
 		return txn.Amount == 100.50 &&
@@ -36,28 +48,91 @@ func TestCreateTransactionHandler(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.CreateTransaction(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTransactionGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int
-		Data       map[string]int
-	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	var data map[string]int
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Equal(t, 1, wrappedResponse.Data["id"])
+	assert.Equal(t, 1, data["id"])
 
 	mockRepo.AssertExpectations(t)
 }
 
+// TestUncategorisedTransactionHandlers runs against SQLite with foreign keys
+// enforced, to make sure category_id 0 is accepted end to end.
+func TestUncategorisedTransactionHandlers(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteDB(filepath.Join(t.TempDir(), "handlers.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { repo.Conn.Close() })
+	migrator, err := migrate.New(repo.Conn, migrations.SQLiteFS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator.Dialect = migrate.SQLite
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	userID, err := repo.CreateUser(ctx, "alice", "hash")
+	assert.NoError(t, err)
+	category, err := repo.CreateCategory(ctx, userID, "Food")
+	assert.NoError(t, err)
+
+	handler := &TransactionHandler{Repo: repo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", strings.NewReader(`{"amount": -8, "description": "Cash", "category_id": 0}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, userID)
+	serve(c, handler.CreateTransactionGin)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+
+	var created CreateTransactionResponse
+	assert.NoError(t, json.NewDecoder(w.Result().Body).Decode(&created))
+
+	for _, categoryID := range []int{category, 0} {
+		body := fmt.Sprintf(`{"amount": -9, "description": "Cash", "category_id": %d}`, categoryID)
+		req = httptest.NewRequest(http.MethodPut, "/api/v1/transactions/"+strconv.Itoa(created.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: strconv.Itoa(created.ID)}}
+		c.Set(middleware.UserIDKey, userID)
+		serve(c, handler.UpdateTransactionGin)
+		c.Writer.WriteHeaderNow()
+
+		assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	}
+
+	txn, err := repo.GetTransaction(ctx, userID, created.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, 0, txn.CategoryID)
+		assert.Equal(t, -9.0, txn.Amount)
+	}
+}
+
 func TestGetTransactionsHandler(t *testing.T) {
 	mockRepo := &repository.MockRepo{}
 
@@ -71,26 +146,230 @@ func TestGetTransactionsHandler(t *testing.T) {
 	handler := &TransactionHandler{Repo: mockRepo}
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/list", nil)
-	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 
 	w := httptest.NewRecorder()
-	handler.GetTransactions(w, req)
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionsGin)
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var wrappedResponse struct {
-		StatusCode int                      `json:"status_code"`
-		Data       []repository.Transaction `json:"data"`
+	var data []repository.Transaction
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data, 2)
+	assert.Equal(t, "Groceries", data[0].Description)
+	assert.Equal(t, "Coffee", data[1].Description)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateTransactionHandlerWithTags(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 1}}, nil)
+
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
+		return txn.Amount == -250 && txn.UserID == 1 && slices.Equal(txn.TagIDs, []int{2, 3})
+	})).Return(7, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	body := map[string]interface{}{
+		"amount":      -250,
+		"description": "Hotel",
+		"category_id": 1,
+		"tag_ids":     []int{2, 3},
 	}
-	err := json.NewDecoder(resp.Body).Decode(&wrappedResponse)
+	b, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTransactionGin)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTransactionsHandlerByTags(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTransactionsFiltered", mock.Anything, 1, repository.TransactionFilter{TagIDs: []int{2, 3}, MatchAllTags: true}).
+		Return([]repository.Transaction{{ID: 7, Amount: -250, Description: "Hotel", UserID: 1, TagIDs: []int{2, 3}}}, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/list?tags=2,3&tag_match=all", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionsGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data []repository.Transaction
+	err := json.NewDecoder(resp.Body).Decode(&data)
 	if err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	assert.Len(t, wrappedResponse.Data, 2)
-	assert.Equal(t, "Groceries", wrappedResponse.Data[0].Description)
-	assert.Equal(t, "Coffee", wrappedResponse.Data[1].Description)
+	assert.Len(t, data, 1)
+	assert.Equal(t, []int{2, 3}, data[0].TagIDs)
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetTransactionHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetTransaction", mock.Anything, 1, 7).
+		Return(&repository.Transaction{ID: 7, Amount: -15.32, Description: "Coffee", UserID: 1, Version: 2}, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions/7", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTransactionGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	var data repository.Transaction
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "Coffee", data.Description)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateTransactionHandlerPathID(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 2}}, nil)
+
+	mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
+		return txn.ID == 7 && txn.UserID == 1 && txn.Version == 2 && txn.Description == "Coffee" && txn.Date.IsZero()
+	})).Return(nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]interface{}{"id": 99, "amount": -4.5, "description": "Coffee", "category_id": 2})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/transactions/7", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.UpdateTransactionGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateTransactionHandlerDateOnly(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("GetUserTimezone", mock.Anything, 1).Return("Asia/Tokyo", nil)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(txn repository.Transaction) bool {
+		// Midnight in Tokyo is 15:00 UTC on the previous day
+		return txn.Date.Equal(time.Date(2024, 11, 30, 15, 0, 0, 0, time.UTC))
+	})).Return(3, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]interface{}{"amount": -20, "date": "2024-12-01", "description": "Sushi"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTransactionGin)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateTransactionHandlerInvalidDate(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]interface{}{"amount": -20, "date": "01.12.2024"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTransactionGin)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+	mockRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestCreateTransactionHandlerReportsEveryViolation(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	// Category 4 belongs to someone else
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 1}}, nil)
+
+	handler := &TransactionHandler{Repo: mockRepo}
+
+	b, _ := json.Marshal(map[string]interface{}{"amount": 0, "category_id": 4, "date": "yesterday"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.CreateTransactionGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var data response.Problem
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, []response.InvalidParam{
+		{Name: "amount", Reason: "must not be zero"},
+		{Name: "date", Reason: "must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{Name: "category_id", Reason: "category does not exist"},
+	}, data.InvalidParams)
+
+	mockRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
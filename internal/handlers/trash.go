@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+)
+
+type TrashHandler struct {
+	Repo repository.Repository
+}
+
+// Handlers
+
+// GetTrashGin handles listing the trash of the user.
+// @Summary Get trash
+// @Description Fetch the deleted transactions and categories of the authenticated user that have not been purged yet
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} repository.Trash
+// @Router /trash [get]
+func (h *TrashHandler) GetTrashGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	trash, err := h.Repo.GetTrash(c.Request.Context(), userID)
+	if err != nil {
+		fail(c, err, "Failed to fetch trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+// RestoreTransactionGin handles restoring a deleted transaction.
+// @Summary Restore a transaction
+// @Description Move a transaction of the authenticated user out of the trash. Fails with 409 while its category, or that of one of its split lines, is still in the trash. Also served by the deprecated POST /transactions/restore?id=.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 204 "No Content"
+// @Failure 409 {object} response.Problem
+// @Router /transactions/{id}/restore [post]
+func (h *TrashHandler) RestoreTransactionGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	txnID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	if err := h.Repo.RestoreTransaction(c.Request.Context(), userID, txnID); err != nil {
+		fail(c, err, "Failed to restore transaction")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreCategoryGin handles restoring a deleted category.
+// @Summary Restore a category
+// @Description Move a category of the authenticated user out of the trash. Also served by the deprecated POST /categories/restore?id=.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 204 "No Content"
+// @Router /categories/{id}/restore [post]
+func (h *TrashHandler) RestoreCategoryGin(c *gin.Context) {
+	userID := c.GetInt("userID")
+
+	categoryID, err := pathOrQueryID(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	if err := h.Repo.RestoreCategory(c.Request.Context(), userID, categoryID); err != nil {
+		fail(c, err, "Failed to restore category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
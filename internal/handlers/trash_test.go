@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTrashHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	deletedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("GetTrash", mock.Anything, 1).
+		Return(&repository.Trash{
+			Transactions: []repository.Transaction{{ID: 7, Amount: 42, Description: "Coffee", DeletedAt: &deletedAt}},
+			Categories:   []repository.Category{{ID: 3, Name: "Old", DeletedAt: &deletedAt}},
+		}, nil)
+
+	handler := &TrashHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/trash", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.GetTrashGin)
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var data repository.Trash
+	err := json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	assert.Len(t, data.Transactions, 1)
+	assert.Equal(t, 7, data.Transactions[0].ID)
+	assert.True(t, deletedAt.Equal(*data.Transactions[0].DeletedAt))
+	assert.Len(t, data.Categories, 1)
+	assert.Equal(t, "Old", data.Categories[0].Name)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreTransactionHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("RestoreTransaction", mock.Anything, 1, 7).Return(nil)
+
+	handler := &TrashHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/7/restore", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.RestoreTransactionGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreCategoryHandler(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+
+	mockRepo.On("RestoreCategory", mock.Anything, 1, 3).Return(nil)
+
+	handler := &TrashHandler{Repo: mockRepo}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories/3/restore", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Set(middleware.UserIDKey, 1)
+	serve(c, handler.RestoreCategoryGin)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	mockRepo.AssertExpectations(t)
+}
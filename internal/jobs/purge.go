@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/storage"
+)
+
+// TrashPurger permanently deletes records that stayed in the trash longer than
+// Retention, together with the files of their attachments.
+type TrashPurger struct {
+	Repo      repository.Repository
+	Storage   storage.BlobStorage
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// Run purges the trash once immediately and then every Interval until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.PurgeOnce(ctx); err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes everything trashed before now minus Retention.
+func (p *TrashPurger) PurgeOnce(ctx context.Context) error {
+	attachments, err := p.Repo.PurgeDeleted(ctx, time.Now().Add(-p.Retention))
+	if err != nil {
+		return err
+	}
+
+	if p.Storage != nil {
+		for _, a := range attachments {
+			if err := p.Storage.Delete(ctx, a.StorageKey); err != nil {
+				log.Printf("Failed to delete attachment file %s: %v", a.StorageKey, err)
+			}
+		}
+	}
+
+	if len(attachments) > 0 {
+		log.Printf("Purged trash, removed %d attachment files", len(attachments))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrashPurgerPurgeOnce(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	blobs, _ := storage.NewLocalStorage(t.TempDir())
+	blobs.Put(context.Background(), "users/1/transactions/5/abc", strings.NewReader("receipt"), 7, "text/plain")
+
+	mockRepo.On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		cutoff := time.Now().Add(-30 * 24 * time.Hour)
+		return before.Sub(cutoff).Abs() < time.Minute
+	})).Return([]repository.Attachment{{ID: 3, TransactionID: 5, StorageKey: "users/1/transactions/5/abc"}}, nil)
+
+	purger := &TrashPurger{Repo: mockRepo, Storage: blobs, Retention: 30 * 24 * time.Hour, Interval: time.Hour}
+
+	err := purger.PurgeOnce(context.Background())
+	assert.NoError(t, err)
+
+	_, err = blobs.Get(context.Background(), "users/1/transactions/5/abc")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTrashPurgerRunStopsOnCancel(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return(nil, nil)
+
+	purger := &TrashPurger{Repo: mockRepo, Retention: time.Hour, Interval: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
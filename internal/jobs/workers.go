@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Workers runs background jobs on a shared context and waits for them to
+// return once it is cancelled.
+type Workers struct {
+	wg sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped []string
+}
+
+// Go runs job in its own goroutine until ctx is cancelled.
+func (w *Workers) Go(ctx context.Context, name string, job func(context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		job(ctx)
+
+		w.mu.Lock()
+		w.stopped = append(w.stopped, name)
+		w.mu.Unlock()
+		log.Printf("Stopped %s", name)
+	}()
+}
+
+// Check returns an error naming the jobs that are no longer running.
+func (w *Workers) Check(context.Context) error {
+	w.mu.Lock()
+	stopped := append([]string(nil), w.stopped...)
+	w.mu.Unlock()
+
+	if len(stopped) == 0 {
+		return nil
+	}
+	sort.Strings(stopped)
+	return fmt.Errorf("background jobs stopped: %s", strings.Join(stopped, ", "))
+}
+
+// Wait blocks until every job has returned, or gives up when ctx is done.
+func (w *Workers) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs did not stop: %w", ctx.Err())
+	}
+}
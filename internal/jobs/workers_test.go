@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkersWaitForJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := &Workers{}
+
+	stopped := make(chan struct{}, 2)
+	for range 2 {
+		workers.Go(ctx, "job", func(ctx context.Context) {
+			<-ctx.Done()
+			stopped <- struct{}{}
+		})
+	}
+
+	cancel()
+	assert.NoError(t, workers.Wait(context.Background()))
+	assert.Len(t, stopped, 2)
+}
+
+func TestWorkersWaitGivesUp(t *testing.T) {
+	workers := &Workers{}
+	release := make(chan struct{})
+	defer close(release)
+
+	// The job ignores cancellation
+	workers.Go(context.Background(), "stuck job", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, workers.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestWorkersCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := &Workers{}
+
+	workers.Go(ctx, "trash purger", func(ctx context.Context) { <-ctx.Done() })
+	assert.NoError(t, workers.Check(context.Background()))
+
+	cancel()
+	assert.NoError(t, workers.Wait(context.Background()))
+	assert.EqualError(t, workers.Check(context.Background()), "background jobs stopped: trash purger")
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/transactions/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/api/v1/transactions/1", "/api/v1/transactions/2", "/wp-login.php"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Requests are labelled by the route they matched rather than their path
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/api/v1/transactions/:id", "204")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(httpDuration))
+}
+
+func TestObserveRepository(t *testing.T) {
+	ObserveRepository("GetTransactions", 20*time.Millisecond)
+
+	expected := `
+# HELP financial_tracker_repository_call_duration_seconds Time spent in repository methods, including their database queries.
+# TYPE financial_tracker_repository_call_duration_seconds histogram
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.001"} 0
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.0025"} 0
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.005"} 0
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.01"} 0
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.025"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.05"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.1"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.25"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="0.5"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="1"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="2.5"} 1
+financial_tracker_repository_call_duration_seconds_bucket{method="GetTransactions",le="+Inf"} 1
+financial_tracker_repository_call_duration_seconds_sum{method="GetTransactions"} 0.02
+financial_tracker_repository_call_duration_seconds_count{method="GetTransactions"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(queryDuration, strings.NewReader(expected)))
+}
+
+func TestPoolCollector(t *testing.T) {
+	collector := &poolCollector{stats: func() repository.PoolStats {
+		return repository.PoolStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitDuration: 1500 * time.Millisecond}
+	}}
+
+	expected := `
+# HELP financial_tracker_db_pool_in_use_connections Connections currently in use.
+# TYPE financial_tracker_db_pool_in_use_connections gauge
+financial_tracker_db_pool_in_use_connections 1
+# HELP financial_tracker_db_pool_max_open_connections Maximum number of open connections to the database.
+# TYPE financial_tracker_db_pool_max_open_connections gauge
+financial_tracker_db_pool_max_open_connections 25
+# HELP financial_tracker_db_pool_wait_duration_seconds_total Time spent waiting for connections.
+# TYPE financial_tracker_db_pool_wait_duration_seconds_total counter
+financial_tracker_db_pool_wait_duration_seconds_total 1.5
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"financial_tracker_db_pool_in_use_connections",
+		"financial_tracker_db_pool_max_open_connections",
+		"financial_tracker_db_pool_wait_duration_seconds_total",
+	))
+	assert.Equal(t, 9, testutil.CollectAndCount(collector))
+}
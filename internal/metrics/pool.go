@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+var (
+	poolMaxOpen           = poolDesc("max_open_connections", "Maximum number of open connections to the database.")
+	poolOpen              = poolDesc("open_connections", "Established connections, in use and idle.")
+	poolInUse             = poolDesc("in_use_connections", "Connections currently in use.")
+	poolIdle              = poolDesc("idle_connections", "Idle connections.")
+	poolWaitCount         = poolDesc("wait_count_total", "Times a caller had to wait for a connection.")
+	poolWaitDuration      = poolDesc("wait_duration_seconds_total", "Time spent waiting for connections.")
+	poolMaxIdleClosed     = poolDesc("max_idle_closed_total", "Connections closed because of the idle connection limit.")
+	poolMaxIdleTimeClosed = poolDesc("max_idle_time_closed_total", "Connections closed because they were idle for too long.")
+	poolMaxLifetimeClosed = poolDesc("max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.")
+)
+
+// poolCollector reads the pool stats on every scrape.
+type poolCollector struct {
+	stats func() repository.PoolStats
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		poolMaxOpen, poolOpen, poolInUse, poolIdle, poolWaitCount, poolWaitDuration,
+		poolMaxIdleClosed, poolMaxIdleTimeClosed, poolMaxLifetimeClosed,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(poolMaxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolOpen, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolInUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(poolIdle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(poolWaitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(poolWaitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(poolMaxIdleClosed, prometheus.CounterValue, float64(s.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(poolMaxIdleTimeClosed, prometheus.CounterValue, float64(s.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(poolMaxLifetimeClosed, prometheus.CounterValue, float64(s.MaxLifetimeClosed))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/nemopss/financial-tracker/internal/response"
 )
 
 const UserIDKey = "userID"
@@ -17,14 +18,14 @@ func AuthGin(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+			c.Error(response.NewProblem(http.StatusUnauthorized, "Authorization header missing"))
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
+			c.Error(response.NewProblem(http.StatusUnauthorized, "Invalid Authorization header format"))
 			c.Abort()
 			return
 		}
@@ -38,21 +39,21 @@ func AuthGin(secret string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Error(response.NewProblem(http.StatusUnauthorized, "Invalid token"))
 			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok || claims["user_id"] == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Error(response.NewProblem(http.StatusUnauthorized, "Invalid token claims"))
 			c.Abort()
 			return
 		}
 
 		userID, ok := claims["user_id"].(float64)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			c.Error(response.NewProblem(http.StatusUnauthorized, "Invalid user ID"))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks the responses of a legacy route with a Deprecation header
+// (RFC 9745) stating since when it is deprecated, and links the route that
+// replaces it.
+func Deprecated(since time.Time, successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	link := "<" + successor + `>; rel="successor-version"`
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Link", link)
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+)
+
+// Errors renders the last error recorded with c.Error during the request as
+// RFC 7807 problem details, unless a response was written already. Register
+// it ahead of the middleware whose errors it should render.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		RenderErrors(c)
+	}
+}
+
+// RenderErrors does the work of Errors for a request that has been handled.
+// The meta of a recorded error, if it is a string, is used as the detail of
+// unexpected failures instead of the internal error message.
+func RenderErrors(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	last := c.Errors.Last()
+	problem := problemFor(last.Err)
+	if problem.Status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, last.Err)
+		if detail, ok := last.Meta.(string); ok {
+			problem.Detail = detail
+		}
+	}
+	problem.Instance = c.Request.URL.Path
+
+	var conflict *repository.VersionConflictError
+	if errors.As(last.Err, &conflict) {
+		c.Header("ETag", `"`+strconv.Itoa(conflict.Current)+`"`)
+	}
+
+	c.Header("Content-Type", response.ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// problemFor maps an error to the problem describing it to the client.
+func problemFor(err error) *response.Problem {
+	var (
+		problem  *response.Problem
+		conflict *repository.VersionConflictError
+		inUse    *repository.CategoryInUseError
+		invalid  *repository.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &problem):
+		p := *problem
+		return &p
+	case errors.As(err, &conflict):
+		p := response.NewProblem(http.StatusPreconditionFailed, "Record was modified, reload it and try again")
+		p.CurrentVersion = conflict.Current
+		return p
+	case errors.As(err, &inUse):
+		p := response.NewProblem(http.StatusConflict, "Category is in use")
+		p.UsageCount = inUse.Count
+		return p
+	case errors.As(err, &invalid):
+		p := response.NewProblem(http.StatusUnprocessableEntity, "Request failed validation")
+		for _, f := range invalid.Fields {
+			p.InvalidParams = append(p.InvalidParams, response.InvalidParam{Name: f.Field, Reason: f.Message})
+		}
+		return p
+	case errors.Is(err, repository.ErrNotFound):
+		return response.NewProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrConflict):
+		return response.NewProblem(http.StatusConflict, err.Error())
+	case errors.Is(err, repository.ErrValidation):
+		return response.NewProblem(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrForbidden):
+		return response.NewProblem(http.StatusForbidden, err.Error())
+	default:
+		return response.NewProblem(http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,570 @@
+// Package migrate applies the SQL migrations embedded from migrations/ without
+// an external tool. Files keep their goose annotations and applied versions are
+// recorded in goose's goose_db_version table, so databases that were migrated
+// with the goose binary are picked up where they left off.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoneApplied is returned by Down and Redo when there is nothing to roll back.
+var ErrNoneApplied = errors.New("no migrations applied")
+
+// OutdatedSchemaError is returned by Check when the database lacks migrations
+// the binary was built with.
+type OutdatedSchemaError struct {
+	Current int64
+	Latest  int64
+	Pending int
+}
+
+func (e *OutdatedSchemaError) Error() string {
+	return fmt.Sprintf("database schema is at version %d, latest is %d (%d pending migrations)", e.Current, e.Latest, e.Pending)
+}
+
+// Migration is a single SQL file split into statements.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// NoTransaction is set by "-- +goose NO TRANSACTION" for statements such as
+	// CREATE INDEX CONCURRENTLY that cannot run inside a transaction.
+	NoTransaction bool
+}
+
+// Status describes a migration and when it was applied. AppliedAt is zero for
+// pending migrations.
+type Status struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// Dialects of the databases the version table can be created in.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// versionTables create goose's version table, laid out like goose does for
+// each dialect.
+var versionTables = map[string]string{
+	Postgres: `CREATE TABLE IF NOT EXISTS goose_db_version (
+		id SERIAL PRIMARY KEY,
+		version_id BIGINT NOT NULL,
+		is_applied BOOLEAN NOT NULL,
+		tstamp TIMESTAMP DEFAULT NOW()
+	)`,
+	SQLite: `CREATE TABLE IF NOT EXISTS goose_db_version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT (datetime('now'))
+	)`,
+}
+
+// versionTableExists reports whether goose's version table exists, so that
+// read-only callers do not have to create it.
+var versionTableExists = map[string]string{
+	Postgres: "SELECT to_regclass('goose_db_version') IS NOT NULL",
+	SQLite:   "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')",
+}
+
+// lockKey identifies the Postgres advisory lock held while migrating, so that
+// instances starting at the same time apply each migration once.
+const lockKey int64 = 0x66696e7472616b // "fintrak"
+
+// Migrator applies Migrations, ordered by version, to DB.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+	// Dialect is Postgres or SQLite; empty means Postgres
+	Dialect string
+}
+
+// New loads the migrations in fsys for db.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// Load parses every *.sql file at the root of fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string)
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s: name must start with a positive version number", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", name, version, other)
+		}
+		seen[version] = name
+
+		src, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		m, err := parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		m.Version = version
+		m.Name = name
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parse splits a goose-annotated file into its Up and Down statements.
+// Statements end with a semicolon at the end of a line that is outside string
+// literals and dollar-quoted bodies. Statements wrapped in StatementBegin and
+// StatementEnd are kept whole regardless of semicolons.
+func parse(src string) (Migration, error) {
+	var m Migration
+	var section *[]string
+	var stmt strings.Builder
+	inBlock := false
+	quote := ""
+
+	flush := func() {
+		*section = append(*section, strings.TrimSpace(stmt.String()))
+		stmt.Reset()
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Inside a literal or a dollar-quoted body an annotation is plain text
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok && quote == "" {
+			annotation = strings.TrimSpace(annotation)
+			switch annotation {
+			case "Up", "Down":
+				if stmt.Len() > 0 || inBlock {
+					return m, errors.New("statement is not terminated before " + trimmed)
+				}
+				if annotation == "Up" {
+					section = &m.Up
+				} else {
+					section = &m.Down
+				}
+			case "StatementBegin":
+				if section == nil || inBlock || stmt.Len() > 0 {
+					return m, errors.New("unexpected StatementBegin")
+				}
+				inBlock = true
+			case "StatementEnd":
+				if !inBlock {
+					return m, errors.New("StatementEnd without StatementBegin")
+				}
+				inBlock = false
+				flush()
+			case "NO TRANSACTION":
+				m.NoTransaction = true
+			default:
+				return m, fmt.Errorf("unknown annotation %q", trimmed)
+			}
+			continue
+		}
+
+		// Blank lines and comments between statements are dropped
+		if !inBlock && stmt.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if section == nil {
+			return m, errors.New("statement before -- +goose Up")
+		}
+
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		if inBlock {
+			continue
+		}
+		quote = scanQuotes(line, quote)
+		if quote == "" && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+
+	switch {
+	case quote != "":
+		return m, fmt.Errorf("unterminated %s quote", quote)
+	case inBlock:
+		return m, errors.New("StatementBegin without StatementEnd")
+	case stmt.Len() > 0:
+		return m, errors.New("last statement is not terminated with a semicolon")
+	case len(m.Up) == 0:
+		return m, errors.New("no -- +goose Up statements")
+	}
+	return m, nil
+}
+
+// scanQuotes returns the quoting state after line, given the state before it:
+// "'" inside a string literal, the dollar tag such as "$$" or "$body$" inside
+// a dollar-quoted body, or empty outside both. A quote escaped by doubling it
+// closes and reopens the literal, which leaves the state unchanged.
+func scanQuotes(line, quote string) string {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != "":
+			if strings.HasPrefix(line[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			}
+		case line[i] == '\'':
+			quote = "'"
+		case strings.HasPrefix(line[i:], "--"):
+			return ""
+		case line[i] == '$':
+			if tag := dollarTag(line[i:]); tag != "" {
+				quote = tag
+				i += len(tag) - 1
+			}
+		}
+	}
+	return quote
+}
+
+// dollarTag returns the dollar-quote opener at the start of s, such as "$$" or
+// "$body$", or empty when s starts with something else like a $1 parameter.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// Latest returns the highest version known to the migrator.
+func (m *Migrator) Latest() int64 {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Version returns the highest version applied to the database, or 0.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Up applies every pending migration in version order and returns how many
+// were applied. It stops at the first failure; earlier migrations stay applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return 0, err
+	}
+	applied, err := m.readApplied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, migration, true); err != nil {
+			return count, err
+		}
+		log.Printf("Applied migration %s", migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	defer unlock()
+	return m.down(ctx)
+}
+
+func (m *Migrator) down(ctx context.Context) (Migration, error) {
+	migration, err := m.current(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if err := m.run(ctx, migration, false); err != nil {
+		return Migration{}, err
+	}
+	log.Printf("Rolled back migration %s", migration.Name)
+	return migration, nil
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	defer unlock()
+
+	migration, err := m.down(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if err := m.run(ctx, migration, true); err != nil {
+		return Migration{}, err
+	}
+	log.Printf("Applied migration %s", migration.Name)
+	return migration, nil
+}
+
+// Status lists every known migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: applied[migration.Version],
+		})
+	}
+	return statuses, nil
+}
+
+// Check returns an OutdatedSchemaError when any known migration is not
+// applied yet. A database that is ahead of the binary passes the check.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	for v := range applied {
+		current = max(current, v)
+	}
+	pending := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return &OutdatedSchemaError{Current: current, Latest: m.Latest(), Pending: pending}
+	}
+	return nil
+}
+
+// current finds the migration behind the highest applied version.
+func (m *Migrator) current(ctx context.Context) (Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if version == 0 {
+		return Migration{}, ErrNoneApplied
+	}
+	for _, migration := range m.Migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("applied migration %d is unknown to this binary", version)
+}
+
+// applied returns the applied versions and when they were applied without
+// writing to the database: a missing version table means nothing is applied
+// yet.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	query, ok := versionTableExists[m.dialect()]
+	if !ok {
+		return nil, fmt.Errorf("unknown database dialect %q", m.Dialect)
+	}
+
+	var exists bool
+	if err := m.DB.QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up migration version table: %w", err)
+	}
+	if !exists {
+		return map[int64]time.Time{}, nil
+	}
+	return m.readApplied(ctx)
+}
+
+// readApplied reads the applied versions from the version table. Like goose,
+// the newest row of a version decides whether it is applied.
+func (m *Migrator) readApplied(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := m.DB.QueryContext(ctx, "SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		if seen[version] || version == 0 {
+			continue
+		}
+		seen[version] = true
+		if isApplied {
+			applied[version] = appliedAt.Time
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// lock takes the Postgres advisory lock on a connection of its own and returns
+// the function releasing it. The lock is held by that session, so migrations
+// keep running on the pool while other migrators wait. SQLite serialises
+// writers itself and takes no lock.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if m.dialect() != Postgres {
+		return func() {}, nil
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			log.Printf("Failed to unlock migrations: %v", err)
+			// Drop the session instead of returning it to the pool, which
+			// releases the lock on the server
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
+
+func (m *Migrator) dialect() string {
+	if m.Dialect == "" {
+		return Postgres
+	}
+	return m.Dialect
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	ddl, ok := versionTables[m.dialect()]
+	if !ok {
+		return fmt.Errorf("unknown database dialect %q", m.Dialect)
+	}
+
+	_, err := m.DB.ExecContext(ctx, ddl)
+	if err != nil {
+		return fmt.Errorf("failed to create migration version table: %w", err)
+	}
+
+	// goose starts the table with version 0, keep it compatible
+	_, err = m.DB.ExecContext(ctx,
+		"INSERT INTO goose_db_version (version_id, is_applied) SELECT 0, TRUE WHERE NOT EXISTS (SELECT 1 FROM goose_db_version)")
+	if err != nil {
+		return fmt.Errorf("failed to initialize migration version table: %w", err)
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// run executes one direction of a migration and records it, inside a single
+// transaction unless the migration opts out.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	statements, record, verb := migration.Up, "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, TRUE)", "apply"
+	if !up {
+		statements, record, verb = migration.Down, "DELETE FROM goose_db_version WHERE version_id = $1", "roll back"
+	}
+
+	exec := func(q execer) error {
+		for _, stmt := range statements {
+			if _, err := q.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		_, err := q.ExecContext(ctx, record, migration.Version)
+		return err
+	}
+
+	if migration.NoTransaction {
+		if err := exec(m.DB); err != nil {
+			return fmt.Errorf("failed to %s migration %s: %w", verb, migration.Name, err)
+		}
+		return nil
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := exec(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to %s migration %s: %w", verb, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to %s migration %s: %w", verb, migration.Name, err)
+	}
+	return nil
+}
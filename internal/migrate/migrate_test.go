@@ -0,0 +1,305 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nemopss/financial-tracker/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+const functionMigration = `-- +goose Up
+-- Accounts of the users
+CREATE TABLE accounts (
+    id SERIAL PRIMARY KEY
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS touch();
+DROP TABLE IF EXISTS accounts;
+`
+
+func expectVersionTable(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS goose_db_version").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO goose_db_version \\(version_id, is_applied\\) SELECT 0, TRUE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC").
+		WillReturnRows(rows)
+}
+
+func expectApplied(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT to_regclass\\('goose_db_version'\\) IS NOT NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id DESC").
+		WillReturnRows(rows)
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").
+		WithArgs(lockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").
+		WithArgs(lockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func versionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"version_id", "is_applied", "tstamp"})
+}
+
+func testMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, fstest.MapFS{
+		"2_create_accounts.sql": {Data: []byte(functionMigration)},
+		"1_create_users.sql":    {Data: []byte("-- +goose Up\nCREATE TABLE users (id INT);\n\n-- +goose Down\nDROP TABLE users;\n")},
+	})
+	assert.NoError(t, err)
+	return m, mock
+}
+
+func TestLoad(t *testing.T) {
+	m, _ := testMigrator(t)
+
+	assert.Len(t, m.Migrations, 2)
+	assert.Equal(t, int64(1), m.Migrations[0].Version)
+	assert.Equal(t, "2_create_accounts.sql", m.Migrations[1].Name)
+	assert.Equal(t, int64(2), m.Latest())
+
+	accounts := m.Migrations[1]
+	assert.Equal(t, []string{
+		"CREATE TABLE accounts (\n    id SERIAL PRIMARY KEY\n);",
+		"CREATE FUNCTION touch() RETURNS trigger AS $$\nBEGIN\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;",
+	}, accounts.Up)
+	assert.Equal(t, []string{"DROP FUNCTION IF EXISTS touch();", "DROP TABLE IF EXISTS accounts;"}, accounts.Down)
+	assert.False(t, accounts.NoTransaction)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]struct {
+		files fstest.MapFS
+		err   string
+	}{
+		"no version": {
+			files: fstest.MapFS{"users.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}},
+			err:   "migration users.sql: name must start with a positive version number",
+		},
+		"duplicate version": {
+			files: fstest.MapFS{
+				"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+				"1_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			},
+			err: "migration 1_b.sql: version 1 is also used by 1_a.sql",
+		},
+		"missing up": {
+			files: fstest.MapFS{"1_a.sql": {Data: []byte("SELECT 1;\n")}},
+			err:   "migration 1_a.sql: statement before -- +goose Up",
+		},
+		"unterminated statement": {
+			files: fstest.MapFS{"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1\n-- +goose Down\n")}},
+			err:   "migration 1_a.sql: statement is not terminated before -- +goose Down",
+		},
+		"unterminated block": {
+			files: fstest.MapFS{"1_a.sql": {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n")}},
+			err:   "migration 1_a.sql: StatementBegin without StatementEnd",
+		},
+		"unterminated body": {
+			files: fstest.MapFS{"1_a.sql": {Data: []byte("-- +goose Up\nDO $body$\nBEGIN\n    NULL;\nEND;\n")}},
+			err:   "migration 1_a.sql: unterminated $body$ quote",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseQuotedSemicolons(t *testing.T) {
+	m, err := parse(`-- +goose Up
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.note := 'a;';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+INSERT INTO notes (body) VALUES ('it''s;
+-- +goose Down
+not an annotation;'), ($1);
+DO $body$ BEGIN PERFORM 1; END $body$; -- trailing $$ comment
+SELECT 1;
+
+-- +goose Down
+DROP FUNCTION touch();
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE FUNCTION touch() RETURNS trigger AS $$\nBEGIN\n    NEW.note := 'a;';\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;",
+		"INSERT INTO notes (body) VALUES ('it''s;\n-- +goose Down\nnot an annotation;'), ($1);",
+		"DO $body$ BEGIN PERFORM 1; END $body$; -- trailing $$ comment\nSELECT 1;",
+	}, m.Up)
+	assert.Equal(t, []string{"DROP FUNCTION touch();"}, m.Down)
+}
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, all)
+
+	for _, m := range all {
+		assert.NotEmpty(t, m.Up, m.Name)
+		assert.NotEmpty(t, m.Down, m.Name)
+	}
+}
+
+func TestUp(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	appliedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	expectLock(mock)
+	expectVersionTable(mock, versionRows().AddRow(1, true, appliedAt).AddRow(0, true, appliedAt))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE FUNCTION touch\\(\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO goose_db_version \\(version_id, is_applied\\) VALUES \\(\\$1, TRUE\\)").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	count, err := m.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpFailureRollsBack(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	expectLock(mock)
+	expectVersionTable(mock, versionRows())
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE users").WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	expectUnlock(mock)
+
+	count, err := m.Up(context.Background())
+	assert.EqualError(t, err, "failed to apply migration 1_create_users.sql: syntax error")
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDown(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	expectLock(mock)
+	expectApplied(mock, versionRows().AddRow(2, true, time.Now()).AddRow(1, true, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP FUNCTION IF EXISTS touch\\(\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DROP TABLE IF EXISTS accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM goose_db_version WHERE version_id = \\$1").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	migration, err := m.Down(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), migration.Version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDownNothingApplied(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	// A later row marking the version as not applied wins over the earlier one
+	expectLock(mock)
+	expectApplied(mock, versionRows().AddRow(1, false, time.Now()).AddRow(1, true, time.Now()))
+	expectUnlock(mock)
+
+	_, err := m.Down(context.Background())
+	assert.ErrorIs(t, err, ErrNoneApplied)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpLockFailure(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").WillReturnError(errors.New("connection refused"))
+
+	_, err := m.Up(context.Background())
+	assert.EqualError(t, err, "failed to lock migrations: connection refused")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatus(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	appliedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	expectApplied(mock, versionRows().AddRow(1, true, appliedAt))
+
+	statuses, err := m.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "1_create_users.sql", AppliedAt: appliedAt},
+		{Version: 2, Name: "2_create_accounts.sql"},
+	}, statuses)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheck(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	expectApplied(mock, versionRows().AddRow(1, true, time.Now()))
+	err := m.Check(context.Background())
+	var outdated *OutdatedSchemaError
+	assert.ErrorAs(t, err, &outdated)
+	assert.Equal(t, &OutdatedSchemaError{Current: 1, Latest: 2, Pending: 1}, outdated)
+
+	// A schema ahead of the binary is accepted
+	expectApplied(mock, versionRows().AddRow(3, true, time.Now()).AddRow(2, true, time.Now()).AddRow(1, true, time.Now()))
+	assert.NoError(t, m.Check(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckWithoutVersionTable(t *testing.T) {
+	m, mock := testMigrator(t)
+
+	// The check must not create the version table: any write would fail
+	// the expectations
+	mock.ExpectQuery("SELECT to_regclass\\('goose_db_version'\\) IS NOT NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	err := m.Check(context.Background())
+	var outdated *OutdatedSchemaError
+	assert.ErrorAs(t, err, &outdated)
+	assert.Equal(t, &OutdatedSchemaError{Current: 0, Latest: 2, Pending: 2}, outdated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,64 +3,92 @@ package repository
 import (
 	"context"
 	"fmt"
+	"math"
+	"time"
 )
 
+// roundCents rounds a sum of amounts to cents: SQLite adds them up as floating
+// point numbers, while the NUMERIC sums of Postgres are exact already.
+func roundCents(sum float64) float64 {
+	return math.Round(sum*100) / 100
+}
+
 type Analytics struct {
 	TotalIncome  float64 `json:"total_income"`
 	TotalExpense float64 `json:"total_expense"`
 }
 
-func (db *DB) GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error) {
-	query := `
+const incomeAndExpensesQuery = `
         SELECT
             COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0) AS total_income,
             COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0) AS total_expense
         FROM transactions
-        WHERE user_id = $1
+        WHERE user_id = $1 AND deleted_at IS NULL
     `
+
+func (db *DB) GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error) {
 	var analytics Analytics
 
-	err := db.Conn.QueryRowContext(ctx, query, userID).Scan(&analytics.TotalIncome, &analytics.TotalExpense)
+	err := db.q().QueryRowContext(ctx, incomeAndExpensesQuery, userID).Scan(&analytics.TotalIncome, &analytics.TotalExpense)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch analytics: %w", err)
 	}
 
+	analytics.TotalIncome, analytics.TotalExpense = roundCents(analytics.TotalIncome), roundCents(analytics.TotalExpense)
 	return &analytics, nil
 }
 
-func (db *DB) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, startDate, endDate string) (*Analytics, error) {
-	query := `
+const incomeAndExpensesFilteredQuery = `
         SELECT
             COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0) AS total_income,
             COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0) AS total_expense
         FROM transactions
-        WHERE user_id = $1 AND date BETWEEN $2 AND $3
+        WHERE user_id = $1 AND deleted_at IS NULL AND date >= $2 AND date < $3
     `
+
+// GetIncomeAndExpensesFiltered only counts transactions dated within [from, to).
+func (db *DB) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, from, to time.Time) (*Analytics, error) {
 	var analytics Analytics
 
-	err := db.Conn.QueryRowContext(ctx, query, userID, startDate, endDate).Scan(&analytics.TotalIncome, &analytics.TotalExpense)
+	err := db.q().QueryRowContext(ctx, incomeAndExpensesFilteredQuery, userID, from, to).Scan(&analytics.TotalIncome, &analytics.TotalExpense)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch filtered analytics: %w", err)
 	}
 
+	analytics.TotalIncome, analytics.TotalExpense = roundCents(analytics.TotalIncome), roundCents(analytics.TotalExpense)
 	return &analytics, nil
 }
 
+// categoryLinesQuery yields one row per category line: split lines for split
+// transactions and the transaction itself otherwise.
+const categoryLinesQuery = `
+            SELECT t.category_id, t.amount, t.date, t.user_id
+            FROM transactions t
+            WHERE t.deleted_at IS NULL
+              AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+            UNION ALL
+            SELECT s.category_id, s.amount, t.date, t.user_id
+            FROM transaction_splits s
+            JOIN transactions t ON s.transaction_id = t.id
+            WHERE t.deleted_at IS NULL
+        `
+
 type CategoryAnalytics struct {
 	CategoryName string  `json:"category_name"`
 	TotalAmount  float64 `json:"total_amount"`
 }
 
-func (db *DB) GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryAnalytics, error) {
-	query := `
+const categoryAnalyticsQuery = `
         SELECT c.name AS category_name, COALESCE(SUM(t.amount), 0) AS total_amount
-        FROM transactions t
-        JOIN categories c ON t.category_id = c.id
+        FROM (` + categoryLinesQuery + `) t
+        JOIN categories c ON t.category_id = c.id AND c.deleted_at IS NULL
         WHERE t.user_id = $1
         GROUP BY c.name
         ORDER BY total_amount DESC
     `
-	rows, err := db.Conn.QueryContext(ctx, query, userID)
+
+func (db *DB) GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryAnalytics, error) {
+	rows, err := db.q().QueryContext(ctx, categoryAnalyticsQuery, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch category analytics: %w", err)
 	}
@@ -72,21 +100,24 @@ func (db *DB) GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryA
 		if err := rows.Scan(&ca.CategoryName, &ca.TotalAmount); err != nil {
 			return nil, err
 		}
+		ca.TotalAmount = roundCents(ca.TotalAmount)
 		analytics = append(analytics, ca)
 	}
-	return analytics, nil
+	return analytics, rows.Err()
 }
 
-func (db *DB) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, startDate, endDate string) ([]CategoryAnalytics, error) {
-	query := `
+const categoryAnalyticsFilteredQuery = `
         SELECT c.name AS category_name, COALESCE(SUM(t.amount), 0) AS total_amount
-        FROM transactions t
-        JOIN categories c ON t.category_id = c.id
-        WHERE t.user_id = $1 AND t.date BETWEEN $2 AND $3
+        FROM (` + categoryLinesQuery + `) t
+        JOIN categories c ON t.category_id = c.id AND c.deleted_at IS NULL
+        WHERE t.user_id = $1 AND t.date >= $2 AND t.date < $3
         GROUP BY c.name
         ORDER BY total_amount DESC
     `
-	rows, err := db.Conn.QueryContext(ctx, query, userID, startDate, endDate)
+
+// GetCategoryAnalyticsFiltered only counts transactions dated within [from, to).
+func (db *DB) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]CategoryAnalytics, error) {
+	rows, err := db.q().QueryContext(ctx, categoryAnalyticsFilteredQuery, userID, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch filtered category analytics: %w", err)
 	}
@@ -98,7 +129,43 @@ func (db *DB) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, star
 		if err := rows.Scan(&ca.CategoryName, &ca.TotalAmount); err != nil {
 			return nil, err
 		}
+		ca.TotalAmount = roundCents(ca.TotalAmount)
 		analytics = append(analytics, ca)
 	}
-	return analytics, nil
+	return analytics, rows.Err()
+}
+
+type TagAnalytics struct {
+	TagName     string  `json:"tag_name"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+const tagAnalyticsFilteredQuery = `
+        SELECT g.name AS tag_name, COALESCE(SUM(t.amount), 0) AS total_amount
+        FROM transactions t
+        JOIN transaction_tags tt ON tt.transaction_id = t.id
+        JOIN tags g ON tt.tag_id = g.id
+        WHERE t.user_id = $1 AND t.deleted_at IS NULL AND t.date >= $2 AND t.date < $3
+        GROUP BY g.name
+        ORDER BY total_amount DESC
+    `
+
+// GetTagAnalyticsFiltered only counts transactions dated within [from, to).
+func (db *DB) GetTagAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]TagAnalytics, error) {
+	rows, err := db.q().QueryContext(ctx, tagAnalyticsFilteredQuery, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filtered tag analytics: %w", err)
+	}
+	defer rows.Close()
+
+	var analytics []TagAnalytics
+	for rows.Next() {
+		var ta TagAnalytics
+		if err := rows.Scan(&ta.TagName, &ta.TotalAmount); err != nil {
+			return nil, err
+		}
+		ta.TotalAmount = roundCents(ta.TotalAmount)
+		analytics = append(analytics, ta)
+	}
+	return analytics, rows.Err()
 }
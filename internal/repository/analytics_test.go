@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -53,12 +55,14 @@ func TestGetIncomeAndExpensesFiltered(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(CASE WHEN amount > 0 THEN amount ELSE 0 END\\), 0\\) AS total_income,").
-		WithArgs(1, "2024-01-01", "2024-12-31").
+		WithArgs(1, from, to).
 		WillReturnRows(sqlmock.NewRows([]string{"total_income", "total_expense"}).AddRow(3000.00, -1000.00))
 
-	analytics, err := r.GetIncomeAndExpensesFiltered(context.Background(), 1, "2024-01-01", "2024-12-31")
+	analytics, err := r.GetIncomeAndExpensesFiltered(context.Background(), 1, from, to)
 	assert.NoError(t, err)
 	assert.Equal(t, 3000.00, analytics.TotalIncome)
 	assert.Equal(t, -1000.00, analytics.TotalExpense)
@@ -90,7 +94,7 @@ func TestGetCategoryAnalytics(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestGetCategoryAnalyticsFiltered(t *testing.T) {
+func TestGetCategoryAnalyticsRowError(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
@@ -98,12 +102,33 @@ func TestGetCategoryAnalyticsFiltered(t *testing.T) {
 	r := &DB{Conn: db}
 
 	mock.ExpectQuery("SELECT c.name AS category_name, COALESCE\\(SUM\\(t.amount\\), 0\\) AS total_amount").
-		WithArgs(1, "2024-01-01", "2024-12-31").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"category_name", "total_amount"}).
+			AddRow("Groceries", -150.00).
+			RowError(0, errors.New("connection reset")))
+
+	_, err = r.GetCategoryAnalytics(context.Background(), 1)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCategoryAnalyticsFiltered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT c.name AS category_name, COALESCE\\(SUM\\(t.amount\\), 0\\) AS total_amount(.+)t.date >= \\$2 AND t.date < \\$3").
+		WithArgs(1, from, to).
 		WillReturnRows(sqlmock.NewRows([]string{"category_name", "total_amount"}).
 			AddRow("Groceries", -200.00).
 			AddRow("Entertainment", -100.00))
 
-	analytics, err := r.GetCategoryAnalyticsFiltered(context.Background(), 1, "2024-01-01", "2024-12-31")
+	analytics, err := r.GetCategoryAnalyticsFiltered(context.Background(), 1, from, to)
 	assert.NoError(t, err)
 	assert.Len(t, analytics, 2)
 	assert.Equal(t, "Groceries", analytics[0].CategoryName)
@@ -113,3 +138,27 @@ func TestGetCategoryAnalyticsFiltered(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetTagAnalyticsFiltered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT g.name AS tag_name, COALESCE\\(SUM\\(t.amount\\), 0\\) AS total_amount(.+)t.date >= \\$2 AND t.date < \\$3").
+		WithArgs(1, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_name", "total_amount"}).
+			AddRow("reimbursable", -80.00).
+			AddRow("vacation-2025", -1200.00))
+
+	analytics, err := r.GetTagAnalyticsFiltered(context.Background(), 1, from, to)
+	assert.NoError(t, err)
+	assert.Len(t, analytics, 2)
+	assert.Equal(t, "vacation-2025", analytics[1].TagName)
+	assert.Equal(t, -1200.00, analytics[1].TotalAmount)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Attachment describes a file stored for a transaction. The file itself lives
+// in blob storage under StorageKey.
+type Attachment struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	UserID        int       `json:"-"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	StorageKey    string    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CreateAttachment records an attachment for a transaction owned by a.UserID.
+func (db *DB) CreateAttachment(ctx context.Context, a Attachment) (int, error) {
+	query := `
+        INSERT INTO attachments (transaction_id, user_id, filename, content_type, size, storage_key)
+        SELECT t.id, t.user_id, $3, $4, $5, $6 FROM transactions t
+        WHERE t.id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+        RETURNING id
+    `
+	var id int
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		err := tx.q().QueryRowContext(ctx, query, a.TransactionID, a.UserID, a.Filename, a.ContentType, a.Size, a.StorageKey).Scan(&id)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create attachment: %w", err)
+		}
+		return tx.recordCreate(ctx, a.UserID, EntityAttachment, id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetAttachments returns the attachments of an active transaction of userID,
+// or a *NotFoundError if the transaction does not exist or belongs to another
+// user.
+func (db *DB) GetAttachments(ctx context.Context, userID, txnID int) ([]Attachment, error) {
+	var id int
+	err := db.q().QueryRowContext(ctx, "SELECT id FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", txnID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Entity: EntityTransaction}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+
+	query := `
+        SELECT id, transaction_id, user_id, filename, content_type, size, storage_key, created_at
+        FROM attachments
+        WHERE transaction_id = $1 AND user_id = $2
+        ORDER BY id
+    `
+	rows, err := db.q().QueryContext(ctx, query, txnID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.TransactionID, &a.UserID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// GetAttachment returns the attachment or nil if it does not exist or belongs to another user.
+func (db *DB) GetAttachment(ctx context.Context, userID, attachmentID int) (*Attachment, error) {
+	query := `
+        SELECT id, transaction_id, user_id, filename, content_type, size, storage_key, created_at
+        FROM attachments
+        WHERE id = $1 AND user_id = $2
+    `
+	var a Attachment
+	err := db.q().QueryRowContext(ctx, query, attachmentID, userID).
+		Scan(&a.ID, &a.TransactionID, &a.UserID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+func (db *DB) DeleteAttachment(ctx context.Context, userID, attachmentID int) error {
+	return db.audited(ctx, userID, EntityAttachment, attachmentID, AuditDelete, func(tx *DB) error {
+		query := "DELETE FROM attachments WHERE id = $1 AND user_id = $2"
+		res, err := tx.q().ExecContext(ctx, query, attachmentID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete attachment: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityAttachment}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO attachments").
+		WithArgs(5, 1, "receipt.pdf", "application/pdf", int64(1024), "users/1/transactions/5/abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectSnapshot(mock, EntityAttachment, 3, `{"id":3,"transaction_id":5}`)
+	expectAudit(mock, 1, EntityAttachment, 3, AuditCreate)
+	mock.ExpectCommit()
+
+	id, err := r.CreateAttachment(context.Background(), Attachment{
+		TransactionID: 5,
+		UserID:        1,
+		Filename:      "receipt.pdf",
+		ContentType:   "application/pdf",
+		Size:          1024,
+		StorageKey:    "users/1/transactions/5/abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, id)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAttachmentForeignTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO attachments").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = r.CreateAttachment(context.Background(), Attachment{TransactionID: 5, UserID: 2})
+	assert.EqualError(t, err, "no transaction found or not authorized")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAttachmentsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(5, 2).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = r.GetAttachments(context.Background(), 2, 5)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAttachmentsRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery("SELECT id, transaction_id, user_id, filename, content_type, size, storage_key, created_at FROM attachments").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "user_id", "filename", "content_type", "size", "storage_key", "created_at"}).
+			AddRow(3, 5, 1, "receipt.pdf", "application/pdf", 42, "users/1/transactions/5/abc", time.Now()).
+			RowError(0, errors.New("connection reset")))
+
+	_, err = r.GetAttachments(context.Background(), 1, 5)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id, transaction_id, user_id, filename, content_type, size, storage_key, created_at FROM attachments WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "user_id", "filename", "content_type", "size", "storage_key", "created_at"}).
+			AddRow(3, 5, 1, "receipt.pdf", "application/pdf", 1024, "users/1/transactions/5/abc", time.Now()))
+
+	attachment, err := r.GetAttachment(context.Background(), 1, 3)
+	assert.NoError(t, err)
+	assert.NotNil(t, attachment)
+	assert.Equal(t, "receipt.pdf", attachment.Filename)
+	assert.Equal(t, "users/1/transactions/5/abc", attachment.StorageKey)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityAttachment, 3, `{"id":3,"transaction_id":5}`)
+	mock.ExpectExec("DELETE FROM attachments WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityAttachment, 3, "")
+	expectAudit(mock, 1, EntityAttachment, 3, AuditDelete)
+	mock.ExpectCommit()
+
+	err = r.DeleteAttachment(context.Background(), 1, 3)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
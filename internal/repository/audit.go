@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audited entity kinds.
+const (
+	EntityUser            = "user"
+	EntityCategory        = "category"
+	EntityTransaction     = "transaction"
+	EntitySplit           = "split"
+	EntityTag             = "tag"
+	EntityTransactionTags = "transaction_tags"
+	EntityAttachment      = "attachment"
+)
+
+// Audited actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	AuditUndo    = "undo"
+)
+
+// AuditEntry is a row of the append-only audit log. Before and After hold the
+// audited record as JSON and are null when it did not exist.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	UserID    int             `json:"-"`
+	ActorID   *int            `json:"actor_id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows down the entries returned by GetAuditLog. Zero values are ignored.
+type AuditFilter struct {
+	Entity    string
+	EntityID  int
+	Action    string
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+// auditSnapshots select the audited document of an entity by ID. Password
+// hashes and other secrets are deliberately left out.
+var auditSnapshots = map[string]string{
+	EntityUser:            "SELECT row_to_json(x) FROM (SELECT id, username, timezone, created_at FROM users WHERE id = $1) x",
+	EntityCategory:        "SELECT row_to_json(x) FROM (SELECT id, name, user_id, deleted_at, version FROM categories WHERE id = $1) x",
+	EntityTransaction:     "SELECT row_to_json(x) FROM (SELECT id, amount, date, description, category_id, user_id, deleted_at, version FROM transactions WHERE id = $1) x",
+	EntitySplit:           "SELECT row_to_json(x) FROM (SELECT id, transaction_id, category_id, amount, note FROM transaction_splits WHERE id = $1) x",
+	EntityTag:             "SELECT row_to_json(x) FROM (SELECT id, name, user_id FROM tags WHERE id = $1) x",
+	EntityTransactionTags: "SELECT COALESCE(json_agg(tag_id ORDER BY tag_id), '[]') FROM transaction_tags WHERE transaction_id = $1",
+	EntityAttachment:      "SELECT row_to_json(x) FROM (SELECT id, transaction_id, filename, content_type, size, created_at FROM attachments WHERE id = $1) x",
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the HTTP request, which
+// is stored with every audit entry written under ctx.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func (db *DB) GetAuditLog(ctx context.Context, userID int, filter AuditFilter) ([]AuditEntry, error) {
+	query := `
+        SELECT id, user_id, actor_id, entity, entity_id, action, before, after, COALESCE(request_id, ''), created_at
+        FROM audit_log
+        WHERE user_id = $1
+    `
+	args := []any{userID}
+	add := func(cond string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+cond, len(args))
+	}
+
+	if filter.Entity != "" {
+		add("entity = $%d", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.RequestID != "" {
+		add("request_id = $%d", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	return db.queryAuditLog(ctx, query, args...)
+}
+
+// GetTransactionAudit returns the audit entries of a transaction together with
+// those of its splits, tags and attachments, newest first.
+func (db *DB) GetTransactionAudit(ctx context.Context, userID, txnID int) ([]AuditEntry, error) {
+	query := `
+        SELECT id, user_id, actor_id, entity, entity_id, action, before, after, COALESCE(request_id, ''), created_at
+        FROM audit_log
+        WHERE user_id = $1 AND (
+            (entity IN ('transaction', 'transaction_tags') AND entity_id = $2)
+            OR (entity IN ('split', 'attachment') AND (COALESCE(after, before)->>'transaction_id')::int = $2)
+        )
+        ORDER BY id DESC
+    `
+	return db.queryAuditLog(ctx, query, userID, txnID)
+}
+
+func (db *DB) queryAuditLog(ctx context.Context, query string, args ...any) ([]AuditEntry, error) {
+	rows, err := db.q().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var actorID sql.NullInt64
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &actorID, &e.Entity, &e.EntityID, &e.Action, &before, &after, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			e.ActorID = &id
+		}
+		if before != nil {
+			e.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(after)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// audited runs change in a transaction and records how it affected entity
+// entityID, owned by userID, in the audit log.
+func (db *DB) audited(ctx context.Context, userID int, entity string, entityID int, action string, change func(tx *DB) error) error {
+	return db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		before, err := tx.snapshot(ctx, entity, entityID)
+		if err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+		after, err := tx.snapshot(ctx, entity, entityID)
+		if err != nil {
+			return err
+		}
+		return tx.recordChange(ctx, userID, entity, entityID, action, before, after)
+	})
+}
+
+// recordCreate records a freshly inserted entity. It must run in the same
+// transaction as the insert.
+func (db *DB) recordCreate(ctx context.Context, userID int, entity string, entityID int) error {
+	after, err := db.snapshot(ctx, entity, entityID)
+	if err != nil {
+		return err
+	}
+	return db.recordChange(ctx, userID, entity, entityID, AuditCreate, nil, after)
+}
+
+// snapshot returns the audited document of an entity, or nil if it does not exist.
+func (db *DB) snapshot(ctx context.Context, entity string, entityID int) ([]byte, error) {
+	query := auditSnapshots[entity]
+	if db.dialect == SQLite {
+		query = sqliteSnapshots[entity]
+	}
+
+	var doc []byte
+	err := db.q().QueryRowContext(ctx, query, entityID).Scan(&doc)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", entity, err)
+	}
+	return doc, nil
+}
+
+const insertAuditQuery = `
+        INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, before, after, request_id)
+        VALUES ($1, $1, $2, $3, $4, $5::jsonb, $6::jsonb, NULLIF($7, ''))
+    `
+
+// recordChange appends an entry to the audit log. Changes that left the
+// document untouched are not recorded.
+func (db *DB) recordChange(ctx context.Context, userID int, entity string, entityID int, action string, before, after []byte) error {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		return nil
+	}
+
+	_, err := db.q().ExecContext(ctx, insertAuditQuery, userID, entity, entityID, action, jsonArg(before), jsonArg(after), requestIDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// jsonArg passes a JSON document as a query argument, mapping nil to NULL.
+func jsonArg(doc []byte) any {
+	if doc == nil {
+		return nil
+	}
+	return string(doc)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectSnapshot expects the audit snapshot of an entity; an empty doc means the row does not exist.
+func expectSnapshot(mock sqlmock.Sqlmock, entity string, entityID int, doc string) {
+	q := mock.ExpectQuery(regexp.QuoteMeta(auditSnapshots[entity])).WithArgs(entityID)
+	if doc == "" {
+		q.WillReturnError(sql.ErrNoRows)
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"doc"}).AddRow([]byte(doc)))
+}
+
+// expectAudit expects an entry to be appended to the audit log.
+func expectAudit(mock sqlmock.Sqlmock, userID int, entity string, entityID int, action string) {
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(userID, entity, entityID, action, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestRecordChangeStoresRequestID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, EntityCategory, 2, AuditUpdate, `{"name":"Food"}`, `{"name":"Groceries"}`, "req-42").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	err = r.recordChange(ctx, 1, EntityCategory, 2, AuditUpdate, []byte(`{"name":"Food"}`), []byte(`{"name":"Groceries"}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordChangeSkipsNoop(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	err = r.recordChange(context.Background(), 1, EntityCategory, 2, AuditUpdate, []byte(`{"name":"Food"}`), []byte(`{"name":"Food"}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM audit_log WHERE user_id = \\$1 AND entity = \\$2 AND created_at >= \\$3 ORDER BY id DESC LIMIT \\$4 OFFSET \\$5").
+		WithArgs(1, EntityTransaction, from, 100, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "actor_id", "entity", "entity_id", "action", "before", "after", "request_id", "created_at"}).
+			AddRow(2, 1, 1, EntityTransaction, 7, AuditUpdate, []byte(`{"amount":10}`), []byte(`{"amount":12}`), "req-1", from).
+			AddRow(1, 1, nil, EntityTransaction, 5, AuditPurge, []byte(`{"amount":3}`), nil, "", from))
+
+	entries, err := r.GetAuditLog(context.Background(), 1, AuditFilter{Entity: EntityTransaction, From: from, Limit: 100})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, 1, *entries[0].ActorID)
+	assert.JSONEq(t, `{"amount":12}`, string(entries[0].After))
+	assert.Equal(t, "req-1", entries[0].RequestID)
+	assert.Nil(t, entries[1].ActorID)
+	assert.Nil(t, entries[1].After)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTransactionAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectQuery("FROM audit_log WHERE user_id = \\$1 AND").
+		WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "actor_id", "entity", "entity_id", "action", "before", "after", "request_id", "created_at"}).
+			AddRow(3, 1, 1, EntitySplit, 12, AuditCreate, nil, []byte(`{"transaction_id":7}`), "", time.Now()))
+
+	entries, err := r.GetTransactionAudit(context.Background(), 1, 7)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, EntitySplit, entries[0].Entity)
+	assert.Nil(t, entries[0].Before)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Bulk operation kinds accepted by ApplyBulk.
+const (
+	BulkOpCreate       = "create"
+	BulkOpUpdate       = "update"
+	BulkOpDelete       = "delete"
+	BulkOpRecategorise = "recategorise"
+)
+
+// ErrBulkRolledBack is returned by ApplyBulk in atomic mode when one of the
+// operations failed and the whole batch was rolled back.
+var ErrBulkRolledBack = errors.New("bulk operation rolled back")
+
+// BulkOperation is a single item of a bulk request. Transaction.ID identifies
+// the target for update, delete and recategorise operations; a non-zero
+// Transaction.Version makes them conditional on that version.
+type BulkOperation struct {
+	Op          string
+	Transaction Transaction
+}
+
+type BulkResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ApplyBulk executes ops on behalf of userID against repo. In atomic mode all
+// operations run through repo.WithTx and nothing is persisted unless every one
+// succeeds; otherwise each operation is applied independently and failures are
+// only reported in its result.
+func ApplyBulk(ctx context.Context, repo Repository, userID int, ops []BulkOperation, atomic bool) ([]BulkResult, error) {
+	if atomic {
+		if results, ok := importBulk(ctx, repo, userID, ops); ok {
+			return results, nil
+		}
+	}
+
+	if !atomic {
+		results := make([]BulkResult, len(ops))
+		for i, op := range ops {
+			results[i] = applyBulkOperation(ctx, repo, userID, i, op)
+		}
+		return results, nil
+	}
+
+	results := make([]BulkResult, 0, len(ops))
+	err := repo.WithTx(ctx, func(tx Repository) error {
+		for i, op := range ops {
+			result := applyBulkOperation(ctx, tx, userID, i, op)
+			results = append(results, result)
+			if result.Error != "" {
+				return ErrBulkRolledBack
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrBulkRolledBack) {
+		// Nothing was persisted, so IDs of created rows are meaningless.
+		for i := range results {
+			if results[i].Op == BulkOpCreate {
+				results[i].ID = 0
+			}
+		}
+		return results, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// importBulk applies ops through the import of repo when they all create
+// transactions. ok is false when repo cannot import them or the import
+// failed, which rolls it back: the operations are then applied one by one to
+// tell which of them failed.
+func importBulk(ctx context.Context, repo Repository, userID int, ops []BulkOperation) (results []BulkResult, ok bool) {
+	importer, ok := repo.(transactionImporter)
+	if !ok || len(ops) == 0 {
+		return nil, false
+	}
+
+	txns := make([]Transaction, len(ops))
+	for i, op := range ops {
+		if op.Op != BulkOpCreate {
+			return nil, false
+		}
+		txns[i] = op.Transaction
+		txns[i].UserID = userID
+	}
+
+	ids, err := importer.importTransactions(ctx, userID, txns)
+	if err != nil {
+		return nil, false
+	}
+
+	results = make([]BulkResult, len(ids))
+	for i, id := range ids {
+		results[i] = BulkResult{Index: i, Op: BulkOpCreate, ID: id}
+	}
+	return results, true
+}
+
+func applyBulkOperation(ctx context.Context, repo Repository, userID, index int, op BulkOperation) BulkResult {
+	result := BulkResult{Index: index, Op: op.Op, ID: op.Transaction.ID}
+
+	txn := op.Transaction
+	txn.UserID = userID
+
+	var err error
+	switch op.Op {
+	case BulkOpCreate:
+		result.ID, err = repo.CreateTransaction(ctx, txn)
+	case BulkOpUpdate:
+		err = repo.UpdateTransaction(ctx, txn)
+	case BulkOpDelete:
+		err = repo.DeleteTransaction(ctx, userID, txn.ID, txn.Version)
+	case BulkOpRecategorise:
+		err = repo.RecategoriseTransaction(ctx, userID, txn.ID, txn.CategoryID, txn.Version)
+	default:
+		err = fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyBulkAtomic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(100.50, now, "Groceries", 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	expectSnapshot(mock, EntityTransaction, 10, `{"id":10}`)
+	expectAudit(mock, 1, EntityTransaction, 10, AuditCreate)
+	expectSnapshot(mock, EntityTransaction, 3, `{"id":3,"deleted_at":null}`)
+	expectSaveVersion(mock, 3, 1)
+	mock.ExpectExec("UPDATE transactions SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 3, `{"id":3,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityTransaction, 3, AuditDelete)
+	mock.ExpectCommit()
+
+	results, err := ApplyBulk(context.Background(), r, 1, []BulkOperation{
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: 100.50, Date: now, Description: "Groceries", CategoryID: 1}},
+		{Op: BulkOpDelete, Transaction: Transaction{ID: 3}},
+	}, true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 10, results[0].ID)
+	assert.Empty(t, results[1].Error)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyBulkAtomicRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(100.50, now, "Groceries", 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	expectSnapshot(mock, EntityTransaction, 10, `{"id":10}`)
+	expectAudit(mock, 1, EntityTransaction, 10, AuditCreate)
+	expectSnapshot(mock, EntityTransaction, 99, "")
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectSaveVersion(mock, 99, 1)
+	mock.ExpectExec("UPDATE transactions SET category_id = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
+		WithArgs(2, 99, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	results, err := ApplyBulk(context.Background(), r, 1, []BulkOperation{
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: 100.50, Date: now, Description: "Groceries", CategoryID: 1}},
+		{Op: BulkOpRecategorise, Transaction: Transaction{ID: 99, CategoryID: 2}},
+	}, true)
+	assert.ErrorIs(t, err, ErrBulkRolledBack)
+	assert.Len(t, results, 2)
+	assert.Zero(t, results[0].ID)
+	assert.NotEmpty(t, results[1].Error)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyBulkBestEffort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 99, "")
+	expectSaveVersion(mock, 99, 1)
+	mock.ExpectExec("UPDATE transactions SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(99, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 3, `{"id":3,"deleted_at":null}`)
+	expectSaveVersion(mock, 3, 1)
+	mock.ExpectExec("UPDATE transactions SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 3, `{"id":3,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityTransaction, 3, AuditDelete)
+	mock.ExpectCommit()
+
+	results, err := ApplyBulk(context.Background(), r, 1, []BulkOperation{
+		{Op: BulkOpDelete, Transaction: Transaction{ID: 99}},
+		{Op: BulkOpDelete, Transaction: Transaction{ID: 3}},
+		{Op: "archive", Transaction: Transaction{ID: 3}},
+	}, false)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.NotEmpty(t, results[0].Error)
+	assert.Empty(t, results[1].Error)
+	assert.Contains(t, results[2].Error, "unknown operation")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,27 +2,63 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Strategies accepted by DeleteCategory for the transactions of a deleted category.
+const (
+	CategoryDeleteReassign = "reassign"
+	CategoryDeleteBlock    = "block"
+	CategoryDeleteCascade  = "cascade"
+)
+
+// CategoryInUseError is returned by DeleteCategory with CategoryDeleteBlock when
+// transactions, including those in the trash, still use the category.
+type CategoryInUseError struct {
+	CategoryID int
+	Count      int
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category %d is used by %d transactions", e.CategoryID, e.Count)
+}
+
+func (e *CategoryInUseError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 type Category struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 func (db *DB) CreateCategory(ctx context.Context, userID int, name string) (int, error) {
-	query := "INSERT INTO categories (name, user_id) VALUES ($1, $2) RETURNING id"
 	var id int
-	err := db.Conn.QueryRowContext(ctx, query, name, userID).Scan(&id)
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		query := "INSERT INTO categories (name, user_id) VALUES ($1, $2) RETURNING id"
+		if err := tx.q().QueryRowContext(ctx, query, name, userID).Scan(&id); err != nil {
+			return fmt.Errorf("failed to create category: %w", err)
+		}
+		return tx.recordCreate(ctx, userID, EntityCategory, id)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to create category: %w", err)
+		return 0, err
 	}
 	return id, nil
 }
 
 func (db *DB) GetCategories(ctx context.Context, userID int) ([]Category, error) {
-	query := "SELECT id, name FROM categories WHERE user_id = $1"
-	rows, err := db.Conn.QueryContext(ctx, query, userID)
+	query := "SELECT id, name, version FROM categories WHERE user_id = $1 AND deleted_at IS NULL"
+	rows, err := db.q().QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch categories: %w", err)
 	}
@@ -31,40 +67,271 @@ func (db *DB) GetCategories(ctx context.Context, userID int) ([]Category, error)
 	var categories []Category
 	for rows.Next() {
 		var category Category
-		if err := rows.Scan(&category.ID, &category.Name); err != nil {
+		if err := rows.Scan(&category.ID, &category.Name, &category.Version); err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
 	}
-	return categories, nil
+	return categories, rows.Err()
 }
 
-func (db *DB) UpdateCategory(ctx context.Context, userID, categoryID int, name string) error {
-	query := "UPDATE categories SET name = $1 WHERE id = $2 AND user_id = $3"
-	res, err := db.Conn.ExecContext(ctx, query, name, categoryID, userID)
+// GetCategory returns an active category, or nil if it does not exist or
+// belongs to another user.
+func (db *DB) GetCategory(ctx context.Context, userID, categoryID int) (*Category, error) {
+	query := "SELECT id, name, version FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+	var category Category
+	err := db.q().QueryRowContext(ctx, query, categoryID, userID).Scan(&category.ID, &category.Name, &category.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update category: %w", err)
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
 	}
+	return &category, nil
+}
+
+// UpdateCategory renames a category. A non-zero expectedVersion must match the
+// stored version, otherwise a *VersionConflictError is returned.
+func (db *DB) UpdateCategory(ctx context.Context, userID, categoryID int, name string, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityCategory, categoryID, AuditUpdate, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityCategory, userID, categoryID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		query := "UPDATE categories SET name = $1, version = version + 1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL"
+		res, err := tx.q().ExecContext(ctx, query, name, categoryID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityCategory}
+		}
+
+		return nil
+	})
+}
+
+// DeleteCategory moves a category to the trash. strategy decides what happens
+// to the transactions that use it: CategoryDeleteReassign moves them (and split
+// lines) to targetID, CategoryDeleteBlock refuses with a *CategoryInUseError and
+// CategoryDeleteCascade moves them to the trash as well. Cascading refuses with
+// a *ConflictError while split lines of active transactions use the category,
+// as trashing those would take the other lines with them. A non-zero
+// expectedVersion must match the stored version, otherwise a
+// *VersionConflictError is returned.
+func (db *DB) DeleteCategory(ctx context.Context, userID, categoryID int, strategy string, targetID, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityCategory, categoryID, AuditDelete, func(tx *DB) error {
+		if err := tx.lockCategory(ctx, userID, categoryID); err != nil {
+			return err
+		}
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityCategory, userID, categoryID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		switch strategy {
+		case CategoryDeleteReassign:
+			if targetID == categoryID {
+				return invalid("target_id", "cannot reassign transactions to the deleted category")
+			}
+			if err := tx.lockCategory(ctx, userID, targetID); err != nil {
+				return err
+			}
+			if err := tx.moveCategoryUsage(ctx, userID, categoryID, targetID); err != nil {
+				return err
+			}
+		case CategoryDeleteBlock:
+			count, err := tx.categoryUsage(ctx, userID, categoryID)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return &CategoryInUseError{CategoryID: categoryID, Count: count}
+			}
+		case CategoryDeleteCascade:
+			if err := tx.checkNoSplitUsage(ctx, userID, categoryID); err != nil {
+				return err
+			}
+			if tx.dialect == SQLite {
+				if err := tx.sqliteTrashCategoryTransactions(ctx, userID, categoryID); err != nil {
+					return err
+				}
+				break
+			}
+
+			query := `
+                WITH old AS (
+                    SELECT id, amount, date, description, category_id, user_id, deleted_at, version FROM transactions
+                    WHERE category_id = $1 AND user_id = $2 AND deleted_at IS NULL
+                    FOR UPDATE
+                ), versions AS (
+                    INSERT INTO transaction_versions (transaction_id, version, amount, date, description, category_id, deleted_at, splits, tag_ids)
+                    SELECT id, version, amount, date, description, category_id, deleted_at, ` + oldSnapshots + ` FROM old
+                ), changed AS (
+                    UPDATE transactions t SET deleted_at = NOW(), version = t.version + 1 FROM old WHERE t.id = old.id
+                    RETURNING t.id, t.amount, t.date, t.description, t.category_id, t.user_id, t.deleted_at, t.version
+                )
+                INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, before, after, request_id)
+                SELECT $2, $2, 'transaction', changed.id, 'delete', row_to_json(old), row_to_json(changed), NULLIF($3, '')
+                FROM changed JOIN old ON old.id = changed.id
+            `
+			if _, err := tx.q().ExecContext(ctx, query, categoryID, userID, requestIDFromContext(ctx)); err != nil {
+				return fmt.Errorf("failed to delete category transactions: %w", err)
+			}
+		default:
+			return invalid("strategy", fmt.Sprintf("unknown delete strategy %q", strategy))
+		}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return fmt.Errorf("no category found or not authorized")
+		query := "UPDATE categories SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+		if _, err := tx.q().ExecContext(ctx, query, categoryID, userID); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		return nil
+	})
+}
+
+// MergeCategories moves every transaction and split line of sourceID, including
+// trashed ones, into targetID and moves sourceID to the trash, all in one
+// transaction. A non-zero expectedVersion must match the stored version of
+// sourceID, otherwise a *VersionConflictError is returned.
+func (db *DB) MergeCategories(ctx context.Context, userID, sourceID, targetID, expectedVersion int) error {
+	if sourceID == targetID {
+		return invalid("target_id", "cannot merge a category into itself")
 	}
 
+	return db.audited(ctx, userID, EntityCategory, sourceID, AuditDelete, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityCategory, userID, sourceID, expectedVersion); err != nil {
+				return err
+			}
+		}
+		if err := tx.lockCategory(ctx, userID, sourceID); err != nil {
+			return err
+		}
+		if err := tx.lockCategory(ctx, userID, targetID); err != nil {
+			return err
+		}
+		if err := tx.moveCategoryUsage(ctx, userID, sourceID, targetID); err != nil {
+			return err
+		}
+
+		query := "UPDATE categories SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+		if _, err := tx.q().ExecContext(ctx, query, sourceID, userID); err != nil {
+			return fmt.Errorf("failed to delete merged category: %w", err)
+		}
+		return nil
+	})
+}
+
+// lockCategory makes sure an active category belongs to userID and locks it
+// until the surrounding transaction ends.
+func (db *DB) lockCategory(ctx context.Context, userID, categoryID int) error {
+	query := "SELECT id FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE"
+	var id int
+	err := db.q().QueryRowContext(ctx, query, categoryID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Entity: EntityCategory}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch category: %w", err)
+	}
 	return nil
 }
 
-func (db *DB) DeleteCategory(ctx context.Context, userID, categoryID int) error {
-	query := "DELETE FROM categories WHERE id = $1 AND user_id = $2"
-	res, err := db.Conn.ExecContext(ctx, query, categoryID, userID)
+// checkNoSplitUsage fails with a *ConflictError listing the active transactions
+// of userID that have split lines in categoryID.
+func (db *DB) checkNoSplitUsage(ctx context.Context, userID, categoryID int) error {
+	query := `
+        SELECT DISTINCT t.id FROM transactions t
+        JOIN transaction_splits s ON s.transaction_id = t.id
+        WHERE s.category_id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+        ORDER BY t.id
+    `
+	ids, err := db.selectIDs(ctx, query, categoryID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete category: %w", err)
+		return fmt.Errorf("failed to fetch split transactions: %w", err)
 	}
+	return splitUsageConflict(ids)
+}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return fmt.Errorf("no category found or not authorized")
+// splitUsageConflict builds the error of a cascade that would trash the split
+// transactions ids, or returns nil if there are none.
+func splitUsageConflict(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.Itoa(id)
+	}
+	return &ConflictError{
+		Entity: EntityCategory,
+		Reason: fmt.Sprintf("split lines of transactions %s use the category; change their splits or reassign them first", strings.Join(list, ", ")),
 	}
+}
 
+// categoryUsage counts the transactions, active or in the trash, that use
+// categoryID directly or through one of their split lines.
+func (db *DB) categoryUsage(ctx context.Context, userID, categoryID int) (int, error) {
+	query := `
+        SELECT COUNT(*) FROM transactions
+        WHERE user_id = $2
+          AND (category_id = $1 OR id IN (SELECT transaction_id FROM transaction_splits WHERE category_id = $1))
+    `
+	var count int
+	if err := db.q().QueryRowContext(ctx, query, categoryID, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count category usage: %w", err)
+	}
+	return count, nil
+}
+
+// moveCategoryUsage points all transactions and split lines of userID that use
+// fromID to toID, recording every changed row in the audit log.
+func (db *DB) moveCategoryUsage(ctx context.Context, userID, fromID, toID int) error {
+	if db.dialect == SQLite {
+		return db.sqliteMoveCategoryUsage(ctx, userID, fromID, toID)
+	}
+
+	query := `
+        WITH old AS (
+            SELECT id, amount, date, description, category_id, user_id, deleted_at, version FROM transactions
+            WHERE category_id = $2 AND user_id = $3
+            FOR UPDATE
+        ), versions AS (
+            INSERT INTO transaction_versions (transaction_id, version, amount, date, description, category_id, deleted_at, splits, tag_ids)
+            SELECT id, version, amount, date, description, category_id, deleted_at, ` + oldSnapshots + ` FROM old
+        ), changed AS (
+            UPDATE transactions t SET category_id = $1, version = t.version + 1 FROM old WHERE t.id = old.id
+            RETURNING t.id, t.amount, t.date, t.description, t.category_id, t.user_id, t.deleted_at, t.version
+        )
+        INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, before, after, request_id)
+        SELECT $3, $3, 'transaction', changed.id, 'update', row_to_json(old), row_to_json(changed), NULLIF($4, '')
+        FROM changed JOIN old ON old.id = changed.id
+    `
+	if _, err := db.q().ExecContext(ctx, query, toID, fromID, userID, requestIDFromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to reassign transactions: %w", err)
+	}
+
+	query = `
+        WITH old AS (
+            SELECT id, transaction_id, category_id, amount, note FROM transaction_splits
+            WHERE category_id = $2 AND transaction_id IN (SELECT id FROM transactions WHERE user_id = $3)
+            FOR UPDATE
+        ), changed AS (
+            UPDATE transaction_splits s SET category_id = $1 FROM old WHERE s.id = old.id
+            RETURNING s.id, s.transaction_id, s.category_id, s.amount, s.note
+        )
+        INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, before, after, request_id)
+        SELECT $3, $3, 'split', changed.id, 'update', row_to_json(old), row_to_json(changed), NULLIF($4, '')
+        FROM changed JOIN old ON old.id = changed.id
+    `
+	if _, err := db.q().ExecContext(ctx, query, toID, fromID, userID, requestIDFromContext(ctx)); err != nil {
+		return fmt.Errorf("failed to reassign splits: %w", err)
+	}
 	return nil
 }
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -14,9 +16,13 @@ func TestCreateCategory(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
+	mock.ExpectBegin()
 	mock.ExpectQuery("INSERT INTO categories").
 		WithArgs("Groceries", 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditCreate)
+	mock.ExpectCommit()
 
 	id, err := r.CreateCategory(context.Background(), 1, "Groceries")
 	assert.NoError(t, err)
@@ -31,11 +37,11 @@ func TestGetCategories(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
-	mock.ExpectQuery("SELECT id, name FROM categories WHERE user_id = ?").
+	mock.ExpectQuery("SELECT id, name, version FROM categories WHERE user_id = ?").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
-			AddRow(1, "Groceries").
-			AddRow(2, "Entertainment"))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version"}).
+			AddRow(1, "Groceries", 1).
+			AddRow(2, "Entertainment", 3))
 
 	categories, err := r.GetCategories(context.Background(), 1)
 	assert.NoError(t, err)
@@ -46,17 +52,40 @@ func TestGetCategories(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetCategoriesRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectQuery("SELECT id, name, version FROM categories WHERE user_id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version"}).
+			AddRow(1, "Groceries", 1).
+			RowError(0, errors.New("connection reset")))
+
+	_, err = r.GetCategories(context.Background(), 1)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateCategory(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
 	r := &DB{Conn: db}
-	mock.ExpectExec("UPDATE categories SET name = \\$1 WHERE id = \\$2 AND user_id = \\$3").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries"}`)
+	mock.ExpectExec("UPDATE categories SET name = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
 		WithArgs("Updated Category", 1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Updated Category"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditUpdate)
+	mock.ExpectCommit()
 
-	err = r.UpdateCategory(context.Background(), 1, 1, "Updated Category")
+	err = r.UpdateCategory(context.Background(), 1, 1, "Updated Category", 0)
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -68,11 +97,14 @@ func TestUpdateCategoryNoRows(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
-	mock.ExpectExec("UPDATE categories SET name = \\$1 WHERE id = \\$2 AND user_id = \\$3").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, "")
+	mock.ExpectExec("UPDATE categories SET name = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
 		WithArgs("Updated Category", 1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
+	mock.ExpectRollback()
 
-	err = r.UpdateCategory(context.Background(), 1, 1, "Updated Category")
+	err = r.UpdateCategory(context.Background(), 1, 1, "Updated Category", 0)
 	assert.Error(t, err)
 	assert.Equal(t, "no category found or not authorized", err.Error())
 
@@ -85,11 +117,22 @@ func TestDeleteCategory(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
-	mock.ExpectExec("DELETE FROM categories WHERE id = \\$1 AND user_id = \\$2").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transactions").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("UPDATE categories SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
 		WithArgs(1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditDelete)
+	mock.ExpectCommit()
 
-	err = r.DeleteCategory(context.Background(), 1, 1)
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteBlock, 0, 0)
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -101,13 +144,178 @@ func TestDeleteCategoryNoRows(t *testing.T) {
 	defer db.Close()
 
 	r := &DB{Conn: db}
-	mock.ExpectExec("DELETE FROM categories WHERE id = \\$1 AND user_id = \\$2").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
 		WithArgs(1, 1).
-		WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
-	err = r.DeleteCategory(context.Background(), 1, 1)
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteBlock, 0, 0)
 	assert.Error(t, err)
 	assert.Equal(t, "no category found or not authorized", err.Error())
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transactions").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteBlock, 0, 0)
+	var inUse *CategoryInUseError
+	assert.ErrorAs(t, err, &inUse)
+	assert.Equal(t, 3, inUse.Count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryReassign(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec("WITH old AS (.+) UPDATE transactions t SET category_id = \\$1").
+		WithArgs(2, 1, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("WITH old AS (.+) UPDATE transaction_splits s SET category_id = \\$1").
+		WithArgs(2, 1, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE categories SET deleted_at = NOW\\(\\)").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditDelete)
+	mock.ExpectCommit()
+
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteReassign, 2, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryCascade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT DISTINCT t.id FROM transactions t JOIN transaction_splits s").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("WITH old AS (.+) UPDATE transactions t SET deleted_at = NOW\\(\\)").
+		WithArgs(1, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE categories SET deleted_at = NOW\\(\\)").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditDelete)
+	mock.ExpectCommit()
+
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteCascade, 0, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryCascadeSplits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT DISTINCT t.id FROM transactions t JOIN transaction_splits s").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(9))
+	mock.ExpectRollback()
+
+	err = r.DeleteCategory(context.Background(), 1, 1, CategoryDeleteCascade, 0, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.EqualError(t, err, "split lines of transactions 4, 9 use the category; change their splits or reassign them first")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 2, `{"id":2,"deleted_at":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("WITH old AS (.+) UPDATE transactions t SET category_id = \\$1").
+		WithArgs(1, 2, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec("WITH old AS (.+) UPDATE transaction_splits s SET category_id = \\$1").
+		WithArgs(1, 2, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE categories SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityCategory, 2, `{"id":2,"deleted_at":"2026-10-19T00:00:00Z"}`)
+	expectAudit(mock, 1, EntityCategory, 2, AuditDelete)
+	mock.ExpectCommit()
+
+	err = r.MergeCategories(context.Background(), 1, 2, 1, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectQuery("SELECT id, name, version FROM categories WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version"}).AddRow(2, "Groceries", 4))
+
+	category, err := r.GetCategory(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, category)
+	assert.Equal(t, "Groceries", category.Name)
+	assert.Equal(t, 4, category.Version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
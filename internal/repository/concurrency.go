@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict matches every *VersionConflictError with errors.Is, as
+// does ErrConflict.
+var ErrVersionConflict = errors.New("record was modified by another request")
+
+// VersionConflictError is returned when a record changed since the version
+// the caller based its change on.
+type VersionConflictError struct {
+	Entity   string
+	ID       int
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %d is at version %d, expected %d", e.Entity, e.ID, e.Current, e.Expected)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict || target == ErrConflict
+}
+
+// versionedTables maps the entities with a version column to their table.
+var versionedTables = map[string]string{
+	EntityTransaction: "transactions",
+	EntityCategory:    "categories",
+	EntityTag:         "tags",
+}
+
+// checkVersion locks a record of userID, trashed or not, until the surrounding
+// transaction ends and makes sure it is still at the expected version.
+func (db *DB) checkVersion(ctx context.Context, entity string, userID, id, expected int) error {
+	current, err := db.currentVersion(ctx, entity, userID, id)
+	if err != nil {
+		return err
+	}
+	if current != expected {
+		return &VersionConflictError{Entity: entity, ID: id, Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// currentVersion locks a record of userID, trashed or not, until the
+// surrounding transaction ends and returns its version.
+func (db *DB) currentVersion(ctx context.Context, entity string, userID, id int) (int, error) {
+	query := "SELECT version FROM " + versionedTables[entity] + " WHERE id = $1 AND user_id = $2 FOR UPDATE"
+	var current int
+	err := db.q().QueryRowContext(ctx, query, id, userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return 0, &NotFoundError{Entity: entity}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s: %w", entity, err)
+	}
+	return current, nil
+}
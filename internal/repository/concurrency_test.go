@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateCategoryVersionMatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries","version":2}`)
+	mock.ExpectQuery("SELECT version FROM categories WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("UPDATE categories SET name = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
+		WithArgs("Food", 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Food","version":3}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.UpdateCategory(context.Background(), 1, 1, "Food", 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateCategoryVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries","version":3}`)
+	mock.ExpectQuery("SELECT version FROM categories WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err = r.UpdateCategory(context.Background(), 1, 1, "Food", 2)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	var conflict *VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, EntityCategory, conflict.Entity)
+	assert.Equal(t, 2, conflict.Expected)
+	assert.Equal(t, 3, conflict.Current)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTransactionVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"version":5}`)
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+	mock.ExpectRollback()
+
+	err = r.UpdateTransaction(context.Background(), Transaction{ID: 1, Amount: 10, Date: time.Now(), UserID: 1, Version: 4})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTransactionVersionNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, "")
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectRollback()
+
+	err = r.DeleteTransaction(context.Background(), 2, 1, 1)
+	assert.EqualError(t, err, "no transaction found or not authorized")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
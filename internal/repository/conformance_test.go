@@ -0,0 +1,978 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nemopss/financial-tracker/internal/migrate"
+	"github.com/nemopss/financial-tracker/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+// The conformance suite runs the same scenarios against every Repository
+// implementation, so the in-memory and SQLite ones cannot drift from Postgres.
+// Set TEST_DATABASE_URL to a disposable database to include Postgres; its
+// tables are truncated before every scenario.
+
+func TestMemoryRepoConformance(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewMemoryRepo()
+	})
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		db, err := NewSQLiteDB(filepath.Join(t.TempDir(), "conformance.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Conn.Close() })
+
+		migrator, err := migrate.New(db.Conn, migrations.SQLiteFS)
+		if err != nil {
+			t.Fatalf("Failed to load migrations: %v", err)
+		}
+		migrator.Dialect = migrate.SQLite
+		var outdated *migrate.OutdatedSchemaError
+		if err := migrator.Check(context.Background()); !errors.As(err, &outdated) {
+			t.Fatalf("Expected a fresh database to be outdated, got %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("Failed to migrate database: %v", err)
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			t.Fatalf("Failed to check migrated database: %v", err)
+		}
+		return db
+	})
+}
+
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := NewDB(context.Background(), PostgresConfig{URL: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db.Conn, migrations.FS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	testRepository(t, func(t *testing.T) Repository {
+		query := `TRUNCATE users, categories, transactions, transaction_splits, tags, transaction_tags,
+            attachments, audit_log, transaction_versions RESTART IDENTITY CASCADE`
+		if _, err := db.Conn.Exec(query); err != nil {
+			t.Fatalf("Failed to reset database: %v", err)
+		}
+		return db
+	})
+}
+
+// testRepository runs every scenario on a fresh repository from newRepo.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r Repository)
+	}{
+		{"Users", conformUsers},
+		{"Categories", conformCategories},
+		{"DeleteCategory", conformDeleteCategory},
+		{"Transactions", conformTransactions},
+		{"Uncategorised", conformUncategorised},
+		{"History", conformHistory},
+		{"Splits", conformSplits},
+		{"Tags", conformTags},
+		{"Trash", conformTrash},
+		{"TrashedCategory", conformTrashedCategory},
+		{"Audit", conformAudit},
+		{"Analytics", conformAnalytics},
+		{"WithTx", conformWithTx},
+	}
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			s.run(t, newRepo(t))
+		})
+	}
+}
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// mustUser creates a user and fails the test if that is not possible.
+func mustUser(t *testing.T, r Repository, username string) int {
+	id, err := r.CreateUser(context.Background(), username, "hash-of-"+username)
+	if err != nil {
+		t.Fatalf("Failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+func mustCategory(t *testing.T, r Repository, userID int, name string) int {
+	id, err := r.CreateCategory(context.Background(), userID, name)
+	if err != nil {
+		t.Fatalf("Failed to create category %s: %v", name, err)
+	}
+	return id
+}
+
+func mustTransaction(t *testing.T, r Repository, txn Transaction) int {
+	id, err := r.CreateTransaction(context.Background(), txn)
+	if err != nil {
+		t.Fatalf("Failed to create transaction %s: %v", txn.Description, err)
+	}
+	return id
+}
+
+func transactionIDs(transactions []Transaction) []int {
+	ids := []int{}
+	for _, t := range transactions {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func conformUsers(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+
+	_, err := r.CreateUser(ctx, "alice", "other")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	user, err := r.GetUserByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, alice, user.ID)
+		assert.Equal(t, "hash-of-alice", user.Password)
+	}
+	user, err = r.GetUserByUsername(ctx, "nobody")
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+
+	timezone, err := r.GetUserTimezone(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", timezone)
+	assert.NoError(t, r.SetUserTimezone(ctx, alice, "Europe/Moscow"))
+	timezone, err = r.GetUserTimezone(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", timezone)
+
+	assert.ErrorIs(t, r.SetUserTimezone(ctx, alice+100, "UTC"), ErrNotFound)
+}
+
+func conformCategories(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	bob := mustUser(t, r, "bob")
+	food := mustCategory(t, r, alice, "Food")
+	travel := mustCategory(t, r, alice, "Travel")
+	mustCategory(t, r, bob, "Rent")
+
+	categories, err := r.GetCategories(ctx, alice)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Category{
+		{ID: food, Name: "Food", Version: 1},
+		{ID: travel, Name: "Travel", Version: 1},
+	}, categories)
+
+	category, err := r.GetCategory(ctx, bob, food)
+	assert.NoError(t, err)
+	assert.Nil(t, category)
+
+	assert.NoError(t, r.UpdateCategory(ctx, alice, food, "Groceries", 1))
+	err = r.UpdateCategory(ctx, alice, food, "Eating out", 1)
+	var conflict *VersionConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, 2, conflict.Current)
+	}
+	assert.ErrorIs(t, r.UpdateCategory(ctx, bob, food, "Stolen", 0), ErrNotFound)
+
+	category, err = r.GetCategory(ctx, alice, food)
+	assert.NoError(t, err)
+	assert.Equal(t, &Category{ID: food, Name: "Groceries", Version: 2}, category)
+
+	txn := mustTransaction(t, r, Transaction{Amount: -10, Date: day(10, 1), Description: "Bread", CategoryID: food, UserID: alice})
+	assert.ErrorIs(t, r.MergeCategories(ctx, alice, food, travel, 1), ErrVersionConflict)
+	assert.NoError(t, r.MergeCategories(ctx, alice, food, travel, 2))
+
+	category, err = r.GetCategory(ctx, alice, food)
+	assert.NoError(t, err)
+	assert.Nil(t, category)
+	trash, err := r.GetTrash(ctx, alice)
+	assert.NoError(t, err)
+	if assert.Len(t, trash.Categories, 1, "the merged category goes to the trash") {
+		assert.Equal(t, food, trash.Categories[0].ID)
+	}
+	moved, err := r.GetTransaction(ctx, alice, txn)
+	assert.NoError(t, err)
+	if assert.NotNil(t, moved) {
+		assert.Equal(t, travel, moved.CategoryID)
+		assert.Equal(t, 2, moved.Version)
+	}
+	assert.ErrorIs(t, r.MergeCategories(ctx, alice, travel, travel, 0), ErrValidation)
+}
+
+func conformDeleteCategory(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	travel := mustCategory(t, r, alice, "Travel")
+	txn := mustTransaction(t, r, Transaction{Amount: -10, Date: day(10, 1), Description: "Bread", CategoryID: food, UserID: alice})
+
+	err := r.DeleteCategory(ctx, alice, food, CategoryDeleteBlock, 0, 0)
+	var inUse *CategoryInUseError
+	if assert.True(t, errors.As(err, &inUse)) {
+		assert.Equal(t, 1, inUse.Count)
+	}
+	assert.ErrorIs(t, r.DeleteCategory(ctx, alice, food, "shred", 0, 0), ErrValidation)
+	assert.ErrorIs(t, r.DeleteCategory(ctx, alice, food, CategoryDeleteReassign, food, 0), ErrValidation)
+
+	assert.NoError(t, r.DeleteCategory(ctx, alice, food, CategoryDeleteReassign, travel, 1))
+	moved, err := r.GetTransaction(ctx, alice, txn)
+	assert.NoError(t, err)
+	if assert.NotNil(t, moved) {
+		assert.Equal(t, travel, moved.CategoryID)
+	}
+
+	assert.NoError(t, r.DeleteCategory(ctx, alice, travel, CategoryDeleteCascade, 0, 0))
+	transactions, err := r.GetTransactions(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, transactions)
+	categories, err := r.GetCategories(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, categories)
+
+	assert.ErrorIs(t, r.DeleteCategory(ctx, alice, travel, CategoryDeleteBlock, 0, 0), ErrNotFound)
+}
+
+func conformTransactions(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	bob := mustUser(t, r, "bob")
+	food := mustCategory(t, r, alice, "Food")
+	travel := mustCategory(t, r, alice, "Travel")
+
+	lunch := mustTransaction(t, r, Transaction{Amount: -12.5, Date: day(10, 1), Description: "Lunch", CategoryID: food, UserID: alice})
+	train := mustTransaction(t, r, Transaction{Amount: -30, Date: day(10, 2), Description: "Train", CategoryID: travel, UserID: alice})
+	mustTransaction(t, r, Transaction{Amount: -5, Date: day(10, 2), Description: "Coffee", CategoryID: mustCategory(t, r, bob, "Food"), UserID: bob})
+
+	transactions, err := r.GetTransactions(ctx, alice)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{lunch, train}, transactionIDs(transactions))
+
+	txn, err := r.GetTransaction(ctx, alice, lunch)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, -12.5, txn.Amount)
+		assert.True(t, day(10, 1).Equal(txn.Date))
+		assert.Equal(t, "Lunch", txn.Description)
+		assert.Equal(t, food, txn.CategoryID)
+		assert.Equal(t, 1, txn.Version)
+	}
+	txn, err = r.GetTransaction(ctx, bob, lunch)
+	assert.NoError(t, err)
+	assert.Nil(t, txn)
+
+	assert.NoError(t, r.UpdateTransaction(ctx, Transaction{ID: lunch, Amount: -15, Description: "Dinner", CategoryID: food, UserID: alice}))
+	txn, err = r.GetTransaction(ctx, alice, lunch)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, -15.0, txn.Amount)
+		assert.True(t, day(10, 1).Equal(txn.Date), "a zero date keeps the stored one")
+		assert.Equal(t, 2, txn.Version)
+	}
+	assert.ErrorIs(t, r.UpdateTransaction(ctx, Transaction{ID: lunch, Amount: -1, UserID: bob}), ErrNotFound)
+
+	description := "Late dinner"
+	err = r.PatchTransaction(ctx, alice, lunch, TransactionPatch{Description: &description}, 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.NoError(t, r.PatchTransaction(ctx, alice, lunch, TransactionPatch{Description: &description, ClearCategory: true}, 2))
+	txn, err = r.GetTransaction(ctx, alice, lunch)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, "Late dinner", txn.Description)
+		assert.Equal(t, 0, txn.CategoryID)
+		assert.Equal(t, 3, txn.Version)
+	}
+
+	assert.ErrorIs(t, r.RecategoriseTransaction(ctx, alice, lunch, food, 2), ErrVersionConflict)
+	assert.NoError(t, r.RecategoriseTransaction(ctx, alice, lunch, food, 3))
+	assert.ErrorIs(t, r.RecategoriseTransaction(ctx, bob, lunch, food, 0), ErrNotFound)
+	assert.ErrorIs(t, r.RecategoriseTransaction(ctx, alice, lunch, mustCategory(t, r, bob, "Travel"), 0), ErrNotFound)
+
+	assert.ErrorIs(t, r.DeleteTransaction(ctx, alice, train, 2), ErrVersionConflict)
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, train, 1))
+	assert.ErrorIs(t, r.DeleteTransaction(ctx, alice, train, 0), ErrNotFound)
+	transactions, err = r.GetTransactions(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{lunch}, transactionIDs(transactions))
+}
+
+// conformUncategorised stores category 0 as no category on every write path.
+func conformUncategorised(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+
+	id, err := r.CreateTransaction(ctx, Transaction{Amount: -8, Date: day(10, 1), Description: "Cash", UserID: alice})
+	assert.NoError(t, err)
+	txn, err := r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, 0, txn.CategoryID)
+	}
+
+	assert.NoError(t, r.UpdateTransaction(ctx, Transaction{ID: id, Amount: -9, Description: "Cash", CategoryID: food, UserID: alice}))
+	assert.NoError(t, r.UpdateTransaction(ctx, Transaction{ID: id, Amount: -9, Description: "Cash", UserID: alice}))
+	assert.NoError(t, r.RecategoriseTransaction(ctx, alice, id, food, 0))
+	assert.NoError(t, r.RecategoriseTransaction(ctx, alice, id, 0, 0))
+	assert.NoError(t, r.SetSplits(ctx, alice, id, []Split{{CategoryID: food, Amount: -4}, {Amount: -5}}, 0))
+
+	transactions, err := r.GetTransactionsFiltered(ctx, alice, TransactionFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, transactions, 1) {
+		assert.Equal(t, 0, transactions[0].CategoryID)
+		assert.Equal(t, 6, transactions[0].Version)
+	}
+	splits, err := r.GetSplits(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.Len(t, splits, 2) {
+		assert.Equal(t, 0, splits[1].CategoryID)
+	}
+}
+
+func conformHistory(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	id := mustTransaction(t, r, Transaction{Amount: -40, Date: day(10, 1), Description: "Lunch", CategoryID: food, UserID: alice})
+
+	amount := -50.0
+	assert.NoError(t, r.PatchTransaction(ctx, alice, id, TransactionPatch{Amount: &amount}, 1))
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, id, 2))
+
+	history, err := r.GetTransactionHistory(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, history) {
+		assert.Equal(t, 3, history.Current.Version)
+		assert.NotNil(t, history.Current.DeletedAt)
+		if assert.Len(t, history.Versions, 2) {
+			assert.Equal(t, 2, history.Versions[0].Version)
+			assert.Equal(t, -50.0, history.Versions[0].Amount)
+			assert.Equal(t, 1, history.Versions[1].Version)
+			assert.Equal(t, -40.0, history.Versions[1].Amount)
+		}
+	}
+
+	_, err = r.UndoTransaction(ctx, alice, id, 2, 0)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, err = r.UndoTransaction(ctx, alice, id, 3, 7)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	version, err := r.UndoTransaction(ctx, alice, id, 3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, version)
+	txn, err := r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, -40.0, txn.Amount)
+		assert.Equal(t, 4, txn.Version)
+	}
+
+	history, err = r.GetTransactionHistory(ctx, alice+100, id)
+	assert.NoError(t, err)
+	assert.Nil(t, history)
+
+	// Versions hold the split lines and tags, which undoing brings back
+	tag, err := r.CreateTag(ctx, alice, "work")
+	assert.NoError(t, err)
+	assert.NoError(t, r.SetSplits(ctx, alice, id, []Split{{CategoryID: food, Amount: -30, Note: "Meal"}, {Amount: -10}}, 4))
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, id, []int{tag}, 5))
+	assert.NoError(t, r.SetSplits(ctx, alice, id, nil, 6))
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, id, nil, 7))
+
+	history, err = r.GetTransactionHistory(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, history) && assert.Len(t, history.Versions, 7) {
+		assert.Equal(t, 7, history.Versions[0].Version)
+		assert.Equal(t, []int{tag}, history.Versions[0].TagIDs)
+		assert.Empty(t, history.Versions[0].Splits)
+		assert.Equal(t, 6, history.Versions[1].Version)
+		if assert.Len(t, history.Versions[1].Splits, 2) {
+			assert.Equal(t, Split{CategoryID: food, Amount: -30, Note: "Meal"}, withoutIDs(history.Versions[1].Splits[0]))
+		}
+	}
+
+	version, err = r.UndoTransaction(ctx, alice, id, 8, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, version)
+	txn, err = r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, []int{tag}, txn.TagIDs)
+	}
+	splits, err := r.GetSplits(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.Len(t, splits, 2) {
+		assert.Equal(t, Split{CategoryID: food, Amount: -30, Note: "Meal"}, withoutIDs(splits[0]))
+		assert.Equal(t, Split{Amount: -10}, withoutIDs(splits[1]))
+	}
+
+	// Nor can a version come back into a trashed category
+	home := mustCategory(t, r, alice, "Home")
+	other := mustTransaction(t, r, Transaction{Amount: -5, Date: day(10, 2), Description: "Nails", CategoryID: home, UserID: alice})
+	assert.NoError(t, r.RecategoriseTransaction(ctx, alice, other, food, 0))
+	assert.NoError(t, r.DeleteCategory(ctx, alice, home, CategoryDeleteBlock, 0, 0))
+	_, err = r.UndoTransaction(ctx, alice, other, 2, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// or into a category merged into another one, even once it is purged
+	garden := mustCategory(t, r, alice, "Garden")
+	plant := mustTransaction(t, r, Transaction{Amount: -15, Date: day(10, 3), Description: "Plant", CategoryID: garden, UserID: alice})
+	assert.NoError(t, r.RecategoriseTransaction(ctx, alice, plant, food, 1))
+	assert.NoError(t, r.SetSplits(ctx, alice, plant, []Split{{CategoryID: garden, Amount: -15}}, 2))
+	assert.NoError(t, r.MergeCategories(ctx, alice, garden, food, 0))
+	_, err = r.UndoTransaction(ctx, alice, plant, 4, 1)
+	assert.ErrorIs(t, err, ErrConflict, "the category of version 1 was merged")
+	_, err = r.UndoTransaction(ctx, alice, plant, 4, 3)
+	assert.ErrorIs(t, err, ErrConflict, "a split line of version 3 used the merged category")
+	_, err = r.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = r.UndoTransaction(ctx, alice, plant, 4, 1)
+	assert.ErrorIs(t, err, ErrConflict, "the merged category was purged")
+	history, err = r.GetTransactionHistory(ctx, alice, plant)
+	assert.NoError(t, err)
+	if assert.NotNil(t, history) {
+		assert.Equal(t, garden, history.Versions[len(history.Versions)-1].CategoryID, "history keeps purged categories")
+	}
+
+	// A tag deleted since cannot be put back either
+	assert.NoError(t, r.DeleteTag(ctx, alice, tag, 0))
+	_, err = r.UndoTransaction(ctx, alice, id, 10, 9)
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+// withoutIDs clears the IDs of a split line, which change when it is rewritten.
+func withoutIDs(s Split) Split {
+	s.ID, s.TransactionID = 0, 0
+	return s
+}
+
+func conformSplits(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	bob := mustUser(t, r, "bob")
+	food := mustCategory(t, r, alice, "Food")
+	home := mustCategory(t, r, alice, "Home")
+	id := mustTransaction(t, r, Transaction{Amount: -100, Date: day(10, 1), Description: "Market", CategoryID: food, UserID: alice})
+
+	err := r.SetSplits(ctx, alice, id, []Split{{CategoryID: food, Amount: -60}}, 0)
+	assert.ErrorIs(t, err, ErrSplitSumMismatch)
+	err = r.SetSplits(ctx, alice, id, []Split{{CategoryID: mustCategory(t, r, bob, "Rent"), Amount: -100}}, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = r.GetSplits(ctx, bob, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = r.SetSplits(ctx, alice, id, []Split{{CategoryID: food, Amount: -100}}, 2)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	assert.NoError(t, r.SetSplits(ctx, alice, id, []Split{
+		{CategoryID: food, Amount: -60, Note: "Vegetables"},
+		{CategoryID: home, Amount: -40},
+	}, 1))
+	splits, err := r.GetSplits(ctx, alice, id)
+	assert.NoError(t, err)
+	if assert.Len(t, splits, 2) {
+		assert.Equal(t, Split{ID: splits[0].ID, TransactionID: id, CategoryID: food, Amount: -60, Note: "Vegetables"}, splits[0])
+		assert.Equal(t, Split{ID: splits[1].ID, TransactionID: id, CategoryID: home, Amount: -40}, splits[1])
+	}
+
+	amount := -90.0
+	err = r.PatchTransaction(ctx, alice, id, TransactionPatch{Amount: &amount}, 0)
+	assert.ErrorIs(t, err, ErrSplitSumMismatch)
+
+	analytics, err := r.GetCategoryAnalytics(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []CategoryAnalytics{
+		{CategoryName: "Home", TotalAmount: -40},
+		{CategoryName: "Food", TotalAmount: -60},
+	}, analytics)
+
+	err = r.DeleteCategory(ctx, alice, home, CategoryDeleteBlock, 0, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+	// Cascading would trash the food line along with the home one
+	err = r.DeleteCategory(ctx, alice, home, CategoryDeleteCascade, 0, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, err.Error(), fmt.Sprintf("transactions %d ", id))
+	txn, err := r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	assert.NotNil(t, txn)
+
+	assert.NoError(t, r.DeleteSplits(ctx, alice, id, 0))
+	splits, err = r.GetSplits(ctx, alice, id)
+	assert.NoError(t, err)
+	assert.Empty(t, splits)
+}
+
+func conformTags(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	bob := mustUser(t, r, "bob")
+	food := mustCategory(t, r, alice, "Food")
+
+	work, err := r.CreateTag(ctx, alice, "work")
+	assert.NoError(t, err)
+	trip, err := r.CreateTag(ctx, alice, "trip")
+	assert.NoError(t, err)
+	_, err = r.CreateTag(ctx, alice, "work")
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = r.CreateTag(ctx, bob, "work")
+	assert.NoError(t, err)
+
+	tags, err := r.GetTags(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: trip, Name: "trip", Version: 1}, {ID: work, Name: "work", Version: 1}}, tags)
+
+	both := mustTransaction(t, r, Transaction{Amount: -20, Date: day(10, 1), Description: "Hotel", CategoryID: food, UserID: alice})
+	one := mustTransaction(t, r, Transaction{Amount: -5, Date: day(10, 1), Description: "Snack", CategoryID: food, UserID: alice})
+	assert.ErrorIs(t, r.SetTransactionTags(ctx, alice, both, []int{work, trip}, 2), ErrVersionConflict)
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, both, []int{work, trip}, 1))
+	assert.ErrorIs(t, r.SetTransactionTags(ctx, alice, both, []int{work}, 1), ErrVersionConflict, "tagging bumps the version")
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, one, []int{work}, 0))
+	assert.ErrorIs(t, r.SetTransactionTags(ctx, bob, one, []int{work}, 0), ErrNotFound)
+
+	txn, err := r.GetTransaction(ctx, alice, both)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.ElementsMatch(t, []int{work, trip}, txn.TagIDs)
+		assert.Equal(t, 2, txn.Version)
+	}
+
+	// Tags written with the transaction share its version
+	tagged, err := r.CreateTransaction(ctx, Transaction{Amount: -9, Date: day(10, 2), Description: "Taxi", UserID: alice, TagIDs: []int{trip}})
+	assert.NoError(t, err)
+	_, err = r.CreateTransaction(ctx, Transaction{Amount: -9, Date: day(10, 2), Description: "Taxi", UserID: alice, TagIDs: []int{work, 9999}})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, r.UpdateTransaction(ctx, Transaction{ID: tagged, Amount: -9, Description: "Taxi", UserID: alice, TagIDs: []int{work}, Version: 1}))
+	assert.NoError(t, r.PatchTransaction(ctx, alice, tagged, TransactionPatch{TagIDs: []int{}}, 2))
+	assert.NoError(t, r.PatchTransaction(ctx, alice, tagged, TransactionPatch{TagIDs: []int{}}, 3), "unchanged tags keep the version")
+	txn, err = r.GetTransaction(ctx, alice, tagged)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Empty(t, txn.TagIDs)
+		assert.Equal(t, 3, txn.Version)
+	}
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, tagged, 3))
+
+	transactions, err := r.GetTransactionsFiltered(ctx, alice, TransactionFilter{TagIDs: []int{work, trip}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{both, one}, transactionIDs(transactions))
+	transactions, err = r.GetTransactionsFiltered(ctx, alice, TransactionFilter{TagIDs: []int{work, trip}, MatchAllTags: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{both}, transactionIDs(transactions))
+	transactions, err = r.GetTransactionsFiltered(ctx, alice, TransactionFilter{TagIDs: []int{trip, trip}, MatchAllTags: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{both}, transactionIDs(transactions), "a repeated tag is counted once")
+
+	assert.ErrorIs(t, r.UpdateTag(ctx, alice, trip, "work", 0), ErrConflict)
+	assert.ErrorIs(t, r.UpdateTag(ctx, alice, trip, "travel", 2), ErrVersionConflict)
+	assert.NoError(t, r.UpdateTag(ctx, alice, trip, "travel", 1))
+	tags, err = r.GetTags(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: trip, Name: "travel", Version: 2}, {ID: work, Name: "work", Version: 1}}, tags)
+	assert.ErrorIs(t, r.DeleteTag(ctx, alice, work, 2), ErrVersionConflict)
+	assert.NoError(t, r.DeleteTag(ctx, alice, work, 1))
+	assert.ErrorIs(t, r.DeleteTag(ctx, alice, work, 0), ErrNotFound)
+
+	txn, err = r.GetTransaction(ctx, alice, one)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Empty(t, txn.TagIDs)
+		assert.Equal(t, 3, txn.Version, "deleting a tag bumps the transactions carrying it")
+	}
+}
+
+func conformTrash(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	kept := mustTransaction(t, r, Transaction{Amount: -3, Date: day(10, 1), Description: "Tea", CategoryID: food, UserID: alice})
+	purged := mustTransaction(t, r, Transaction{Amount: -7, Date: day(10, 1), Description: "Cake", CategoryID: food, UserID: alice})
+
+	attachmentID, err := r.CreateAttachment(ctx, Attachment{
+		TransactionID: purged,
+		UserID:        alice,
+		Filename:      "receipt.pdf",
+		ContentType:   "application/pdf",
+		Size:          42,
+		StorageKey:    "receipts/cake.pdf",
+	})
+	assert.NoError(t, err)
+	attachments, err := r.GetAttachments(ctx, alice, purged)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	bob := mustUser(t, r, "bob")
+	_, err = r.GetAttachments(ctx, bob, purged)
+	assert.ErrorIs(t, err, ErrNotFound, "the attachments of another user's transaction are not found")
+
+	assert.NoError(t, r.SetSplits(ctx, alice, purged, []Split{{CategoryID: food, Amount: -7}}, 0))
+	tag, err := r.CreateTag(ctx, alice, "sweets")
+	assert.NoError(t, err)
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, purged, []int{tag}, 0))
+
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, kept, 0))
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, purged, 0))
+	trash, err := r.GetTrash(ctx, alice)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{kept, purged}, transactionIDs(trash.Transactions))
+	assert.Empty(t, trash.Categories)
+
+	assert.NoError(t, r.RestoreTransaction(ctx, alice, kept))
+	assert.ErrorIs(t, r.RestoreTransaction(ctx, alice, kept), ErrNotFound)
+
+	removed, err := r.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, attachmentID, removed[0].ID)
+		assert.Equal(t, "receipts/cake.pdf", removed[0].StorageKey)
+	}
+
+	trash, err = r.GetTrash(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, trash.Transactions)
+
+	// Rows removed along with the transaction are audited too
+	entries, err := r.GetTransactionAudit(ctx, alice, purged)
+	assert.NoError(t, err)
+	var purges []string
+	for _, e := range entries {
+		if e.Action == AuditPurge {
+			purges = append(purges, e.Entity)
+			assert.Nil(t, e.ActorID)
+			assert.NotEmpty(t, e.Before)
+		}
+	}
+	assert.ElementsMatch(t, []string{EntityTransaction, EntityAttachment, EntitySplit, EntityTransactionTags}, purges)
+
+	attachment, err := r.GetAttachment(ctx, alice, attachmentID)
+	assert.NoError(t, err)
+	assert.Nil(t, attachment)
+	txn, err := r.GetTransaction(ctx, alice, kept)
+	assert.NoError(t, err)
+	assert.NotNil(t, txn)
+}
+
+// conformTrashedCategory makes sure no active transaction ends up pointing at
+// a category in the trash, and that purging never clears a category.
+func conformTrashedCategory(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	home := mustCategory(t, r, alice, "Home")
+	lunch := mustTransaction(t, r, Transaction{Amount: -10, Date: day(10, 1), Description: "Lunch", CategoryID: food, UserID: alice})
+	market := mustTransaction(t, r, Transaction{Amount: -100, Date: day(10, 2), Description: "Market", CategoryID: food, UserID: alice})
+	assert.NoError(t, r.SetSplits(ctx, alice, market, []Split{{CategoryID: food, Amount: -60}, {CategoryID: home, Amount: -40}}, 0))
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, lunch, 0))
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, market, 0))
+
+	// Trashed transactions and their split lines still hold on to the category
+	err := r.DeleteCategory(ctx, alice, food, CategoryDeleteBlock, 0, 0)
+	var inUse *CategoryInUseError
+	if assert.True(t, errors.As(err, &inUse)) {
+		assert.Equal(t, 2, inUse.Count)
+	}
+	err = r.DeleteCategory(ctx, alice, home, CategoryDeleteBlock, 0, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	assert.NoError(t, r.DeleteCategory(ctx, alice, food, CategoryDeleteCascade, 0, 0))
+	assert.NoError(t, r.DeleteCategory(ctx, alice, home, CategoryDeleteCascade, 0, 0))
+	assert.ErrorIs(t, r.RestoreTransaction(ctx, alice, lunch), ErrConflict)
+	assert.ErrorIs(t, r.RestoreTransaction(ctx, alice, market), ErrConflict)
+
+	assert.NoError(t, r.RestoreCategory(ctx, alice, food))
+	assert.NoError(t, r.RestoreTransaction(ctx, alice, lunch))
+	assert.ErrorIs(t, r.RestoreTransaction(ctx, alice, market), ErrConflict, "a split line is still in a trashed category")
+	assert.NoError(t, r.RestoreCategory(ctx, alice, home))
+	assert.NoError(t, r.RestoreTransaction(ctx, alice, market))
+
+	analytics, err := r.GetCategoryAnalytics(ctx, alice)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []CategoryAnalytics{
+		{CategoryName: "Food", TotalAmount: -70},
+		{CategoryName: "Home", TotalAmount: -40},
+	}, analytics)
+
+	// Rows written before restores checked the category may still point at
+	// the trash; purging keeps such a category rather than clearing theirs
+	travel := mustCategory(t, r, alice, "Travel")
+	assert.NoError(t, r.DeleteCategory(ctx, alice, travel, CategoryDeleteBlock, 0, 0))
+	stale := mustTransaction(t, r, Transaction{Amount: -30, Date: day(10, 3), Description: "Train", CategoryID: travel, UserID: alice})
+	_, err = r.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	trash, err := r.GetTrash(ctx, alice)
+	assert.NoError(t, err)
+	if assert.Len(t, trash.Categories, 1) {
+		assert.Equal(t, travel, trash.Categories[0].ID)
+	}
+	txn, err := r.GetTransaction(ctx, alice, stale)
+	assert.NoError(t, err)
+	if assert.NotNil(t, txn) {
+		assert.Equal(t, travel, txn.CategoryID)
+	}
+
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, stale, 0))
+	_, err = r.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	trash, err = r.GetTrash(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, trash.Categories)
+	assert.Empty(t, trash.Transactions)
+}
+
+func conformAudit(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	id := mustTransaction(t, r, Transaction{Amount: -8, Date: day(10, 1), Description: "Pizza", CategoryID: food, UserID: alice})
+	assert.NoError(t, r.UpdateCategory(ctx, alice, food, "Eating out", 0))
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, id, 0))
+	assert.NoError(t, r.RestoreTransaction(ctx, alice, id))
+
+	entries, err := r.GetTransactionAudit(ctx, alice, id)
+	assert.NoError(t, err)
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{AuditRestore, AuditDelete, AuditCreate}, actions)
+
+	entries, err = r.GetAuditLog(ctx, alice, AuditFilter{Entity: EntityCategory, Action: AuditUpdate, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, food, entries[0].EntityID)
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%d,"name":"Food","user_id":%d,"deleted_at":null,"version":1}`, food, alice), string(entries[0].Before))
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%d,"name":"Eating out","user_id":%d,"deleted_at":null,"version":2}`, food, alice), string(entries[0].After))
+	}
+
+	entries, err = r.GetAuditLog(ctx, alice, AuditFilter{Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = r.GetAuditLog(ctx, alice+100, AuditFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func conformAnalytics(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	bob := mustUser(t, r, "bob")
+	food := mustCategory(t, r, alice, "Food")
+	salary := mustCategory(t, r, alice, "Salary")
+	tag, err := r.CreateTag(ctx, alice, "family")
+	assert.NoError(t, err)
+
+	mustTransaction(t, r, Transaction{Amount: 1000, Date: day(9, 30), Description: "September pay", CategoryID: salary, UserID: alice})
+	groceries := mustTransaction(t, r, Transaction{Amount: -40.25, Date: day(10, 1), Description: "Groceries", CategoryID: food, UserID: alice})
+	mustTransaction(t, r, Transaction{Amount: 1200, Date: day(10, 15), Description: "October pay", CategoryID: salary, UserID: alice})
+	mustTransaction(t, r, Transaction{Amount: -9.75, Date: day(10, 31), Description: "Snacks", CategoryID: food, UserID: alice})
+	trashed := mustTransaction(t, r, Transaction{Amount: -500, Date: day(10, 20), Description: "Mistake", CategoryID: food, UserID: alice})
+	mustTransaction(t, r, Transaction{Amount: -70, Date: day(10, 5), Description: "Other user", CategoryID: mustCategory(t, r, bob, "Food"), UserID: bob})
+	assert.NoError(t, r.DeleteTransaction(ctx, alice, trashed, 0))
+	assert.NoError(t, r.SetTransactionTags(ctx, alice, groceries, []int{tag}, 0))
+
+	summary, err := r.GetIncomeAndExpenses(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, &Analytics{TotalIncome: 2200, TotalExpense: -50}, summary)
+
+	// The window is [from, to): September 30 and October 31 fall outside
+	from, to := day(10, 1), day(10, 31)
+	summary, err = r.GetIncomeAndExpensesFiltered(ctx, alice, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, &Analytics{TotalIncome: 1200, TotalExpense: -40.25}, summary)
+
+	categories, err := r.GetCategoryAnalytics(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []CategoryAnalytics{
+		{CategoryName: "Salary", TotalAmount: 2200},
+		{CategoryName: "Food", TotalAmount: -50},
+	}, categories)
+	categories, err = r.GetCategoryAnalyticsFiltered(ctx, alice, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []CategoryAnalytics{
+		{CategoryName: "Salary", TotalAmount: 1200},
+		{CategoryName: "Food", TotalAmount: -40.25},
+	}, categories)
+
+	tags, err := r.GetTagAnalyticsFiltered(ctx, alice, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []TagAnalytics{{TagName: "family", TotalAmount: -40.25}}, tags)
+	tags, err = r.GetTagAnalyticsFiltered(ctx, alice, day(11, 1), day(12, 1))
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+
+	summary, err = r.GetIncomeAndExpensesFiltered(ctx, bob, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, &Analytics{TotalExpense: -70}, summary)
+}
+
+func conformWithTx(t *testing.T, r Repository) {
+	ctx := context.Background()
+	alice := mustUser(t, r, "alice")
+	errAbort := errors.New("abort")
+
+	err := r.WithTx(ctx, func(tx Repository) error {
+		if _, err := tx.CreateCategory(ctx, alice, "Food"); err != nil {
+			return err
+		}
+		return tx.WithTx(ctx, func(tx Repository) error {
+			if _, err := tx.CreateTag(ctx, alice, "work"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	categories, err := r.GetCategories(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, categories)
+	tags, err := r.GetTags(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+
+	var food int
+	assert.NoError(t, r.WithTx(ctx, func(tx Repository) error {
+		var err error
+		food, err = tx.CreateCategory(ctx, alice, "Food")
+		return err
+	}))
+	category, err := r.GetCategory(ctx, alice, food)
+	assert.NoError(t, err)
+	assert.NotNil(t, category)
+}
+
+// TestMemoryRepoConcurrency hammers one MemoryRepo from many goroutines; run
+// it with -race to catch unguarded state.
+func TestMemoryRepoConcurrency(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRepo()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	id := mustTransaction(t, r, Transaction{Amount: 0, Date: day(10, 1), Description: "Counter", CategoryID: food, UserID: alice})
+
+	const workers, rounds = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				// Optimistic increments retry on a version conflict, so none are lost
+				for {
+					txn, err := r.GetTransaction(ctx, alice, id)
+					if err != nil || txn == nil {
+						t.Errorf("Failed to read transaction: %v", err)
+						return
+					}
+					amount := txn.Amount + 1
+					err = r.PatchTransaction(ctx, alice, id, TransactionPatch{Amount: &amount}, txn.Version)
+					if errors.Is(err, ErrVersionConflict) {
+						continue
+					}
+					if err != nil {
+						t.Errorf("Failed to patch transaction: %v", err)
+						return
+					}
+					break
+				}
+
+				mustTransaction(t, r, Transaction{Amount: -1, Date: day(10, 2), Description: "Spend", CategoryID: food, UserID: alice})
+				if _, err := r.GetIncomeAndExpenses(ctx, alice); err != nil {
+					t.Errorf("Failed to read analytics: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	txn, err := r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(workers*rounds), txn.Amount)
+	assert.Equal(t, workers*rounds+1, txn.Version)
+
+	summary, err := r.GetIncomeAndExpenses(ctx, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, &Analytics{TotalIncome: workers * rounds, TotalExpense: -workers * rounds}, summary)
+}
+
+// TestMemoryRepoRollback checks that the journal reverts every kind of change,
+// including IDs handed out and audit entries, when WithTx fails or panics.
+func TestMemoryRepoRollback(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRepo()
+	alice := mustUser(t, r, "alice")
+	food := mustCategory(t, r, alice, "Food")
+	rent := mustCategory(t, r, alice, "Rent")
+	id := mustTransaction(t, r, Transaction{Amount: -10, Date: day(10, 1), Description: "Lunch", CategoryID: food, UserID: alice})
+
+	before, err := r.GetAuditLog(ctx, alice, AuditFilter{Limit: 100})
+	assert.NoError(t, err)
+
+	change := func(tx Repository) {
+		amount := -20.0
+		assert.NoError(t, tx.PatchTransaction(ctx, alice, id, TransactionPatch{Amount: &amount}, 1))
+		assert.NoError(t, tx.SetSplits(ctx, alice, id, []Split{{CategoryID: rent, Amount: -20}}, 0))
+		_, err := tx.CreateTag(ctx, alice, "work")
+		assert.NoError(t, err)
+		assert.NoError(t, tx.DeleteCategory(ctx, alice, food, CategoryDeleteCascade, 0, 0))
+	}
+	errAbort := errors.New("abort")
+	assert.ErrorIs(t, r.WithTx(ctx, func(tx Repository) error {
+		change(tx)
+		return errAbort
+	}), errAbort)
+	assert.Panics(t, func() {
+		r.WithTx(ctx, func(tx Repository) error {
+			change(tx)
+			panic("boom")
+		})
+	})
+
+	txn, err := r.GetTransaction(ctx, alice, id)
+	assert.NoError(t, err)
+	assert.Equal(t, -10.0, txn.Amount)
+	assert.Equal(t, 1, txn.Version)
+	splits, err := r.GetSplits(ctx, alice, id)
+	assert.NoError(t, err)
+	assert.Empty(t, splits)
+	tags, err := r.GetTags(ctx, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+	category, err := r.GetCategory(ctx, alice, food)
+	assert.NoError(t, err)
+	assert.NotNil(t, category)
+	after, err := r.GetAuditLog(ctx, alice, AuditFilter{Limit: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// The IDs of the reverted rows are handed out again, like after a rollback
+	work, err := r.CreateTag(ctx, alice, "work")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, work)
+}
@@ -1,35 +1,226 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier runs the queries of the repository methods, so the same queries can
+// run either standalone or inside a transaction, through database/sql or pgx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (resultRows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) resultRow
+}
+
+// resultRows is the result of a query, as read by the repository methods.
+type resultRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Close() error
+	Err() error
+}
+
+// resultRow is the result of a query returning at most one row.
+type resultRow interface {
+	Scan(dest ...any) error
+}
+
+// sqlConn is the subset of *sql.DB and *sql.Tx used by the repository.
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlQuerier runs the queries of a DB through database/sql.
+type sqlQuerier struct {
+	q sqlConn
+}
+
+func (s sqlQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.q.ExecContext(ctx, query, args...)
+}
+
+func (s sqlQuerier) QueryContext(ctx context.Context, query string, args ...any) (resultRows, error) {
+	return s.q.QueryContext(ctx, query, args...)
+}
+
+func (s sqlQuerier) QueryRowContext(ctx context.Context, query string, args ...any) resultRow {
+	return s.q.QueryRowContext(ctx, query, args...)
+}
+
+// Dialect is the SQL flavour spoken by the database behind a DB.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
 )
 
 type DB struct {
+	// Conn is the database/sql handle. When the DB runs on pgx it shares the
+	// connections of the pool and only serves callers outside the repository,
+	// such as migrations.
 	Conn *sql.DB
+
+	// pool and pgx are set when Postgres is reached natively through pgx:
+	// pgx is the pool, or the transaction the DB is bound to.
+	pool  *pgxpool.Pool
+	pgx   pgxConn
+	pgxTx pgx.Tx
+	// tx is set on copies of DB bound to an open database/sql transaction.
+	tx *sql.Tx
+	// dialect is empty for Postgres.
+	dialect Dialect
+}
+
+// Close closes the database and its connection pool.
+func (db *DB) Close() error {
+	err := db.Conn.Close()
+	if db.pool != nil {
+		db.pool.Close()
+	}
+	return err
+}
+
+// Ping checks that the database accepts connections.
+func (db *DB) Ping(ctx context.Context) error {
+	if db.pool != nil {
+		return db.pool.Ping(ctx)
+	}
+	return db.Conn.PingContext(ctx)
 }
 
-func NewDB(host, port, password, user, dbname string) (*DB, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s",
-		user, password, host, port, dbname,
-	)
+// PoolStats is a snapshot of the connection pool for monitoring.
+type PoolStats struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration_ns"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// PoolStats reports the state of the connection pool. On pgx, WaitCount counts
+// the acquires that found no idle connection and WaitDuration is the total
+// time spent acquiring connections.
+func (db *DB) PoolStats() PoolStats {
+	if db.pool != nil {
+		s := db.pool.Stat()
+		return PoolStats{
+			MaxOpenConnections: int(s.MaxConns()),
+			OpenConnections:    int(s.TotalConns()),
+			InUse:              int(s.AcquiredConns()),
+			Idle:               int(s.IdleConns()),
+			WaitCount:          s.EmptyAcquireCount(),
+			WaitDuration:       s.AcquireDuration(),
+			MaxIdleTimeClosed:  s.MaxIdleDestroyCount(),
+			MaxLifetimeClosed:  s.MaxLifetimeDestroyCount(),
+		}
+	}
+
+	s := db.Conn.Stats()
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+// Dialect reports which database DB talks to.
+func (db *DB) Dialect() Dialect {
+	if db.dialect == "" {
+		return Postgres
+	}
+	return db.dialect
+}
+
+// q returns the transaction the DB is bound to, or the connection pool otherwise.
+func (db *DB) q() querier {
+	if db.pgx != nil {
+		return pgxQuerier{db.pgx}
+	}
+
+	var q sqlConn = db.Conn
+	if db.tx != nil {
+		q = db.tx
+	}
+	if db.dialect == SQLite {
+		return sqliteQuerier{q}
+	}
+	return sqlQuerier{q}
+}
 
-	log.Printf("Connecting to DB: %s", dsn)
-	db, err := sql.Open("pgx", dsn)
+// inTx reports whether the DB is bound to a transaction.
+func (db *DB) inTx() bool {
+	return db.tx != nil || db.pgxTx != nil
+}
 
+// beginTx starts a database transaction and returns a copy of DB bound to it.
+func (db *DB) beginTx(ctx context.Context) (*DB, error) {
+	if db.pgx != nil {
+		tx, err := db.pgx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		return &DB{Conn: db.Conn, pool: db.pool, pgx: tx, pgxTx: tx, dialect: db.dialect}, nil
+	}
+
+	tx, err := db.Conn.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	return &DB{Conn: db.Conn, tx: tx, dialect: db.dialect}, nil
+}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("database connection error: %w", err)
+// commit commits the transaction the DB is bound to.
+func (db *DB) commit(ctx context.Context) error {
+	if db.pgxTx != nil {
+		return db.pgxTx.Commit(ctx)
 	}
+	return db.tx.Commit()
+}
 
-	log.Printf("database connection estabilished")
+// rollback rolls back the transaction the DB is bound to, unless it was
+// committed already. It also runs once ctx is cancelled.
+func (db *DB) rollback(ctx context.Context) {
+	if db.pgxTx != nil {
+		db.pgxTx.Rollback(context.WithoutCancel(ctx))
+		return
+	}
+	db.tx.Rollback()
+}
+
+// selectIDs returns the IDs selected by query.
+func (db *DB) selectIDs(ctx context.Context, query string, args ...any) ([]int, error) {
+	rows, err := db.q().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return &DB{Conn: db}, nil
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, &NotFoundError{Entity: EntityTag}, ErrNotFound)
+	assert.ErrorIs(t, &ConflictError{Entity: EntityTag, Reason: "taken"}, ErrConflict)
+	assert.ErrorIs(t, &VersionConflictError{Entity: EntityCategory, ID: 1, Expected: 1, Current: 2}, ErrConflict)
+	assert.ErrorIs(t, &CategoryInUseError{CategoryID: 1, Count: 3}, ErrConflict)
+	assert.ErrorIs(t, ErrSplitSumMismatch, ErrValidation)
+	assert.ErrorIs(t, ErrVersionNotFound, ErrNotFound)
+
+	assert.False(t, errors.Is(&NotFoundError{Entity: EntityTag}, ErrConflict))
+	assert.Equal(t, "no tag found or not authorized", (&NotFoundError{Entity: EntityTag}).Error())
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "amount", Message: "must not be zero"},
+		{Field: "name", Message: "is required"},
+	}}
+	assert.Equal(t, "amount: must not be zero; name: is required", err.Error())
+}
+
+func TestCreateTagDuplicateName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO tags").
+		WithArgs("travel", 1).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err = r.CreateTag(context.Background(), 1, "travel")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// copyThreshold is the number of transactions from which an import is copied
+// in with COPY rather than sent as a batch of inserts.
+const copyThreshold = 100
+
+// transactionImporter is implemented by repositories that create many
+// transactions at once faster than one by one.
+type transactionImporter interface {
+	importTransactions(ctx context.Context, userID int, txns []Transaction) ([]int, error)
+}
+
+// importTransactions creates txns of userID in one transaction and returns
+// their IDs in order. It is only supported on pgx: a few transactions are
+// inserted with a single batch, larger imports are copied in. The audit
+// entries of all of them are written by a single statement.
+func (db *DB) importTransactions(ctx context.Context, userID int, txns []Transaction) ([]int, error) {
+	if db.pgx == nil {
+		return nil, errors.ErrUnsupported
+	}
+
+	var ids []int
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		var err error
+		if len(txns) < copyThreshold {
+			ids, err = tx.insertTransactionBatch(ctx, txns)
+		} else {
+			ids, err = tx.copyTransactions(ctx, txns)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import transactions: %w", err)
+		}
+
+		query := `
+            INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, after, request_id)
+            SELECT $1, $1, 'transaction', x.id, 'create', row_to_json(x), NULLIF($3, '')
+            FROM (
+                SELECT id, amount, date, description, category_id, user_id, deleted_at, version
+                FROM transactions WHERE id = ANY($2) ORDER BY id
+            ) x
+        `
+		if _, err := tx.q().ExecContext(ctx, query, userID, ids, requestIDFromContext(ctx)); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// insertTransactionBatch inserts txns with one round trip.
+func (db *DB) insertTransactionBatch(ctx context.Context, txns []Transaction) ([]int, error) {
+	batch := &pgx.Batch{}
+	for _, txn := range txns {
+		batch.Queue(insertTransactionQuery, txn.Amount, txn.Date, txn.Description, categoryArg(txn.CategoryID), txn.UserID)
+	}
+
+	results := db.pgx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	ids := make([]int, len(txns))
+	for i := range txns {
+		if err := results.QueryRow().Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, results.Close()
+}
+
+// copyTransactions copies txns into the table. COPY returns no IDs, so they
+// are taken from the sequence up front.
+func (db *DB) copyTransactions(ctx context.Context, txns []Transaction) ([]int, error) {
+	query := "SELECT nextval(pg_get_serial_sequence('transactions', 'id')) FROM generate_series(1, $1)"
+	ids, err := db.selectIDs(ctx, query, len(txns))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{"id", "amount", "date", "description", "category_id", "user_id"}
+	if _, err := db.pgx.CopyFrom(ctx, pgx.Identifier{"transactions"}, columns, pgx.CopyFromRows(copyRows(ids, txns))); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// copyRows lays out txns with their IDs in the columns copyTransactions
+// copies, with no category stored as NULL.
+func copyRows(ids []int, txns []Transaction) [][]any {
+	rows := make([][]any, len(txns))
+	for i, txn := range txns {
+		rows[i] = []any{ids[i], txn.Amount, txn.Date, txn.Description, categoryArg(txn.CategoryID), txn.UserID}
+	}
+	return rows
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Observer is told how long a call of a Repository method took.
+type Observer func(method string, duration time.Duration)
+
+// Instrumented wraps repo so that every call, including those made on the
+// Repository handed out by WithTx, is reported to observe.
+func Instrumented(repo Repository, observe Observer) Repository {
+	return &instrumentedRepo{repo: repo, observe: observe}
+}
+
+type instrumentedRepo struct {
+	repo    Repository
+	observe Observer
+}
+
+func (r *instrumentedRepo) track(method string, start time.Time) {
+	r.observe(method, time.Since(start))
+}
+
+// WithTx reports the time the whole unit of work took as well as each call in it.
+func (r *instrumentedRepo) WithTx(ctx context.Context, fn func(Repository) error) error {
+	defer r.track("WithTx", time.Now())
+	return r.repo.WithTx(ctx, func(tx Repository) error {
+		return fn(&instrumentedRepo{repo: tx, observe: r.observe})
+	})
+}
+
+// importTransactions keeps the import of the wrapped repository available to
+// ApplyBulk.
+func (r *instrumentedRepo) importTransactions(ctx context.Context, userID int, txns []Transaction) ([]int, error) {
+	importer, ok := r.repo.(transactionImporter)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	defer r.track("importTransactions", time.Now())
+	return importer.importTransactions(ctx, userID, txns)
+}
+
+// Categories
+
+func (r *instrumentedRepo) CreateCategory(ctx context.Context, userID int, name string) (int, error) {
+	defer r.track("CreateCategory", time.Now())
+	return r.repo.CreateCategory(ctx, userID, name)
+}
+
+func (r *instrumentedRepo) GetCategories(ctx context.Context, userID int) ([]Category, error) {
+	defer r.track("GetCategories", time.Now())
+	return r.repo.GetCategories(ctx, userID)
+}
+
+func (r *instrumentedRepo) GetCategory(ctx context.Context, userID, categoryID int) (*Category, error) {
+	defer r.track("GetCategory", time.Now())
+	return r.repo.GetCategory(ctx, userID, categoryID)
+}
+
+func (r *instrumentedRepo) UpdateCategory(ctx context.Context, userID, categoryID int, name string, expectedVersion int) error {
+	defer r.track("UpdateCategory", time.Now())
+	return r.repo.UpdateCategory(ctx, userID, categoryID, name, expectedVersion)
+}
+
+func (r *instrumentedRepo) DeleteCategory(ctx context.Context, userID, categoryID int, strategy string, targetID, expectedVersion int) error {
+	defer r.track("DeleteCategory", time.Now())
+	return r.repo.DeleteCategory(ctx, userID, categoryID, strategy, targetID, expectedVersion)
+}
+
+func (r *instrumentedRepo) MergeCategories(ctx context.Context, userID, sourceID, targetID, expectedVersion int) error {
+	defer r.track("MergeCategories", time.Now())
+	return r.repo.MergeCategories(ctx, userID, sourceID, targetID, expectedVersion)
+}
+
+// Transactions
+
+func (r *instrumentedRepo) CreateTransaction(ctx context.Context, txn Transaction) (int, error) {
+	defer r.track("CreateTransaction", time.Now())
+	return r.repo.CreateTransaction(ctx, txn)
+}
+
+func (r *instrumentedRepo) GetTransactions(ctx context.Context, userID int) ([]Transaction, error) {
+	defer r.track("GetTransactions", time.Now())
+	return r.repo.GetTransactions(ctx, userID)
+}
+
+func (r *instrumentedRepo) GetTransaction(ctx context.Context, userID, txnID int) (*Transaction, error) {
+	defer r.track("GetTransaction", time.Now())
+	return r.repo.GetTransaction(ctx, userID, txnID)
+}
+
+func (r *instrumentedRepo) GetTransactionsFiltered(ctx context.Context, userID int, filter TransactionFilter) ([]Transaction, error) {
+	defer r.track("GetTransactionsFiltered", time.Now())
+	return r.repo.GetTransactionsFiltered(ctx, userID, filter)
+}
+
+func (r *instrumentedRepo) UpdateTransaction(ctx context.Context, txn Transaction) error {
+	defer r.track("UpdateTransaction", time.Now())
+	return r.repo.UpdateTransaction(ctx, txn)
+}
+
+func (r *instrumentedRepo) PatchTransaction(ctx context.Context, userID, txnID int, patch TransactionPatch, expectedVersion int) error {
+	defer r.track("PatchTransaction", time.Now())
+	return r.repo.PatchTransaction(ctx, userID, txnID, patch, expectedVersion)
+}
+
+func (r *instrumentedRepo) DeleteTransaction(ctx context.Context, userID, txnID, expectedVersion int) error {
+	defer r.track("DeleteTransaction", time.Now())
+	return r.repo.DeleteTransaction(ctx, userID, txnID, expectedVersion)
+}
+
+func (r *instrumentedRepo) RecategoriseTransaction(ctx context.Context, userID, txnID, categoryID, expectedVersion int) error {
+	defer r.track("RecategoriseTransaction", time.Now())
+	return r.repo.RecategoriseTransaction(ctx, userID, txnID, categoryID, expectedVersion)
+}
+
+// Transaction versions
+
+func (r *instrumentedRepo) GetTransactionHistory(ctx context.Context, userID, txnID int) (*TransactionHistory, error) {
+	defer r.track("GetTransactionHistory", time.Now())
+	return r.repo.GetTransactionHistory(ctx, userID, txnID)
+}
+
+func (r *instrumentedRepo) UndoTransaction(ctx context.Context, userID, txnID, expectedVersion, toVersion int) (int, error) {
+	defer r.track("UndoTransaction", time.Now())
+	return r.repo.UndoTransaction(ctx, userID, txnID, expectedVersion, toVersion)
+}
+
+// Transaction splits
+
+func (r *instrumentedRepo) GetSplits(ctx context.Context, userID, txnID int) ([]Split, error) {
+	defer r.track("GetSplits", time.Now())
+	return r.repo.GetSplits(ctx, userID, txnID)
+}
+
+func (r *instrumentedRepo) SetSplits(ctx context.Context, userID, txnID int, splits []Split, expectedVersion int) error {
+	defer r.track("SetSplits", time.Now())
+	return r.repo.SetSplits(ctx, userID, txnID, splits, expectedVersion)
+}
+
+func (r *instrumentedRepo) DeleteSplits(ctx context.Context, userID, txnID, expectedVersion int) error {
+	defer r.track("DeleteSplits", time.Now())
+	return r.repo.DeleteSplits(ctx, userID, txnID, expectedVersion)
+}
+
+// Attachments
+
+func (r *instrumentedRepo) CreateAttachment(ctx context.Context, a Attachment) (int, error) {
+	defer r.track("CreateAttachment", time.Now())
+	return r.repo.CreateAttachment(ctx, a)
+}
+
+func (r *instrumentedRepo) GetAttachments(ctx context.Context, userID, txnID int) ([]Attachment, error) {
+	defer r.track("GetAttachments", time.Now())
+	return r.repo.GetAttachments(ctx, userID, txnID)
+}
+
+func (r *instrumentedRepo) GetAttachment(ctx context.Context, userID, attachmentID int) (*Attachment, error) {
+	defer r.track("GetAttachment", time.Now())
+	return r.repo.GetAttachment(ctx, userID, attachmentID)
+}
+
+func (r *instrumentedRepo) DeleteAttachment(ctx context.Context, userID, attachmentID int) error {
+	defer r.track("DeleteAttachment", time.Now())
+	return r.repo.DeleteAttachment(ctx, userID, attachmentID)
+}
+
+// Tags
+
+func (r *instrumentedRepo) CreateTag(ctx context.Context, userID int, name string) (int, error) {
+	defer r.track("CreateTag", time.Now())
+	return r.repo.CreateTag(ctx, userID, name)
+}
+
+func (r *instrumentedRepo) GetTags(ctx context.Context, userID int) ([]Tag, error) {
+	defer r.track("GetTags", time.Now())
+	return r.repo.GetTags(ctx, userID)
+}
+
+func (r *instrumentedRepo) UpdateTag(ctx context.Context, userID, tagID int, name string, expectedVersion int) error {
+	defer r.track("UpdateTag", time.Now())
+	return r.repo.UpdateTag(ctx, userID, tagID, name, expectedVersion)
+}
+
+func (r *instrumentedRepo) DeleteTag(ctx context.Context, userID, tagID, expectedVersion int) error {
+	defer r.track("DeleteTag", time.Now())
+	return r.repo.DeleteTag(ctx, userID, tagID, expectedVersion)
+}
+
+func (r *instrumentedRepo) SetTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, expectedVersion int) error {
+	defer r.track("SetTransactionTags", time.Now())
+	return r.repo.SetTransactionTags(ctx, userID, txnID, tagIDs, expectedVersion)
+}
+
+// Trash
+
+func (r *instrumentedRepo) GetTrash(ctx context.Context, userID int) (*Trash, error) {
+	defer r.track("GetTrash", time.Now())
+	return r.repo.GetTrash(ctx, userID)
+}
+
+func (r *instrumentedRepo) RestoreTransaction(ctx context.Context, userID, txnID int) error {
+	defer r.track("RestoreTransaction", time.Now())
+	return r.repo.RestoreTransaction(ctx, userID, txnID)
+}
+
+func (r *instrumentedRepo) RestoreCategory(ctx context.Context, userID, categoryID int) error {
+	defer r.track("RestoreCategory", time.Now())
+	return r.repo.RestoreCategory(ctx, userID, categoryID)
+}
+
+func (r *instrumentedRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]Attachment, error) {
+	defer r.track("PurgeDeleted", time.Now())
+	return r.repo.PurgeDeleted(ctx, before)
+}
+
+// Audit log
+
+func (r *instrumentedRepo) GetAuditLog(ctx context.Context, userID int, filter AuditFilter) ([]AuditEntry, error) {
+	defer r.track("GetAuditLog", time.Now())
+	return r.repo.GetAuditLog(ctx, userID, filter)
+}
+
+func (r *instrumentedRepo) GetTransactionAudit(ctx context.Context, userID, txnID int) ([]AuditEntry, error) {
+	defer r.track("GetTransactionAudit", time.Now())
+	return r.repo.GetTransactionAudit(ctx, userID, txnID)
+}
+
+// Analytics
+
+func (r *instrumentedRepo) GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error) {
+	defer r.track("GetIncomeAndExpenses", time.Now())
+	return r.repo.GetIncomeAndExpenses(ctx, userID)
+}
+
+func (r *instrumentedRepo) GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryAnalytics, error) {
+	defer r.track("GetCategoryAnalytics", time.Now())
+	return r.repo.GetCategoryAnalytics(ctx, userID)
+}
+
+func (r *instrumentedRepo) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, from, to time.Time) (*Analytics, error) {
+	defer r.track("GetIncomeAndExpensesFiltered", time.Now())
+	return r.repo.GetIncomeAndExpensesFiltered(ctx, userID, from, to)
+}
+
+func (r *instrumentedRepo) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]CategoryAnalytics, error) {
+	defer r.track("GetCategoryAnalyticsFiltered", time.Now())
+	return r.repo.GetCategoryAnalyticsFiltered(ctx, userID, from, to)
+}
+
+func (r *instrumentedRepo) GetTagAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]TagAnalytics, error) {
+	defer r.track("GetTagAnalyticsFiltered", time.Now())
+	return r.repo.GetTagAnalyticsFiltered(ctx, userID, from, to)
+}
+
+// User methods
+
+func (r *instrumentedRepo) CreateUser(ctx context.Context, username, hashedPassword string) (int, error) {
+	defer r.track("CreateUser", time.Now())
+	return r.repo.CreateUser(ctx, username, hashedPassword)
+}
+
+func (r *instrumentedRepo) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	defer r.track("GetUserByUsername", time.Now())
+	return r.repo.GetUserByUsername(ctx, username)
+}
+
+func (r *instrumentedRepo) GetUserTimezone(ctx context.Context, userID int) (string, error) {
+	defer r.track("GetUserTimezone", time.Now())
+	return r.repo.GetUserTimezone(ctx, userID)
+}
+
+func (r *instrumentedRepo) SetUserTimezone(ctx context.Context, userID int, timezone string) error {
+	defer r.track("SetUserTimezone", time.Now())
+	return r.repo.SetUserTimezone(ctx, userID, timezone)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is an Observer that remembers the methods it was told about.
+type recorder struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (r *recorder) observe(method string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methods = append(r.methods, method)
+}
+
+func TestInstrumented(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	repo := Instrumented(NewMemoryRepo(), rec.observe)
+
+	userID, err := repo.CreateUser(ctx, "alice", "hash")
+	assert.NoError(t, err)
+
+	// Calls through the transaction are reported once they return, before
+	// the transaction itself
+	err = repo.WithTx(ctx, func(tx Repository) error {
+		_, err := tx.CreateCategory(ctx, userID, "Groceries")
+		return err
+	})
+	assert.NoError(t, err)
+
+	categories, err := repo.GetCategories(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, categories, 1)
+
+	assert.Equal(t, []string{"CreateUser", "CreateCategory", "WithTx", "GetCategories"}, rec.methods)
+}
+
+func TestInstrumentedImport(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+	rec := &recorder{}
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// ApplyBulk still takes the import of the wrapped DB
+	mock.ExpectBegin()
+	mock.ExpectBatch().ExpectQuery("INSERT INTO transactions").
+		WithArgs(-12.5, date, "Lunch", 3, 1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(41))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, []int{41}, "").
+		WillReturnResult(pgconn.NewCommandTag("INSERT 0 1"))
+	mock.ExpectCommit()
+
+	results, err := ApplyBulk(ctx, Instrumented(db, rec.observe), 1, []BulkOperation{
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: -12.5, Date: date, Description: "Lunch", CategoryID: 3}},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []BulkResult{{Index: 0, Op: BulkOpCreate, ID: 41}}, results)
+	assert.Equal(t, []string{"importTransactions"}, rec.methods)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,805 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Repository = (*MemoryRepo)(nil)
+
+// MemoryRepo is a Repository that keeps every record in memory. It follows the
+// behaviour of DB, including versions, the trash, the audit log and the typed
+// errors, so handlers can be exercised end to end without Postgres. It is safe
+// for concurrent use: reads run in parallel, writes and WithTx are serialised.
+type MemoryRepo struct {
+	mu   *sync.RWMutex
+	data *memoryData
+
+	// inTx is set on copies handed to the callback of WithTx, which already
+	// hold mu and journal their changes to data.
+	inTx bool
+}
+
+// NewMemoryRepo returns an empty in-memory repository.
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{mu: &sync.RWMutex{}, data: newMemoryData()}
+}
+
+// memoryData holds the tables. Writes change them in place through setRow,
+// deleteRow, nextID and appendAudit, which journal how to revert the change.
+// Maps store values, and slices stored in them are never modified in place,
+// so restoring the previous value of a row is enough to revert it.
+type memoryData struct {
+	seq          map[string]int
+	users        map[int]memoryUser
+	categories   map[int]memoryCategory
+	transactions map[int]Transaction
+	versions     map[int][]TransactionVersion
+	splits       map[int]Split
+	tags         map[int]memoryTag
+	txnTags      map[int][]int
+	attachments  map[int]Attachment
+	audit        []AuditEntry
+
+	// undo reverts the changes of the running write, newest last
+	undo []func()
+}
+
+type memoryUser struct {
+	User
+	Timezone  string
+	createdAt time.Time
+}
+
+type memoryCategory struct {
+	Category
+	UserID int
+}
+
+type memoryTag struct {
+	Tag
+	UserID int
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		seq:          map[string]int{},
+		users:        map[int]memoryUser{},
+		categories:   map[int]memoryCategory{},
+		transactions: map[int]Transaction{},
+		versions:     map[int][]TransactionVersion{},
+		splits:       map[int]Split{},
+		tags:         map[int]memoryTag{},
+		txnTags:      map[int][]int{},
+		attachments:  map[int]Attachment{},
+	}
+}
+
+// setRow stores v under id in table.
+func setRow[V any](d *memoryData, table map[int]V, id int, v V) {
+	journal(d, table, id)
+	table[id] = v
+}
+
+// deleteRow removes id from table.
+func deleteRow[V any](d *memoryData, table map[int]V, id int) {
+	if _, ok := table[id]; ok {
+		journal(d, table, id)
+		delete(table, id)
+	}
+}
+
+// journal remembers the row id of table as it is before a change.
+func journal[V any](d *memoryData, table map[int]V, id int) {
+	old, ok := table[id]
+	d.undo = append(d.undo, func() {
+		if ok {
+			table[id] = old
+		} else {
+			delete(table, id)
+		}
+	})
+}
+
+func (d *memoryData) nextID(table string) int {
+	old := d.seq[table]
+	d.undo = append(d.undo, func() { d.seq[table] = old })
+	d.seq[table]++
+	return d.seq[table]
+}
+
+// commit forgets the journal once a write is kept.
+func (d *memoryData) commit() {
+	d.undo = d.undo[:0]
+}
+
+// rollback reverts every journalled change, newest first.
+func (d *memoryData) rollback() {
+	for i := len(d.undo) - 1; i >= 0; i-- {
+		d.undo[i]()
+	}
+	d.undo = d.undo[:0]
+}
+
+// read runs fn against the committed state, or the state of the open WithTx.
+func (m *MemoryRepo) read(fn func(d *memoryData) error) error {
+	if m.inTx {
+		return fn(m.data)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fn(m.data)
+}
+
+// write runs fn against the state and reverts its changes unless fn
+// succeeds, so every method is atomic like its SQL counterpart. Inside WithTx
+// the changes are reverted with those of the whole callback.
+func (m *MemoryRepo) write(fn func(d *memoryData) error) error {
+	if m.inTx {
+		return fn(m.data)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data.atomically(func() error { return fn(m.data) })
+}
+
+// WithTx runs fn with writes excluded and reverts its changes if fn fails.
+// Nested calls join the open one. fn must only use the Repository it is
+// given; calling the outer MemoryRepo would deadlock.
+func (m *MemoryRepo) WithTx(ctx context.Context, fn func(Repository) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &MemoryRepo{mu: m.mu, data: m.data, inTx: true}
+	return m.data.atomically(func() error { return fn(tx) })
+}
+
+// atomically keeps the changes of fn only if it returns nil, reverting them
+// on errors and panics alike.
+func (d *memoryData) atomically(fn func() error) (err error) {
+	kept := false
+	defer func() {
+		if kept {
+			d.commit()
+		} else {
+			d.rollback()
+		}
+	}()
+	err = fn()
+	kept = err == nil
+	return err
+}
+
+// Helpers
+
+// cents rounds an amount the way the NUMERIC(10, 2) columns store it.
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// nullID maps the zero ID to null in audit documents, like a NULL column.
+func nullID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func sortedIDs[V any](records map[int]V) []int {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Audit log
+
+// snapshot returns the audited document of an entity, or nil if it does not
+// exist. Documents carry the same members as the auditSnapshots of DB.
+func (d *memoryData) snapshot(entity string, id int) []byte {
+	var doc any
+	switch entity {
+	case EntityUser:
+		u, ok := d.users[id]
+		if !ok {
+			return nil
+		}
+		doc = struct {
+			ID        int       `json:"id"`
+			Username  string    `json:"username"`
+			Timezone  string    `json:"timezone"`
+			CreatedAt time.Time `json:"created_at"`
+		}{u.ID, u.Username, u.Timezone, u.createdAt}
+	case EntityCategory:
+		c, ok := d.categories[id]
+		if !ok {
+			return nil
+		}
+		doc = struct {
+			ID        int        `json:"id"`
+			Name      string     `json:"name"`
+			UserID    int        `json:"user_id"`
+			DeletedAt *time.Time `json:"deleted_at"`
+			Version   int        `json:"version"`
+		}{c.ID, c.Name, c.UserID, c.DeletedAt, c.Version}
+	case EntityTransaction:
+		t, ok := d.transactions[id]
+		if !ok {
+			return nil
+		}
+		doc = transactionDoc(t)
+	case EntitySplit:
+		s, ok := d.splits[id]
+		if !ok {
+			return nil
+		}
+		doc = struct {
+			ID            int     `json:"id"`
+			TransactionID int     `json:"transaction_id"`
+			CategoryID    *int    `json:"category_id"`
+			Amount        float64 `json:"amount"`
+			Note          string  `json:"note"`
+		}{s.ID, s.TransactionID, nullID(s.CategoryID), s.Amount, s.Note}
+	case EntityTag:
+		g, ok := d.tags[id]
+		if !ok {
+			return nil
+		}
+		doc = struct {
+			ID     int    `json:"id"`
+			Name   string `json:"name"`
+			UserID int    `json:"user_id"`
+		}{g.ID, g.Name, g.UserID}
+	case EntityTransactionTags:
+		tagIDs := d.txnTags[id]
+		if tagIDs == nil {
+			tagIDs = []int{}
+		}
+		doc = tagIDs
+	case EntityAttachment:
+		a, ok := d.attachments[id]
+		if !ok {
+			return nil
+		}
+		doc = struct {
+			ID            int       `json:"id"`
+			TransactionID int       `json:"transaction_id"`
+			Filename      string    `json:"filename"`
+			ContentType   string    `json:"content_type"`
+			Size          int64     `json:"size"`
+			CreatedAt     time.Time `json:"created_at"`
+		}{a.ID, a.TransactionID, a.Filename, a.ContentType, a.Size, a.CreatedAt}
+	default:
+		return nil
+	}
+
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+func transactionDoc(t Transaction) any {
+	return struct {
+		ID          int        `json:"id"`
+		Amount      float64    `json:"amount"`
+		Date        time.Time  `json:"date"`
+		Description string     `json:"description"`
+		CategoryID  *int       `json:"category_id"`
+		UserID      int        `json:"user_id"`
+		DeletedAt   *time.Time `json:"deleted_at"`
+		Version     int        `json:"version"`
+	}{t.ID, t.Amount, t.Date, t.Description, nullID(t.CategoryID), t.UserID, t.DeletedAt, t.Version}
+}
+
+// audited runs change and records how it affected entity entityID, owned by
+// userID, in the audit log.
+func (d *memoryData) audited(ctx context.Context, userID int, entity string, entityID int, action string, change func() error) error {
+	before := d.snapshot(entity, entityID)
+	if err := change(); err != nil {
+		return err
+	}
+	d.recordChange(ctx, userID, entity, entityID, action, before, d.snapshot(entity, entityID))
+	return nil
+}
+
+func (d *memoryData) recordCreate(ctx context.Context, userID int, entity string, entityID int) {
+	d.recordChange(ctx, userID, entity, entityID, AuditCreate, nil, d.snapshot(entity, entityID))
+}
+
+// recordChange appends an entry made by userID to the audit log. Changes that
+// left the document untouched are not recorded.
+func (d *memoryData) recordChange(ctx context.Context, userID int, entity string, entityID int, action string, before, after []byte) {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		return
+	}
+	actorID := userID
+	d.appendAudit(AuditEntry{
+		UserID:    userID,
+		ActorID:   &actorID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Before:    before,
+		After:     after,
+		RequestID: requestIDFromContext(ctx),
+	})
+}
+
+func (d *memoryData) appendAudit(e AuditEntry) {
+	e.ID = int64(d.nextID("audit_log"))
+	e.CreatedAt = time.Now()
+	n := len(d.audit)
+	d.undo = append(d.undo, func() { d.audit = d.audit[:n] })
+	d.audit = append(d.audit, e)
+}
+
+func (m *MemoryRepo) GetAuditLog(ctx context.Context, userID int, filter AuditFilter) ([]AuditEntry, error) {
+	entries := []AuditEntry{}
+	err := m.read(func(d *memoryData) error {
+		skipped := 0
+		for i := len(d.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+			e := d.audit[i]
+			switch {
+			case e.UserID != userID,
+				filter.Entity != "" && e.Entity != filter.Entity,
+				filter.EntityID != 0 && e.EntityID != filter.EntityID,
+				filter.Action != "" && e.Action != filter.Action,
+				filter.RequestID != "" && e.RequestID != filter.RequestID,
+				!filter.From.IsZero() && e.CreatedAt.Before(filter.From),
+				!filter.To.IsZero() && !e.CreatedAt.Before(filter.To):
+				continue
+			}
+			if skipped < filter.Offset {
+				skipped++
+				continue
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// GetTransactionAudit returns the audit entries of a transaction together with
+// those of its splits, tags and attachments, newest first.
+func (m *MemoryRepo) GetTransactionAudit(ctx context.Context, userID, txnID int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := m.read(func(d *memoryData) error {
+		entries = d.transactionAudit(userID, txnID)
+		return nil
+	})
+	return entries, err
+}
+
+func (d *memoryData) transactionAudit(userID, txnID int) []AuditEntry {
+	entries := []AuditEntry{}
+	for i := len(d.audit) - 1; i >= 0; i-- {
+		e := d.audit[i]
+		if e.UserID != userID {
+			continue
+		}
+		switch e.Entity {
+		case EntityTransaction, EntityTransactionTags:
+			if e.EntityID != txnID {
+				continue
+			}
+		case EntitySplit, EntityAttachment:
+			doc := e.After
+			if doc == nil {
+				doc = e.Before
+			}
+			var ref struct {
+				TransactionID int `json:"transaction_id"`
+			}
+			if json.Unmarshal(doc, &ref) != nil || ref.TransactionID != txnID {
+				continue
+			}
+		default:
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Users
+
+func (m *MemoryRepo) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
+	var id int
+	err := m.write(func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Username == username {
+				return &ConflictError{Entity: EntityUser, Reason: "username is already taken"}
+			}
+		}
+
+		id = d.nextID("users")
+		now := time.Now()
+		setRow(d, d.users, id, memoryUser{
+			User:      User{ID: id, Username: username, Password: passwordHash, CreatedAt: now.Format(time.RFC3339)},
+			Timezone:  "UTC",
+			createdAt: now,
+		})
+		d.recordCreate(ctx, id, EntityUser, id)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MemoryRepo) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user *User
+	err := m.read(func(d *memoryData) error {
+		for _, u := range d.users {
+			if u.Username == username {
+				found := u.User
+				user = &found
+			}
+		}
+		return nil
+	})
+	return user, err
+}
+
+func (m *MemoryRepo) GetUserTimezone(ctx context.Context, userID int) (string, error) {
+	var timezone string
+	err := m.read(func(d *memoryData) error {
+		u, ok := d.users[userID]
+		if !ok {
+			return fmt.Errorf("failed to get user timezone: %w", &NotFoundError{Entity: EntityUser})
+		}
+		timezone = u.Timezone
+		return nil
+	})
+	return timezone, err
+}
+
+func (m *MemoryRepo) SetUserTimezone(ctx context.Context, userID int, timezone string) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityUser, userID, AuditUpdate, func() error {
+			u, ok := d.users[userID]
+			if !ok {
+				return &NotFoundError{Entity: EntityUser}
+			}
+			u.Timezone = timezone
+			setRow(d, d.users, userID, u)
+			return nil
+		})
+	})
+}
+
+// Analytics
+
+func (m *MemoryRepo) GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error) {
+	return m.incomeAndExpenses(userID, time.Time{}, time.Time{})
+}
+
+// GetIncomeAndExpensesFiltered only counts transactions dated within [from, to).
+func (m *MemoryRepo) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, from, to time.Time) (*Analytics, error) {
+	return m.incomeAndExpenses(userID, from, to)
+}
+
+func (m *MemoryRepo) incomeAndExpenses(userID int, from, to time.Time) (*Analytics, error) {
+	analytics := &Analytics{}
+	err := m.read(func(d *memoryData) error {
+		for _, t := range d.transactions {
+			if !d.counted(t, userID, from, to) {
+				continue
+			}
+			if t.Amount > 0 {
+				analytics.TotalIncome += t.Amount
+			} else {
+				analytics.TotalExpense += t.Amount
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	analytics.TotalIncome = cents(analytics.TotalIncome)
+	analytics.TotalExpense = cents(analytics.TotalExpense)
+	return analytics, nil
+}
+
+func (m *MemoryRepo) GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryAnalytics, error) {
+	return m.categoryAnalytics(userID, time.Time{}, time.Time{})
+}
+
+// GetCategoryAnalyticsFiltered only counts transactions dated within [from, to).
+func (m *MemoryRepo) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]CategoryAnalytics, error) {
+	return m.categoryAnalytics(userID, from, to)
+}
+
+// categoryAnalytics sums split lines for split transactions and the
+// transaction itself otherwise, per name of an active category.
+func (m *MemoryRepo) categoryAnalytics(userID int, from, to time.Time) ([]CategoryAnalytics, error) {
+	totals := map[string]float64{}
+	err := m.read(func(d *memoryData) error {
+		add := func(categoryID int, amount float64) {
+			if c, ok := d.categories[categoryID]; ok && c.DeletedAt == nil {
+				totals[c.Name] += amount
+			}
+		}
+
+		splits := map[int][]Split{}
+		for _, s := range d.splits {
+			splits[s.TransactionID] = append(splits[s.TransactionID], s)
+		}
+		for _, t := range d.transactions {
+			if !d.counted(t, userID, from, to) {
+				continue
+			}
+			if lines, ok := splits[t.ID]; ok {
+				for _, s := range lines {
+					add(s.CategoryID, s.Amount)
+				}
+				continue
+			}
+			add(t.CategoryID, t.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var analytics []CategoryAnalytics
+	for name, total := range totals {
+		analytics = append(analytics, CategoryAnalytics{CategoryName: name, TotalAmount: cents(total)})
+	}
+	sort.Slice(analytics, func(i, j int) bool {
+		a, b := analytics[i], analytics[j]
+		return a.TotalAmount > b.TotalAmount || a.TotalAmount == b.TotalAmount && a.CategoryName < b.CategoryName
+	})
+	return analytics, nil
+}
+
+// GetTagAnalyticsFiltered only counts transactions dated within [from, to).
+func (m *MemoryRepo) GetTagAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]TagAnalytics, error) {
+	totals := map[string]float64{}
+	err := m.read(func(d *memoryData) error {
+		for _, t := range d.transactions {
+			if !d.counted(t, userID, from, to) {
+				continue
+			}
+			for _, tagID := range d.txnTags[t.ID] {
+				totals[d.tags[tagID].Name] += t.Amount
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var analytics []TagAnalytics
+	for name, total := range totals {
+		analytics = append(analytics, TagAnalytics{TagName: name, TotalAmount: cents(total)})
+	}
+	sort.Slice(analytics, func(i, j int) bool {
+		a, b := analytics[i], analytics[j]
+		return a.TotalAmount > b.TotalAmount || a.TotalAmount == b.TotalAmount && a.TagName < b.TagName
+	})
+	return analytics, nil
+}
+
+// counted reports whether an active transaction of userID falls within
+// [from, to); zero bounds are open.
+func (d *memoryData) counted(t Transaction, userID int, from, to time.Time) bool {
+	if t.UserID != userID || t.DeletedAt != nil {
+		return false
+	}
+	if !from.IsZero() && t.Date.Before(from) {
+		return false
+	}
+	return to.IsZero() || t.Date.Before(to)
+}
+
+// Trash
+
+func (m *MemoryRepo) GetTrash(ctx context.Context, userID int) (*Trash, error) {
+	trash := &Trash{Transactions: []Transaction{}, Categories: []Category{}}
+	err := m.read(func(d *memoryData) error {
+		for _, id := range sortedIDs(d.transactions) {
+			if t := d.transactions[id]; t.UserID == userID && t.DeletedAt != nil {
+				trash.Transactions = append(trash.Transactions, t)
+			}
+		}
+		for _, id := range sortedIDs(d.categories) {
+			if c := d.categories[id]; c.UserID == userID && c.DeletedAt != nil {
+				trash.Categories = append(trash.Categories, c.Category)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(trash.Transactions, func(i, j int) bool {
+		return trash.Transactions[i].DeletedAt.After(*trash.Transactions[j].DeletedAt)
+	})
+	sort.SliceStable(trash.Categories, func(i, j int) bool {
+		return trash.Categories[i].DeletedAt.After(*trash.Categories[j].DeletedAt)
+	})
+	return trash, nil
+}
+
+// RestoreTransaction moves a transaction out of the trash. It fails with a
+// *ConflictError while its category, or the category of one of its split
+// lines, is still in the trash.
+func (m *MemoryRepo) RestoreTransaction(ctx context.Context, userID, txnID int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTransaction, txnID, AuditRestore, func() error {
+			t, ok := d.transactions[txnID]
+			if !ok || t.UserID != userID || t.DeletedAt == nil {
+				return &NotFoundError{Entity: "deleted transaction"}
+			}
+			categoryIDs := []int{t.CategoryID}
+			for _, s := range d.splits {
+				if s.TransactionID == txnID {
+					categoryIDs = append(categoryIDs, s.CategoryID)
+				}
+			}
+			for _, id := range categoryIDs {
+				if c, ok := d.categories[id]; ok && c.DeletedAt != nil {
+					return errCategoryInTrash
+				}
+			}
+			d.bumpTransaction(txnID, func(t *Transaction) { t.DeletedAt = nil })
+			return nil
+		})
+	})
+}
+
+func (m *MemoryRepo) RestoreCategory(ctx context.Context, userID, categoryID int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityCategory, categoryID, AuditRestore, func() error {
+			c, ok := d.categories[categoryID]
+			if !ok || c.UserID != userID || c.DeletedAt == nil {
+				return &NotFoundError{Entity: "deleted category"}
+			}
+			c.DeletedAt = nil
+			c.Version++
+			setRow(d, d.categories, categoryID, c)
+			return nil
+		})
+	})
+}
+
+// PurgeDeleted permanently removes transactions and categories of all users
+// that were moved to the trash before the cutoff, recording their attachments,
+// split lines and tags in the audit log too. Categories still used by a
+// remaining transaction or split line stay in the trash until it is purged.
+// It returns the attachments of the purged transactions so their files can be
+// removed from storage.
+func (m *MemoryRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]Attachment, error) {
+	var attachments []Attachment
+	err := m.write(func(d *memoryData) error {
+		for _, id := range sortedIDs(d.transactions) {
+			t := d.transactions[id]
+			if t.DeletedAt == nil || !t.DeletedAt.Before(before) {
+				continue
+			}
+			for _, attachmentID := range sortedIDs(d.attachments) {
+				if a := d.attachments[attachmentID]; a.TransactionID == id {
+					attachments = append(attachments, a)
+					d.recordPurge(t.UserID, EntityAttachment, attachmentID)
+				}
+			}
+			for _, splitID := range sortedIDs(d.splits) {
+				if d.splits[splitID].TransactionID == id {
+					d.recordPurge(t.UserID, EntitySplit, splitID)
+				}
+			}
+			if len(d.txnTags[id]) > 0 {
+				d.recordPurge(t.UserID, EntityTransactionTags, id)
+			}
+			d.recordPurge(t.UserID, EntityTransaction, id)
+			d.removeTransaction(id)
+		}
+
+		for _, id := range sortedIDs(d.categories) {
+			c := d.categories[id]
+			if c.DeletedAt == nil || !c.DeletedAt.Before(before) || d.categoryReferenced(id) {
+				continue
+			}
+			doc, _ := json.Marshal(struct {
+				ID        int        `json:"id"`
+				Name      string     `json:"name"`
+				UserID    int        `json:"user_id"`
+				DeletedAt *time.Time `json:"deleted_at"`
+			}{c.ID, c.Name, c.UserID, c.DeletedAt})
+			d.appendAudit(AuditEntry{
+				UserID:   c.UserID,
+				Entity:   EntityCategory,
+				EntityID: id,
+				Action:   AuditPurge,
+				Before:   doc,
+			})
+			d.removeCategory(id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// removeTransaction deletes a transaction together with the rows that
+// reference it, like the ON DELETE CASCADE foreign keys.
+func (d *memoryData) removeTransaction(id int) {
+	deleteRow(d, d.transactions, id)
+	deleteRow(d, d.versions, id)
+	deleteRow(d, d.txnTags, id)
+	for splitID, s := range d.splits {
+		if s.TransactionID == id {
+			deleteRow(d, d.splits, splitID)
+		}
+	}
+	for attachmentID, a := range d.attachments {
+		if a.TransactionID == id {
+			deleteRow(d, d.attachments, attachmentID)
+		}
+	}
+}
+
+// recordPurge records a row removed by PurgeDeleted, which has no actor.
+func (d *memoryData) recordPurge(userID int, entity string, id int) {
+	d.appendAudit(AuditEntry{
+		UserID:   userID,
+		Entity:   entity,
+		EntityID: id,
+		Action:   AuditPurge,
+		Before:   d.snapshot(entity, id),
+	})
+}
+
+// categoryReferenced reports whether a transaction or split line of any user
+// still uses categoryID.
+func (d *memoryData) categoryReferenced(categoryID int) bool {
+	for _, t := range d.transactions {
+		if t.CategoryID == categoryID {
+			return true
+		}
+	}
+	for _, s := range d.splits {
+		if s.CategoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCategory deletes a category and clears references to it, like the
+// ON DELETE SET NULL foreign keys.
+func (d *memoryData) removeCategory(id int) {
+	deleteRow(d, d.categories, id)
+	for txnID, t := range d.transactions {
+		if t.CategoryID == id {
+			t.CategoryID = 0
+			setRow(d, d.transactions, txnID, t)
+		}
+	}
+	for splitID, s := range d.splits {
+		if s.CategoryID == id {
+			s.CategoryID = 0
+			setRow(d, d.splits, splitID, s)
+		}
+	}
+}
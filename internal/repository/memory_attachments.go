@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// CreateAttachment records an attachment for a transaction owned by a.UserID.
+func (m *MemoryRepo) CreateAttachment(ctx context.Context, a Attachment) (int, error) {
+	var id int
+	err := m.write(func(d *memoryData) error {
+		if _, ok := d.activeTransaction(a.UserID, a.TransactionID); !ok {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+
+		id = d.nextID("attachments")
+		a.ID = id
+		a.CreatedAt = time.Now()
+		setRow(d, d.attachments, id, a)
+		d.recordCreate(ctx, a.UserID, EntityAttachment, id)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MemoryRepo) GetAttachments(ctx context.Context, userID, txnID int) ([]Attachment, error) {
+	var attachments []Attachment
+	err := m.read(func(d *memoryData) error {
+		if _, ok := d.activeTransaction(userID, txnID); !ok {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+		for _, id := range sortedIDs(d.attachments) {
+			if a := d.attachments[id]; a.TransactionID == txnID && a.UserID == userID {
+				attachments = append(attachments, a)
+			}
+		}
+		return nil
+	})
+	return attachments, err
+}
+
+// GetAttachment returns the attachment or nil if it does not exist or belongs to another user.
+func (m *MemoryRepo) GetAttachment(ctx context.Context, userID, attachmentID int) (*Attachment, error) {
+	var attachment *Attachment
+	err := m.read(func(d *memoryData) error {
+		if a, ok := d.attachments[attachmentID]; ok && a.UserID == userID {
+			attachment = &a
+		}
+		return nil
+	})
+	return attachment, err
+}
+
+func (m *MemoryRepo) DeleteAttachment(ctx context.Context, userID, attachmentID int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityAttachment, attachmentID, AuditDelete, func() error {
+			a, ok := d.attachments[attachmentID]
+			if !ok || a.UserID != userID {
+				return &NotFoundError{Entity: EntityAttachment}
+			}
+			deleteRow(d, d.attachments, attachmentID)
+			return nil
+		})
+	})
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (m *MemoryRepo) CreateCategory(ctx context.Context, userID int, name string) (int, error) {
+	var id int
+	err := m.write(func(d *memoryData) error {
+		id = d.nextID("categories")
+		setRow(d, d.categories, id, memoryCategory{Category: Category{ID: id, Name: name, Version: 1}, UserID: userID})
+		d.recordCreate(ctx, userID, EntityCategory, id)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MemoryRepo) GetCategories(ctx context.Context, userID int) ([]Category, error) {
+	var categories []Category
+	err := m.read(func(d *memoryData) error {
+		for _, id := range sortedIDs(d.categories) {
+			if c, ok := d.activeCategory(userID, id); ok {
+				categories = append(categories, c.Category)
+			}
+		}
+		return nil
+	})
+	return categories, err
+}
+
+// GetCategory returns an active category, or nil if it does not exist or
+// belongs to another user.
+func (m *MemoryRepo) GetCategory(ctx context.Context, userID, categoryID int) (*Category, error) {
+	var category *Category
+	err := m.read(func(d *memoryData) error {
+		if c, ok := d.activeCategory(userID, categoryID); ok {
+			category = &c.Category
+		}
+		return nil
+	})
+	return category, err
+}
+
+// UpdateCategory renames a category. A non-zero expectedVersion must match the
+// stored version, otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) UpdateCategory(ctx context.Context, userID, categoryID int, name string, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityCategory, categoryID, AuditUpdate, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityCategory, userID, categoryID, expectedVersion); err != nil {
+					return err
+				}
+			}
+
+			c, ok := d.activeCategory(userID, categoryID)
+			if !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+			c.Name = name
+			c.Version++
+			setRow(d, d.categories, categoryID, c)
+			return nil
+		})
+	})
+}
+
+// DeleteCategory moves a category to the trash, handling its transactions
+// according to strategy like DB.DeleteCategory.
+func (m *MemoryRepo) DeleteCategory(ctx context.Context, userID, categoryID int, strategy string, targetID, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityCategory, categoryID, AuditDelete, func() error {
+			if _, ok := d.activeCategory(userID, categoryID); !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityCategory, userID, categoryID, expectedVersion); err != nil {
+					return err
+				}
+			}
+
+			now := time.Now()
+			switch strategy {
+			case CategoryDeleteReassign:
+				if targetID == categoryID {
+					return invalid("target_id", "cannot reassign transactions to the deleted category")
+				}
+				if _, ok := d.activeCategory(userID, targetID); !ok {
+					return &NotFoundError{Entity: EntityCategory}
+				}
+				d.moveCategoryUsage(ctx, userID, categoryID, targetID)
+			case CategoryDeleteBlock:
+				if count := len(d.categoryUsage(userID, categoryID)); count > 0 {
+					return &CategoryInUseError{CategoryID: categoryID, Count: count}
+				}
+			case CategoryDeleteCascade:
+				if err := splitUsageConflict(d.splitUsage(userID, categoryID)); err != nil {
+					return err
+				}
+				for _, id := range d.categoryUsage(userID, categoryID) {
+					if t := d.transactions[id]; t.DeletedAt != nil || t.CategoryID != categoryID {
+						continue
+					}
+					d.audited(ctx, userID, EntityTransaction, id, AuditDelete, func() error {
+						d.bumpTransaction(id, func(t *Transaction) { t.DeletedAt = &now })
+						return nil
+					})
+				}
+			default:
+				return invalid("strategy", fmt.Sprintf("unknown delete strategy %q", strategy))
+			}
+
+			c := d.categories[categoryID]
+			c.DeletedAt = &now
+			c.Version++
+			setRow(d, d.categories, categoryID, c)
+			return nil
+		})
+	})
+}
+
+// MergeCategories moves every transaction and split line of sourceID, including
+// trashed ones, into targetID and moves sourceID to the trash. A non-zero
+// expectedVersion must match the stored version of sourceID, otherwise a
+// *VersionConflictError is returned.
+func (m *MemoryRepo) MergeCategories(ctx context.Context, userID, sourceID, targetID, expectedVersion int) error {
+	if sourceID == targetID {
+		return invalid("target_id", "cannot merge a category into itself")
+	}
+
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityCategory, sourceID, AuditDelete, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityCategory, userID, sourceID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			if _, ok := d.activeCategory(userID, sourceID); !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+			if _, ok := d.activeCategory(userID, targetID); !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+			d.moveCategoryUsage(ctx, userID, sourceID, targetID)
+
+			c := d.categories[sourceID]
+			now := time.Now()
+			c.DeletedAt = &now
+			c.Version++
+			setRow(d, d.categories, sourceID, c)
+			return nil
+		})
+	})
+}
+
+func (d *memoryData) activeCategory(userID, categoryID int) (memoryCategory, bool) {
+	c, ok := d.categories[categoryID]
+	if !ok || c.UserID != userID || c.DeletedAt != nil {
+		return memoryCategory{}, false
+	}
+	return c, true
+}
+
+// categoryUsage returns the transactions of userID, active or in the trash,
+// that use categoryID directly or through one of their split lines.
+func (d *memoryData) categoryUsage(userID, categoryID int) []int {
+	split := map[int]bool{}
+	for _, s := range d.splits {
+		if s.CategoryID == categoryID {
+			split[s.TransactionID] = true
+		}
+	}
+
+	var ids []int
+	for _, id := range sortedIDs(d.transactions) {
+		t := d.transactions[id]
+		if t.UserID == userID && (t.CategoryID == categoryID || split[id]) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// splitUsage returns the active transactions of userID that have split lines
+// in categoryID.
+func (d *memoryData) splitUsage(userID, categoryID int) []int {
+	var ids []int
+	for _, id := range sortedIDs(d.transactions) {
+		if _, ok := d.activeTransaction(userID, id); !ok {
+			continue
+		}
+		for _, s := range d.splits {
+			if s.TransactionID == id && s.CategoryID == categoryID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// moveCategoryUsage points all transactions and split lines of userID that use
+// fromID to toID, recording every changed row in the audit log.
+func (d *memoryData) moveCategoryUsage(ctx context.Context, userID, fromID, toID int) {
+	for _, id := range sortedIDs(d.transactions) {
+		if t := d.transactions[id]; t.UserID != userID || t.CategoryID != fromID {
+			continue
+		}
+		d.audited(ctx, userID, EntityTransaction, id, AuditUpdate, func() error {
+			d.bumpTransaction(id, func(t *Transaction) { t.CategoryID = toID })
+			return nil
+		})
+	}
+
+	for _, id := range sortedIDs(d.splits) {
+		s := d.splits[id]
+		if s.CategoryID != fromID || d.transactions[s.TransactionID].UserID != userID {
+			continue
+		}
+		d.audited(ctx, userID, EntitySplit, id, AuditUpdate, func() error {
+			s.CategoryID = toID
+			setRow(d, d.splits, id, s)
+			return nil
+		})
+	}
+}
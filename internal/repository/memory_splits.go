@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+)
+
+// GetSplits returns the split lines of a transaction, or a *NotFoundError if
+// the transaction does not exist or belongs to another user.
+func (m *MemoryRepo) GetSplits(ctx context.Context, userID, txnID int) ([]Split, error) {
+	var splits []Split
+	err := m.read(func(d *memoryData) error {
+		if _, ok := d.activeTransaction(userID, txnID); !ok {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+		for _, id := range sortedIDs(d.splits) {
+			if s := d.splits[id]; s.TransactionID == txnID {
+				splits = append(splits, s)
+			}
+		}
+		return nil
+	})
+	return splits, err
+}
+
+// SetSplits replaces the split lines of a transaction and bumps its version.
+// The amounts must sum to the transaction amount and every category must be an
+// active category of userID; an empty slice removes all splits. A non-zero
+// expectedVersion must match the stored version of the transaction, otherwise
+// a *VersionConflictError is returned.
+func (m *MemoryRepo) SetSplits(ctx context.Context, userID, txnID int, splits []Split, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		if expectedVersion != 0 {
+			if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		t, ok := d.activeTransaction(userID, txnID)
+		if !ok {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+
+		if len(splits) > 0 {
+			var total float64
+			for _, split := range splits {
+				total += split.Amount
+			}
+			if !sameAmount(total, t.Amount) {
+				return ErrSplitSumMismatch
+			}
+		}
+		for _, split := range splits {
+			if _, ok := d.activeCategory(userID, split.CategoryID); split.CategoryID != 0 && !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+		}
+		d.bumpTransaction(txnID, func(*Transaction) {})
+		d.writeSplits(ctx, userID, txnID, splits)
+		return nil
+	})
+}
+
+// writeSplits replaces the split lines of txnID without checking them,
+// recording every deleted and created line in the audit log.
+func (d *memoryData) writeSplits(ctx context.Context, userID, txnID int, splits []Split) {
+	for _, id := range sortedIDs(d.splits) {
+		if d.splits[id].TransactionID != txnID {
+			continue
+		}
+		before := d.snapshot(EntitySplit, id)
+		deleteRow(d, d.splits, id)
+		d.recordChange(ctx, userID, EntitySplit, id, AuditDelete, before, nil)
+	}
+
+	for _, split := range splits {
+		id := d.nextID("transaction_splits")
+		setRow(d, d.splits, id, Split{
+			ID:            id,
+			TransactionID: txnID,
+			CategoryID:    split.CategoryID,
+			Amount:        cents(split.Amount),
+			Note:          split.Note,
+		})
+		d.recordCreate(ctx, userID, EntitySplit, id)
+	}
+}
+
+// splitLines returns the split lines of txnID, whatever its state, ordered by ID.
+func (d *memoryData) splitLines(txnID int) []Split {
+	splits := []Split{}
+	for _, id := range sortedIDs(d.splits) {
+		if s := d.splits[id]; s.TransactionID == txnID {
+			splits = append(splits, s)
+		}
+	}
+	return splits
+}
+
+func (m *MemoryRepo) DeleteSplits(ctx context.Context, userID, txnID, expectedVersion int) error {
+	return m.SetSplits(ctx, userID, txnID, nil, expectedVersion)
+}
+
+// checkSplitTotal makes sure a new amount for txnID still matches its splits, if any.
+func (d *memoryData) checkSplitTotal(txnID int, amount float64) error {
+	count := 0
+	var total float64
+	for _, s := range d.splits {
+		if s.TransactionID == txnID {
+			count++
+			total += s.Amount
+		}
+	}
+	if count > 0 && !sameAmount(total, amount) {
+		return ErrSplitSumMismatch
+	}
+	return nil
+}
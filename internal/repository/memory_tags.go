@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"slices"
+)
+
+func (m *MemoryRepo) CreateTag(ctx context.Context, userID int, name string) (int, error) {
+	var id int
+	err := m.write(func(d *memoryData) error {
+		if d.tagNameTaken(userID, 0, name) {
+			return &ConflictError{Entity: EntityTag, Reason: "a tag with this name already exists"}
+		}
+
+		id = d.nextID("tags")
+		setRow(d, d.tags, id, memoryTag{Tag: Tag{ID: id, Name: name, Version: 1}, UserID: userID})
+		d.recordCreate(ctx, userID, EntityTag, id)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MemoryRepo) GetTags(ctx context.Context, userID int) ([]Tag, error) {
+	var tags []Tag
+	err := m.read(func(d *memoryData) error {
+		for _, id := range sortedIDs(d.tags) {
+			if g := d.tags[id]; g.UserID == userID {
+				tags = append(tags, g.Tag)
+			}
+		}
+		return nil
+	})
+	slices.SortStableFunc(tags, func(a, b Tag) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	return tags, err
+}
+
+// UpdateTag renames a tag. A non-zero expectedVersion must match the stored
+// version, otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) UpdateTag(ctx context.Context, userID, tagID int, name string, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTag, tagID, AuditUpdate, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityTag, userID, tagID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			g, ok := d.tags[tagID]
+			if !ok || g.UserID != userID {
+				return &NotFoundError{Entity: EntityTag}
+			}
+			if d.tagNameTaken(userID, tagID, name) {
+				return &ConflictError{Entity: EntityTag, Reason: "a tag with this name already exists"}
+			}
+			g.Name = name
+			g.Version++
+			setRow(d, d.tags, tagID, g)
+			return nil
+		})
+	})
+}
+
+// DeleteTag deletes a tag and removes it from every transaction, bumping the
+// version of the active ones. A non-zero expectedVersion must match the stored
+// version, otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) DeleteTag(ctx context.Context, userID, tagID, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTag, tagID, AuditDelete, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityTag, userID, tagID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			g, ok := d.tags[tagID]
+			if !ok || g.UserID != userID {
+				return &NotFoundError{Entity: EntityTag}
+			}
+			deleteRow(d, d.tags, tagID)
+
+			for _, txnID := range sortedIDs(d.txnTags) {
+				tagIDs := d.txnTags[txnID]
+				if !slices.Contains(tagIDs, tagID) {
+					continue
+				}
+				if _, ok := d.activeTransaction(userID, txnID); ok {
+					d.bumpTransaction(txnID, func(*Transaction) {})
+				}
+				var kept []int
+				for _, id := range tagIDs {
+					if id != tagID {
+						kept = append(kept, id)
+					}
+				}
+				d.setTags(txnID, kept)
+			}
+			return nil
+		})
+	})
+}
+
+// SetTransactionTags replaces the tags of a transaction and bumps its version
+// unless they stay the same. Both the transaction and every tag must belong to
+// userID. A non-zero expectedVersion must match the stored version of the
+// transaction, otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) SetTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		if expectedVersion != 0 {
+			if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+		return d.replaceTags(ctx, userID, txnID, tagIDs, true)
+	})
+}
+
+// replaceTags replaces the tags of an active transaction of userID and records
+// the change in the audit log. When bump is set and the tags change, the
+// version of the transaction is bumped first; otherwise the caller bumps it.
+func (d *memoryData) replaceTags(ctx context.Context, userID, txnID int, tagIDs []int, bump bool) error {
+	return d.audited(ctx, userID, EntityTransactionTags, txnID, AuditUpdate, func() error {
+		_, active := d.activeTransaction(userID, txnID)
+
+		var kept []int
+		for _, tagID := range tagIDs {
+			if slices.Contains(kept, tagID) {
+				continue
+			}
+			if g, ok := d.tags[tagID]; !active || !ok || g.UserID != userID {
+				return &NotFoundError{Entity: "transaction or tag"}
+			}
+			kept = append(kept, tagID)
+		}
+		slices.Sort(kept)
+		current := slices.Clone(d.txnTags[txnID])
+		slices.Sort(current)
+		if !active || slices.Equal(kept, current) {
+			return nil
+		}
+		if bump {
+			d.bumpTransaction(txnID, func(*Transaction) {})
+		}
+		d.setTags(txnID, kept)
+		return nil
+	})
+}
+
+func (d *memoryData) tagNameTaken(userID, exceptID int, name string) bool {
+	for id, g := range d.tags {
+		if id != exceptID && g.UserID == userID && g.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *memoryData) setTags(txnID int, tagIDs []int) {
+	if len(tagIDs) == 0 {
+		deleteRow(d, d.txnTags, txnID)
+		return
+	}
+	setRow(d, d.txnTags, txnID, tagIDs)
+}
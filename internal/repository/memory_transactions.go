@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// CreateTransaction inserts a transaction with the tags in txn.TagIDs, which
+// must belong to txn.UserID.
+func (m *MemoryRepo) CreateTransaction(ctx context.Context, txn Transaction) (int, error) {
+	var id int
+	err := m.write(func(d *memoryData) error {
+		id = d.nextID("transactions")
+		setRow(d, d.transactions, id, Transaction{
+			ID:          id,
+			Amount:      cents(txn.Amount),
+			Date:        txn.Date,
+			Description: txn.Description,
+			CategoryID:  txn.CategoryID,
+			UserID:      txn.UserID,
+			Version:     1,
+		})
+		d.recordCreate(ctx, txn.UserID, EntityTransaction, id)
+		if len(txn.TagIDs) > 0 {
+			return d.replaceTags(ctx, txn.UserID, id, txn.TagIDs, false)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *MemoryRepo) GetTransactions(ctx context.Context, userID int) ([]Transaction, error) {
+	return m.GetTransactionsFiltered(ctx, userID, TransactionFilter{})
+}
+
+// GetTransaction returns an active transaction with its tags, or nil if it does
+// not exist or belongs to another user.
+func (m *MemoryRepo) GetTransaction(ctx context.Context, userID, txnID int) (*Transaction, error) {
+	var txn *Transaction
+	err := m.read(func(d *memoryData) error {
+		if t, ok := d.activeTransaction(userID, txnID); ok {
+			t.TagIDs = slices.Clone(d.txnTags[txnID])
+			txn = &t
+		}
+		return nil
+	})
+	return txn, err
+}
+
+func (m *MemoryRepo) GetTransactionsFiltered(ctx context.Context, userID int, filter TransactionFilter) ([]Transaction, error) {
+	var transactions []Transaction
+	err := m.read(func(d *memoryData) error {
+		for _, id := range sortedIDs(d.transactions) {
+			t, ok := d.activeTransaction(userID, id)
+			if !ok || !d.matchesTags(id, filter) {
+				continue
+			}
+			t.TagIDs = slices.Clone(d.txnTags[id])
+			transactions = append(transactions, t)
+		}
+		return nil
+	})
+	return transactions, err
+}
+
+// matchesTags reports whether a transaction carries any of the filter tags, or
+// every one of them when MatchAllTags is set.
+func (d *memoryData) matchesTags(txnID int, filter TransactionFilter) bool {
+	tagIDs := filter.tagIDs()
+	if len(tagIDs) == 0 {
+		return true
+	}
+
+	matched := map[int]bool{}
+	for _, tagID := range d.txnTags[txnID] {
+		if slices.Contains(tagIDs, tagID) {
+			matched[tagID] = true
+		}
+	}
+	if filter.MatchAllTags {
+		return len(matched) == len(tagIDs)
+	}
+	return len(matched) > 0
+}
+
+// UpdateTransaction overwrites a transaction, keeping the stored date when
+// txn.Date is zero and the stored tags when txn.TagIDs is nil. A non-zero txn.Version must match the stored version,
+// otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) UpdateTransaction(ctx context.Context, txn Transaction) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, txn.UserID, EntityTransaction, txn.ID, AuditUpdate, func() error {
+			if txn.Version != 0 {
+				if err := d.checkVersion(EntityTransaction, txn.UserID, txn.ID, txn.Version); err != nil {
+					return err
+				}
+			}
+			if err := d.checkSplitTotal(txn.ID, txn.Amount); err != nil {
+				return err
+			}
+			if _, ok := d.activeTransaction(txn.UserID, txn.ID); !ok {
+				return &NotFoundError{Entity: EntityTransaction}
+			}
+
+			d.bumpTransaction(txn.ID, func(t *Transaction) {
+				t.Amount = cents(txn.Amount)
+				if !txn.Date.IsZero() {
+					t.Date = txn.Date
+				}
+				t.Description = txn.Description
+				t.CategoryID = txn.CategoryID
+			})
+			if txn.TagIDs != nil {
+				return d.replaceTags(ctx, txn.UserID, txn.ID, txn.TagIDs, false)
+			}
+			return nil
+		})
+	})
+}
+
+// PatchTransaction changes only the fields set in patch. A non-zero
+// expectedVersion must match the stored version, otherwise a
+// *VersionConflictError is returned. An empty patch changes nothing.
+func (m *MemoryRepo) PatchTransaction(ctx context.Context, userID, txnID int, patch TransactionPatch, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTransaction, txnID, AuditUpdate, func() error {
+			if _, ok := d.activeTransaction(userID, txnID); !ok {
+				return &NotFoundError{Entity: EntityTransaction}
+			}
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			if patch.Amount != nil {
+				if err := d.checkSplitTotal(txnID, *patch.Amount); err != nil {
+					return err
+				}
+			}
+			changed := patch.Amount != nil || patch.Date != nil || patch.Description != nil || patch.CategoryID != nil || patch.ClearCategory
+			if changed {
+				d.bumpTransaction(txnID, func(t *Transaction) {
+					if patch.Amount != nil {
+						t.Amount = cents(*patch.Amount)
+					}
+					if patch.Date != nil {
+						t.Date = *patch.Date
+					}
+					if patch.Description != nil {
+						t.Description = *patch.Description
+					}
+					if patch.ClearCategory {
+						t.CategoryID = 0
+					} else if patch.CategoryID != nil {
+						t.CategoryID = *patch.CategoryID
+					}
+				})
+			}
+
+			// Changing only the tags bumps the version on its own
+			if patch.TagIDs != nil {
+				return d.replaceTags(ctx, userID, txnID, patch.TagIDs, !changed)
+			}
+			return nil
+		})
+	})
+}
+
+// DeleteTransaction moves a transaction to the trash. A non-zero expectedVersion
+// must match the stored version, otherwise a *VersionConflictError is returned.
+func (m *MemoryRepo) DeleteTransaction(ctx context.Context, userID, txnID, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTransaction, txnID, AuditDelete, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			if _, ok := d.activeTransaction(userID, txnID); !ok {
+				return &NotFoundError{Entity: EntityTransaction}
+			}
+
+			now := time.Now()
+			d.bumpTransaction(txnID, func(t *Transaction) { t.DeletedAt = &now })
+			return nil
+		})
+	})
+}
+
+// RecategoriseTransaction moves a transaction to categoryID, which must be an
+// active category of userID, or clears its category when categoryID is 0. A
+// non-zero expectedVersion must match the stored version, otherwise a
+// *VersionConflictError is returned.
+func (m *MemoryRepo) RecategoriseTransaction(ctx context.Context, userID, txnID, categoryID, expectedVersion int) error {
+	return m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTransaction, txnID, AuditUpdate, func() error {
+			if expectedVersion != 0 {
+				if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+					return err
+				}
+			}
+			if _, ok := d.activeCategory(userID, categoryID); categoryID != 0 && !ok {
+				return &NotFoundError{Entity: EntityCategory}
+			}
+			if _, ok := d.activeTransaction(userID, txnID); !ok {
+				return &NotFoundError{Entity: EntityTransaction}
+			}
+			d.bumpTransaction(txnID, func(t *Transaction) { t.CategoryID = categoryID })
+			return nil
+		})
+	})
+}
+
+func (d *memoryData) activeTransaction(userID, txnID int) (Transaction, bool) {
+	t, ok := d.transactions[txnID]
+	if !ok || t.UserID != userID || t.DeletedAt != nil {
+		return Transaction{}, false
+	}
+	return t, true
+}
+
+// checkVersion makes sure a record of userID, trashed or not, is still at the
+// expected version.
+func (d *memoryData) checkVersion(entity string, userID, id, expected int) error {
+	current, err := d.currentVersion(entity, userID, id)
+	if err != nil {
+		return err
+	}
+	if current != expected {
+		return &VersionConflictError{Entity: entity, ID: id, Expected: expected, Current: current}
+	}
+	return nil
+}
+
+// currentVersion returns the version of a record of userID, trashed or not.
+func (d *memoryData) currentVersion(entity string, userID, id int) (int, error) {
+	switch entity {
+	case EntityTransaction:
+		if t, ok := d.transactions[id]; ok && t.UserID == userID {
+			return t.Version, nil
+		}
+	case EntityCategory:
+		if c, ok := d.categories[id]; ok && c.UserID == userID {
+			return c.Version, nil
+		}
+	case EntityTag:
+		if g, ok := d.tags[id]; ok && g.UserID == userID {
+			return g.Version, nil
+		}
+	}
+	return 0, &NotFoundError{Entity: entity}
+}
+
+// bumpTransaction saves the current state of a transaction in its history,
+// applies change and bumps the version.
+func (d *memoryData) bumpTransaction(txnID int, change func(t *Transaction)) {
+	t := d.transactions[txnID]
+	tagIDs := slices.Clone(d.txnTags[txnID])
+	if tagIDs == nil {
+		tagIDs = []int{}
+	}
+	setRow(d, d.versions, txnID, append(slices.Clip(d.versions[txnID]), TransactionVersion{
+		Version:     t.Version,
+		Amount:      t.Amount,
+		Date:        t.Date,
+		Description: t.Description,
+		CategoryID:  t.CategoryID,
+		Splits:      d.splitLines(txnID),
+		TagIDs:      tagIDs,
+		DeletedAt:   t.DeletedAt,
+		ReplacedAt:  time.Now(),
+	}))
+
+	change(&t)
+	t.Version++
+	setRow(d, d.transactions, txnID, t)
+}
+
+// Versions
+
+// GetTransactionHistory returns the history of a transaction, or nil if it does
+// not exist or belongs to another user.
+func (m *MemoryRepo) GetTransactionHistory(ctx context.Context, userID, txnID int) (*TransactionHistory, error) {
+	var history *TransactionHistory
+	err := m.read(func(d *memoryData) error {
+		t, ok := d.transactions[txnID]
+		if !ok || t.UserID != userID {
+			return nil
+		}
+
+		versions := slices.Clone(d.versions[txnID])
+		slices.SortFunc(versions, func(a, b TransactionVersion) int { return b.Version - a.Version })
+		if versions == nil {
+			versions = []TransactionVersion{}
+		}
+		history = &TransactionHistory{Current: t, Versions: versions}
+		return nil
+	})
+	return history, err
+}
+
+// UndoTransaction brings a transaction back to a previous version like
+// DB.UndoTransaction and returns the number of the new version.
+func (m *MemoryRepo) UndoTransaction(ctx context.Context, userID, txnID, expectedVersion, toVersion int) (int, error) {
+	var newVersion int
+	err := m.write(func(d *memoryData) error {
+		return d.audited(ctx, userID, EntityTransaction, txnID, AuditUndo, func() error {
+			if expectedVersion == 0 {
+				current, err := d.currentVersion(EntityTransaction, userID, txnID)
+				if err != nil {
+					return err
+				}
+				expectedVersion = current
+			}
+			if err := d.checkVersion(EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+
+			if toVersion == 0 {
+				toVersion = expectedVersion - 1
+			}
+			if toVersion < 1 || toVersion >= expectedVersion {
+				return ErrVersionNotFound
+			}
+
+			i := slices.IndexFunc(d.versions[txnID], func(v TransactionVersion) bool { return v.Version == toVersion })
+			if i < 0 {
+				return ErrVersionNotFound
+			}
+			target := d.versions[txnID][i]
+
+			categories := []int{target.CategoryID}
+			for _, split := range target.Splits {
+				categories = append(categories, split.CategoryID)
+			}
+			for _, id := range categories {
+				if _, ok := d.activeCategory(userID, id); id != 0 && !ok {
+					return errUndoCategoryGone
+				}
+			}
+			for _, tagID := range target.TagIDs {
+				if g, ok := d.tags[tagID]; !ok || g.UserID != userID {
+					return errUndoTagGone
+				}
+			}
+
+			d.bumpTransaction(txnID, func(t *Transaction) {
+				t.Amount = target.Amount
+				t.Date = target.Date
+				t.Description = target.Description
+				t.CategoryID = target.CategoryID
+				t.DeletedAt = target.DeletedAt
+			})
+			newVersion = d.transactions[txnID].Version
+
+			if !sameSplits(d.splitLines(txnID), target.Splits) {
+				d.writeSplits(ctx, userID, txnID, target.Splits)
+			}
+			return d.audited(ctx, userID, EntityTransactionTags, txnID, AuditUpdate, func() error {
+				d.setTags(txnID, slices.Clone(target.TagIDs))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return newVersion, nil
+}
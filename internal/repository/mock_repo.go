@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -26,13 +27,24 @@ func (m *MockRepo) GetCategories(ctx context.Context, userID int) ([]Category, e
 	return categories, args.Error(1)
 }
 
-func (m *MockRepo) UpdateCategory(ctx context.Context, userID, categoryID int, name string) error {
-	args := m.Called(ctx, userID, categoryID, name)
+func (m *MockRepo) GetCategory(ctx context.Context, userID, categoryID int) (*Category, error) {
+	args := m.Called(ctx, userID, categoryID)
+	category, _ := args.Get(0).(*Category)
+	return category, args.Error(1)
+}
+
+func (m *MockRepo) UpdateCategory(ctx context.Context, userID, categoryID int, name string, expectedVersion int) error {
+	args := m.Called(ctx, userID, categoryID, name, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockRepo) DeleteCategory(ctx context.Context, userID, categoryID int) error {
-	args := m.Called(ctx, userID, categoryID)
+func (m *MockRepo) DeleteCategory(ctx context.Context, userID, categoryID int, strategy string, targetID, expectedVersion int) error {
+	args := m.Called(ctx, userID, categoryID, strategy, targetID, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MergeCategories(ctx context.Context, userID, sourceID, targetID, expectedVersion int) error {
+	args := m.Called(ctx, userID, sourceID, targetID, expectedVersion)
 	return args.Error(0)
 }
 
@@ -47,16 +59,152 @@ func (m *MockRepo) GetTransactions(ctx context.Context, userID int) ([]Transacti
 	return args.Get(0).([]Transaction), args.Error(1)
 }
 
+func (m *MockRepo) GetTransaction(ctx context.Context, userID, txnID int) (*Transaction, error) {
+	args := m.Called(ctx, userID, txnID)
+	txn, _ := args.Get(0).(*Transaction)
+	return txn, args.Error(1)
+}
+
+func (m *MockRepo) GetTransactionsFiltered(ctx context.Context, userID int, filter TransactionFilter) ([]Transaction, error) {
+	args := m.Called(ctx, userID, filter)
+	return args.Get(0).([]Transaction), args.Error(1)
+}
+
 func (m *MockRepo) UpdateTransaction(ctx context.Context, txn Transaction) error {
 	args := m.Called(ctx, txn)
 	return args.Error(0)
 }
 
-func (m *MockRepo) DeleteTransaction(ctx context.Context, userID, txnID int) error {
+func (m *MockRepo) PatchTransaction(ctx context.Context, userID, txnID int, patch TransactionPatch, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, patch, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteTransaction(ctx context.Context, userID, txnID, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RecategoriseTransaction(ctx context.Context, userID, txnID, categoryID, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, categoryID, expectedVersion)
+	return args.Error(0)
+}
+
+// Методы для версий транзакций
+func (m *MockRepo) GetTransactionHistory(ctx context.Context, userID, txnID int) (*TransactionHistory, error) {
+	args := m.Called(ctx, userID, txnID)
+	history, _ := args.Get(0).(*TransactionHistory)
+	return history, args.Error(1)
+}
+
+func (m *MockRepo) UndoTransaction(ctx context.Context, userID, txnID, expectedVersion, toVersion int) (int, error) {
+	args := m.Called(ctx, userID, txnID, expectedVersion, toVersion)
+	return args.Int(0), args.Error(1)
+}
+
+// Методы для разбивки транзакций
+func (m *MockRepo) GetSplits(ctx context.Context, userID, txnID int) ([]Split, error) {
+	args := m.Called(ctx, userID, txnID)
+	splits, _ := args.Get(0).([]Split)
+	return splits, args.Error(1)
+}
+
+func (m *MockRepo) SetSplits(ctx context.Context, userID, txnID int, splits []Split, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, splits, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteSplits(ctx context.Context, userID, txnID, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, expectedVersion)
+	return args.Error(0)
+}
+
+// Методы для вложений
+func (m *MockRepo) CreateAttachment(ctx context.Context, a Attachment) (int, error) {
+	args := m.Called(ctx, a)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepo) GetAttachments(ctx context.Context, userID, txnID int) ([]Attachment, error) {
+	args := m.Called(ctx, userID, txnID)
+	attachments, _ := args.Get(0).([]Attachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockRepo) GetAttachment(ctx context.Context, userID, attachmentID int) (*Attachment, error) {
+	args := m.Called(ctx, userID, attachmentID)
+	attachment, _ := args.Get(0).(*Attachment)
+	return attachment, args.Error(1)
+}
+
+func (m *MockRepo) DeleteAttachment(ctx context.Context, userID, attachmentID int) error {
+	args := m.Called(ctx, userID, attachmentID)
+	return args.Error(0)
+}
+
+// Методы для тегов
+func (m *MockRepo) CreateTag(ctx context.Context, userID int, name string) (int, error) {
+	args := m.Called(ctx, userID, name)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepo) GetTags(ctx context.Context, userID int) ([]Tag, error) {
+	args := m.Called(ctx, userID)
+	tags, _ := args.Get(0).([]Tag)
+	return tags, args.Error(1)
+}
+
+func (m *MockRepo) UpdateTag(ctx context.Context, userID, tagID int, name string, expectedVersion int) error {
+	args := m.Called(ctx, userID, tagID, name, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteTag(ctx context.Context, userID, tagID, expectedVersion int) error {
+	args := m.Called(ctx, userID, tagID, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SetTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, expectedVersion int) error {
+	args := m.Called(ctx, userID, txnID, tagIDs, expectedVersion)
+	return args.Error(0)
+}
+
+// Методы для корзины
+func (m *MockRepo) GetTrash(ctx context.Context, userID int) (*Trash, error) {
+	args := m.Called(ctx, userID)
+	trash, _ := args.Get(0).(*Trash)
+	return trash, args.Error(1)
+}
+
+func (m *MockRepo) RestoreTransaction(ctx context.Context, userID, txnID int) error {
 	args := m.Called(ctx, userID, txnID)
 	return args.Error(0)
 }
 
+func (m *MockRepo) RestoreCategory(ctx context.Context, userID, categoryID int) error {
+	args := m.Called(ctx, userID, categoryID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]Attachment, error) {
+	args := m.Called(ctx, before)
+	attachments, _ := args.Get(0).([]Attachment)
+	return attachments, args.Error(1)
+}
+
+// Методы для журнала аудита
+func (m *MockRepo) GetAuditLog(ctx context.Context, userID int, filter AuditFilter) ([]AuditEntry, error) {
+	args := m.Called(ctx, userID, filter)
+	entries, _ := args.Get(0).([]AuditEntry)
+	return entries, args.Error(1)
+}
+
+func (m *MockRepo) GetTransactionAudit(ctx context.Context, userID, txnID int) ([]AuditEntry, error) {
+	args := m.Called(ctx, userID, txnID)
+	entries, _ := args.Get(0).([]AuditEntry)
+	return entries, args.Error(1)
+}
+
 // Методы для аналитики
 func (m *MockRepo) GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error) {
 	args := m.Called(ctx, userID)
@@ -69,16 +217,21 @@ func (m *MockRepo) GetCategoryAnalytics(ctx context.Context, userID int) ([]Cate
 }
 
 // Методы для аналитики
-func (m *MockRepo) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, startDate, endDate string) (*Analytics, error) {
-	args := m.Called(ctx, userID, startDate, endDate)
+func (m *MockRepo) GetIncomeAndExpensesFiltered(ctx context.Context, userID int, from, to time.Time) (*Analytics, error) {
+	args := m.Called(ctx, userID, from, to)
 	return args.Get(0).(*Analytics), args.Error(1)
 }
 
-func (m *MockRepo) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, startDate, endDate string) ([]CategoryAnalytics, error) {
-	args := m.Called(ctx, userID, startDate, endDate)
+func (m *MockRepo) GetCategoryAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]CategoryAnalytics, error) {
+	args := m.Called(ctx, userID, from, to)
 	return args.Get(0).([]CategoryAnalytics), args.Error(1)
 }
 
+func (m *MockRepo) GetTagAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]TagAnalytics, error) {
+	args := m.Called(ctx, userID, from, to)
+	return args.Get(0).([]TagAnalytics), args.Error(1)
+}
+
 // Реализация метода CreateUser
 func (m *MockRepo) CreateUser(ctx context.Context, username, hashedPassword string) (int, error) {
 	args := m.Called(ctx, username, hashedPassword)
@@ -94,3 +247,20 @@ func (m *MockRepo) GetUserByUsername(ctx context.Context, username string) (*Use
 	}
 	return user, args.Error(1)
 }
+
+// Реализация метода GetUserTimezone
+func (m *MockRepo) GetUserTimezone(ctx context.Context, userID int) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+// Реализация метода SetUserTimezone
+func (m *MockRepo) SetUserTimezone(ctx context.Context, userID int, timezone string) error {
+	args := m.Called(ctx, userID, timezone)
+	return args.Error(0)
+}
+
+// WithTx просто вызывает fn с тем же моком, чтобы ожидания внутри транзакции работали как обычно
+func (m *MockRepo) WithTx(ctx context.Context, fn func(Repository) error) error {
+	return fn(m)
+}
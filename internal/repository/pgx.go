@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgxConn is what the repository needs from a pgx pool, connection or
+// transaction.
+type pgxConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// preparer is implemented by pgx connections and transactions, which prepare
+// statements on the connection they hold.
+type preparer interface {
+	Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error)
+}
+
+// preparedStatements run as named prepared statements on pgx: the queries
+// behind nearly every request and the analytics reports. The audit snapshots
+// are added as snapshot_<entity>.
+var preparedStatements = map[string]string{
+	"user_by_username":             userByUsernameQuery,
+	"user_timezone":                userTimezoneQuery,
+	"insert_audit":                 insertAuditQuery,
+	"income_and_expenses":          incomeAndExpensesQuery,
+	"income_and_expenses_filtered": incomeAndExpensesFilteredQuery,
+	"category_analytics":           categoryAnalyticsQuery,
+	"category_analytics_filtered":  categoryAnalyticsFilteredQuery,
+	"tag_analytics_filtered":       tagAnalyticsFilteredQuery,
+}
+
+// statementNames maps the SQL of the prepared statements to their names.
+var statementNames = func() map[string]string {
+	names := make(map[string]string, len(preparedStatements)+len(auditSnapshots))
+	for name, query := range preparedStatements {
+		names[query] = name
+	}
+	for entity, query := range auditSnapshots {
+		names[query] = "snapshot_" + entity
+	}
+	return names
+}()
+
+// pgxQuerier runs the queries of a DB natively through pgx.
+type pgxQuerier struct {
+	conn pgxConn
+}
+
+// statement returns the connection and SQL to run query with. Prepared
+// statements are prepared once per connection and then run by name, so a
+// connection is taken from the pool for them; release gives it back.
+func (p pgxQuerier) statement(ctx context.Context, query string) (conn pgxConn, sql string, release func(), err error) {
+	name, ok := statementNames[query]
+	if !ok {
+		return p.conn, query, func() {}, nil
+	}
+
+	conn, release = p.conn, func() {}
+	if pool, ok := p.conn.(*pgxpool.Pool); ok {
+		c, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		conn, release = c.Conn(), c.Release
+	}
+
+	prep, ok := conn.(preparer)
+	if !ok {
+		return conn, query, release, nil
+	}
+	if _, err := prep.Prepare(ctx, name, query); err != nil {
+		release()
+		return nil, "", nil, fmt.Errorf("failed to prepare %s: %w", name, err)
+	}
+	return conn, name, release, nil
+}
+
+func (p pgxQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	conn, query, release, err := p.statement(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tag, err := conn.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxResult(tag), nil
+}
+
+func (p pgxQuerier) QueryContext(ctx context.Context, query string, args ...any) (resultRows, error) {
+	conn, query, release, err := p.statement(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &pgxRows{rows: rows, release: release}, nil
+}
+
+func (p pgxQuerier) QueryRowContext(ctx context.Context, query string, args ...any) resultRow {
+	conn, query, release, err := p.statement(ctx, query)
+	if err != nil {
+		return pgxRow{err: err}
+	}
+	return pgxRow{row: conn.QueryRow(ctx, query, args...), release: release}
+}
+
+// pgxResult reports the rows affected by a statement like sql.Result.
+type pgxResult pgconn.CommandTag
+
+func (r pgxResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by Postgres, use RETURNING")
+}
+
+func (r pgxResult) RowsAffected() (int64, error) {
+	return pgconn.CommandTag(r).RowsAffected(), nil
+}
+
+// pgxRows reads pgx rows like sql.Rows and releases their connection once
+// closed.
+type pgxRows struct {
+	rows    pgx.Rows
+	release func()
+}
+
+func (r *pgxRows) Next() bool             { return r.rows.Next() }
+func (r *pgxRows) Scan(dest ...any) error { return r.rows.Scan(dest...) }
+func (r *pgxRows) Err() error             { return r.rows.Err() }
+
+func (r *pgxRows) Close() error {
+	r.rows.Close()
+	if r.release != nil {
+		r.release()
+		r.release = nil
+	}
+	return r.rows.Err()
+}
+
+// pgxRow reads a pgx row like sql.Row: a missing row is sql.ErrNoRows.
+type pgxRow struct {
+	row     pgx.Row
+	err     error
+	release func()
+}
+
+func (r pgxRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.release()
+
+	err := r.row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// timeText scans a timestamp into a string formatted the way database/sql
+// does it, which pgx leaves to the caller.
+type timeText string
+
+func (t *timeText) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*t = timeText(v.Format(time.RFC3339Nano))
+	case string:
+		*t = timeText(v)
+	case []byte:
+		*t = timeText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a timestamp string", src)
+	}
+	return nil
+}
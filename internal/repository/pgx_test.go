@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPgxMock(t *testing.T) (*DB, pgxmock.PgxConnIface) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("Failed to create pgx mock: %v", err)
+	}
+	t.Cleanup(func() { mock.Close(context.Background()) })
+	return &DB{pgx: mock}, mock
+}
+
+func TestPgxPreparedStatements(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+
+	mock.ExpectPrepare("user_timezone", regexp.QuoteMeta(userTimezoneQuery))
+	mock.ExpectQuery("^user_timezone$").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"timezone"}).AddRow("Europe/Moscow"))
+
+	timezone, err := db.GetUserTimezone(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", timezone)
+
+	// Timestamps fill string fields as they do through database/sql
+	createdAt := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	mock.ExpectPrepare("user_by_username", regexp.QuoteMeta(userByUsernameQuery))
+	mock.ExpectQuery("^user_by_username$").
+		WithArgs("alice").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "username", "password_hash", "created_at"}).
+			AddRow(1, "alice", "hash", createdAt))
+
+	user, err := db.GetUserByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, &User{ID: 1, Username: "alice", Password: "hash", CreatedAt: "2026-10-19T12:30:00Z"}, user)
+
+	// A missing row is sql.ErrNoRows to the repository
+	mock.ExpectPrepare("user_by_username", regexp.QuoteMeta(userByUsernameQuery))
+	mock.ExpectQuery("^user_by_username$").
+		WithArgs("bob").
+		WillReturnError(pgx.ErrNoRows)
+
+	user, err = db.GetUserByUsername(ctx, "bob")
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgxWithTx(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions").
+		WithArgs(10, 1).
+		WillReturnResult(pgconn.NewCommandTag("UPDATE 1"))
+	mock.ExpectCommit()
+
+	err := db.WithTx(ctx, func(r Repository) error {
+		res, err := r.(*DB).q().ExecContext(ctx, "UPDATE transactions SET amount = $1 WHERE id = $2", 10, 1)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		assert.Equal(t, int64(1), n)
+		return err
+	})
+	assert.NoError(t, err)
+
+	failure := errors.New("failure")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = db.WithTx(ctx, func(Repository) error { return failure })
+	assert.ErrorIs(t, err, failure)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgxImportBatch(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	batch := mock.ExpectBatch()
+	batch.ExpectQuery("INSERT INTO transactions").
+		WithArgs(-12.5, date, "Lunch", 3, 1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(41))
+	batch.ExpectQuery("INSERT INTO transactions").
+		WithArgs(100.0, date, "Refund", nil, 1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, []int{41, 42}, "").
+		WillReturnResult(pgconn.NewCommandTag("INSERT 0 2"))
+	mock.ExpectCommit()
+
+	results, err := ApplyBulk(ctx, db, 1, []BulkOperation{
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: -12.5, Date: date, Description: "Lunch", CategoryID: 3}},
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: 100, Date: date, Description: "Refund"}},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []BulkResult{
+		{Index: 0, Op: BulkOpCreate, ID: 41},
+		{Index: 1, Op: BulkOpCreate, ID: 42},
+	}, results)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgxImportCopy(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+
+	ops := make([]BulkOperation, copyThreshold)
+	ids := pgxmock.NewRows([]string{"nextval"})
+	want := make([]int, copyThreshold)
+	for i := range ops {
+		ops[i] = BulkOperation{Op: BulkOpCreate, Transaction: Transaction{Amount: -1, Description: "Coffee"}}
+		ids.AddRow(1000 + i)
+		want[i] = 1000 + i
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT nextval").
+		WithArgs(copyThreshold).
+		WillReturnRows(ids)
+	mock.ExpectCopyFrom(pgx.Identifier{"transactions"}, []string{"id", "amount", "date", "description", "category_id", "user_id"}).
+		WillReturnResult(copyThreshold)
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(1, want, "").
+		WillReturnResult(pgconn.NewCommandTag("INSERT 0 100"))
+	mock.ExpectCommit()
+
+	results, err := ApplyBulk(ctx, db, 1, ops, true)
+	assert.NoError(t, err)
+	assert.Len(t, results, copyThreshold)
+	assert.Equal(t, 1099, results[99].ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCopyRowsUncategorised(t *testing.T) {
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := copyRows([]int{41, 42}, []Transaction{
+		{Amount: -12.5, Date: date, Description: "Lunch", CategoryID: 3, UserID: 1},
+		{Amount: 100, Date: date, Description: "Refund", UserID: 1},
+	})
+	assert.Equal(t, [][]any{
+		{41, -12.5, date, "Lunch", 3, 1},
+		{42, 100.0, date, "Refund", nil, 1},
+	}, rows)
+}
+
+func TestPgxImportFallback(t *testing.T) {
+	db, mock := newPgxMock(t)
+	ctx := context.Background()
+	violation := &pgconn.PgError{Code: "23503"}
+
+	// The failed import is rolled back and the operations are retried one by
+	// one to report which of them failed
+	mock.ExpectBegin()
+	mock.ExpectBatch().ExpectQuery("INSERT INTO transactions").
+		WithArgs(-5.0, time.Time{}, "Coffee", 99, 1).
+		WillReturnError(violation)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(-5.0, time.Time{}, "Coffee", 99, 1).
+		WillReturnError(violation)
+	mock.ExpectRollback()
+
+	results, err := ApplyBulk(ctx, db, 1, []BulkOperation{
+		{Op: BulkOpCreate, Transaction: Transaction{Amount: -5, Description: "Coffee", CategoryID: 99}},
+	}, true)
+	assert.ErrorIs(t, err, ErrBulkRolledBack)
+	if assert.Len(t, results, 1) {
+		assert.NotEmpty(t, results[0].Error)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
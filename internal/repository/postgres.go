@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresConfig describes how to reach Postgres and how to size the pool.
+type PostgresConfig struct {
+	// URL is a full postgres:// connection URL. When set, Host, Port, User,
+	// Password and Name are ignored.
+	URL      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+
+	// TLS settings override those of URL when set. SSLMode is one of the
+	// libpq modes: disable, allow, prefer, require, verify-ca, verify-full.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// Pool limits; zero leaves the pgxpool default.
+	MaxConns        int
+	MinConns        int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectTimeout is how long NewDB keeps retrying while the database is
+	// unreachable; zero tries once.
+	ConnectTimeout time.Duration
+}
+
+// DSN returns the connection URL described by cfg.
+func (cfg PostgresConfig) DSN() (string, error) {
+	var u *url.URL
+	if cfg.URL != "" {
+		parsed, err := url.Parse(cfg.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid database URL: %s", RedactDSN(cfg.URL))
+		}
+		if parsed.Scheme != "postgres" && parsed.Scheme != "postgresql" {
+			return "", fmt.Errorf("database URL must start with postgres://, got %q", parsed.Scheme)
+		}
+		u = parsed
+	} else {
+		u = &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Password),
+			Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:   "/" + cfg.Name,
+		}
+	}
+
+	query := u.Query()
+	for key, value := range map[string]string{
+		"sslmode":     cfg.SSLMode,
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// RedactDSN hides the password of a connection URL so it can be logged.
+func RedactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "<invalid database URL>"
+	}
+	return u.Redacted()
+}
+
+// NewDB opens the pgx pool described by cfg and waits for the database to
+// accept connections, retrying with backoff for up to cfg.ConnectTimeout.
+// The database/sql handle of the DB shares the connections of the pool.
+func NewDB(ctx context.Context, cfg PostgresConfig) (*DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Connecting to DB: %s", RedactDSN(dsn))
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = int32(cfg.MinConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := retry(ctx, cfg.ConnectTimeout, pool.Ping); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("database connection error: %w", err)
+	}
+
+	log.Printf("database connection estabilished")
+
+	return &DB{Conn: stdlib.OpenDBFromPool(pool), pool: pool, pgx: pool}, nil
+}
+
+// Backoff between attempts of retry.
+const (
+	retryInitialDelay = 500 * time.Millisecond
+	retryMaxDelay     = 5 * time.Second
+)
+
+// retry calls fn until it succeeds or timeout has passed, doubling the pause
+// between attempts. It returns the last error of fn.
+func retry(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	delay := retryInitialDelay
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return err
+		}
+
+		log.Printf("Database not ready, retrying in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, retryMaxDelay)
+	}
+}
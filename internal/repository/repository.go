@@ -1,28 +1,75 @@
 package repository
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository interface with CRUD for categories, transactions, analytics and user methods
 type Repository interface {
 	// Categories
 	CreateCategory(ctx context.Context, userID int, name string) (int, error)
 	GetCategories(ctx context.Context, userID int) ([]Category, error)
-	UpdateCategory(ctx context.Context, userID, categoryID int, name string) error
-	DeleteCategory(ctx context.Context, userID, categoryID int) error
+	GetCategory(ctx context.Context, userID, categoryID int) (*Category, error)
+	UpdateCategory(ctx context.Context, userID, categoryID int, name string, expectedVersion int) error
+	DeleteCategory(ctx context.Context, userID, categoryID int, strategy string, targetID, expectedVersion int) error
+	MergeCategories(ctx context.Context, userID, sourceID, targetID, expectedVersion int) error
 
 	// Transactions
 	CreateTransaction(ctx context.Context, txn Transaction) (int, error)
 	GetTransactions(ctx context.Context, userID int) ([]Transaction, error)
+	GetTransaction(ctx context.Context, userID, txnID int) (*Transaction, error)
+	GetTransactionsFiltered(ctx context.Context, userID int, filter TransactionFilter) ([]Transaction, error)
 	UpdateTransaction(ctx context.Context, txn Transaction) error
-	DeleteTransaction(ctx context.Context, userID, txnID int) error
+	PatchTransaction(ctx context.Context, userID, txnID int, patch TransactionPatch, expectedVersion int) error
+	DeleteTransaction(ctx context.Context, userID, txnID, expectedVersion int) error
+	RecategoriseTransaction(ctx context.Context, userID, txnID, categoryID, expectedVersion int) error
+
+	// Transaction versions
+	GetTransactionHistory(ctx context.Context, userID, txnID int) (*TransactionHistory, error)
+	UndoTransaction(ctx context.Context, userID, txnID, expectedVersion, toVersion int) (int, error)
+
+	// Transaction splits
+	GetSplits(ctx context.Context, userID, txnID int) ([]Split, error)
+	SetSplits(ctx context.Context, userID, txnID int, splits []Split, expectedVersion int) error
+	DeleteSplits(ctx context.Context, userID, txnID, expectedVersion int) error
+
+	// Attachments
+	CreateAttachment(ctx context.Context, a Attachment) (int, error)
+	GetAttachments(ctx context.Context, userID, txnID int) ([]Attachment, error)
+	GetAttachment(ctx context.Context, userID, attachmentID int) (*Attachment, error)
+	DeleteAttachment(ctx context.Context, userID, attachmentID int) error
+
+	// Tags
+	CreateTag(ctx context.Context, userID int, name string) (int, error)
+	GetTags(ctx context.Context, userID int) ([]Tag, error)
+	UpdateTag(ctx context.Context, userID, tagID int, name string, expectedVersion int) error
+	DeleteTag(ctx context.Context, userID, tagID, expectedVersion int) error
+	SetTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, expectedVersion int) error
+
+	// Trash
+	GetTrash(ctx context.Context, userID int) (*Trash, error)
+	RestoreTransaction(ctx context.Context, userID, txnID int) error
+	RestoreCategory(ctx context.Context, userID, categoryID int) error
+	PurgeDeleted(ctx context.Context, before time.Time) ([]Attachment, error)
+
+	// Audit log
+	GetAuditLog(ctx context.Context, userID int, filter AuditFilter) ([]AuditEntry, error)
+	GetTransactionAudit(ctx context.Context, userID, txnID int) ([]AuditEntry, error)
 
 	// Analytics
 	GetIncomeAndExpenses(ctx context.Context, userID int) (*Analytics, error)
 	GetCategoryAnalytics(ctx context.Context, userID int) ([]CategoryAnalytics, error)
-	GetIncomeAndExpensesFiltered(ctx context.Context, userID int, startDate, endDate string) (*Analytics, error)
-	GetCategoryAnalyticsFiltered(ctx context.Context, userID int, startDate, endDate string) ([]CategoryAnalytics, error)
+	GetIncomeAndExpensesFiltered(ctx context.Context, userID int, from, to time.Time) (*Analytics, error)
+	GetCategoryAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]CategoryAnalytics, error)
+	GetTagAnalyticsFiltered(ctx context.Context, userID int, from, to time.Time) ([]TagAnalytics, error)
 
 	// User methods
 	CreateUser(ctx context.Context, username, hashedPassword string) (int, error)
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserTimezone(ctx context.Context, userID int) (string, error)
+	SetUserTimezone(ctx context.Context, userID int, timezone string) error
+
+	// Unit of work
+	WithTx(ctx context.Context, fn func(Repository) error) error
 }
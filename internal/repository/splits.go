@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// ErrSplitSumMismatch is returned when split lines do not add up to the amount
+// of their parent transaction.
+var ErrSplitSumMismatch = invalid("amount", "split amounts must sum to the transaction amount")
+
+// Split is a part of a transaction assigned to its own category.
+type Split struct {
+	ID            int     `json:"id"`
+	TransactionID int     `json:"transaction_id"`
+	CategoryID    int     `json:"category_id"`
+	Amount        float64 `json:"amount"`
+	Note          string  `json:"note"`
+}
+
+// GetSplits returns the split lines of a transaction, or a *NotFoundError if
+// the transaction does not exist or belongs to another user.
+func (db *DB) GetSplits(ctx context.Context, userID, txnID int) ([]Split, error) {
+	var id int
+	err := db.q().QueryRowContext(ctx, "SELECT id FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", txnID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Entity: EntityTransaction}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+
+	query := `
+        SELECT s.id, s.transaction_id, s.category_id, s.amount, s.note
+        FROM transaction_splits s
+        JOIN transactions t ON s.transaction_id = t.id
+        WHERE s.transaction_id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+        ORDER BY s.id
+    `
+	rows, err := db.q().QueryContext(ctx, query, txnID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch splits: %w", err)
+	}
+	defer rows.Close()
+
+	var splits []Split
+	for rows.Next() {
+		var split Split
+		var categoryID sql.NullInt64
+		var note sql.NullString
+		if err := rows.Scan(&split.ID, &split.TransactionID, &categoryID, &split.Amount, &note); err != nil {
+			return nil, err
+		}
+		split.CategoryID = int(categoryID.Int64)
+		split.Note = note.String
+		splits = append(splits, split)
+	}
+	return splits, rows.Err()
+}
+
+// SetSplits replaces the split lines of a transaction and bumps its version.
+// The amounts must sum to the transaction amount and every category must be an
+// active category of userID; an empty slice removes all splits. A non-zero
+// expectedVersion must match the stored version of the transaction, otherwise
+// a *VersionConflictError is returned.
+func (db *DB) SetSplits(ctx context.Context, userID, txnID int, splits []Split, expectedVersion int) error {
+	return db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		var amount float64
+		err := tx.q().QueryRowContext(ctx, "SELECT amount FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", txnID, userID).Scan(&amount)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch transaction: %w", err)
+		}
+
+		if len(splits) > 0 {
+			var total float64
+			for _, split := range splits {
+				total += split.Amount
+			}
+			if !sameAmount(total, amount) {
+				return ErrSplitSumMismatch
+			}
+		}
+		for _, split := range splits {
+			if split.CategoryID == 0 {
+				continue
+			}
+			if err := tx.lockCategory(ctx, userID, split.CategoryID); err != nil {
+				return err
+			}
+		}
+		if err := tx.bumpTransactionVersion(ctx, userID, txnID); err != nil {
+			return err
+		}
+		return tx.writeSplits(ctx, userID, txnID, splits)
+	})
+}
+
+// writeSplits replaces the split lines of txnID without checking them,
+// recording every deleted and created line in the audit log.
+func (db *DB) writeSplits(ctx context.Context, userID, txnID int, splits []Split) error {
+	if db.dialect == SQLite {
+		if err := db.sqliteDeleteSplits(ctx, userID, txnID); err != nil {
+			return err
+		}
+	} else {
+		query := `
+            WITH gone AS (
+                DELETE FROM transaction_splits WHERE transaction_id = $1
+                RETURNING id, transaction_id, category_id, amount, note
+            )
+            INSERT INTO audit_log (user_id, actor_id, entity, entity_id, action, before, request_id)
+            SELECT $2, $2, 'split', gone.id, 'delete', row_to_json(gone), NULLIF($3, '') FROM gone
+        `
+		if _, err := db.q().ExecContext(ctx, query, txnID, userID, requestIDFromContext(ctx)); err != nil {
+			return fmt.Errorf("failed to delete splits: %w", err)
+		}
+	}
+
+	query := "INSERT INTO transaction_splits (transaction_id, category_id, amount, note) VALUES ($1, $2, $3, $4) RETURNING id"
+	for _, split := range splits {
+		var id int
+		if err := db.q().QueryRowContext(ctx, query, txnID, categoryArg(split.CategoryID), split.Amount, split.Note).Scan(&id); err != nil {
+			return fmt.Errorf("failed to create split: %w", err)
+		}
+		if err := db.recordCreate(ctx, userID, EntitySplit, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) DeleteSplits(ctx context.Context, userID, txnID, expectedVersion int) error {
+	return db.SetSplits(ctx, userID, txnID, nil, expectedVersion)
+}
+
+// checkSplitTotal makes sure a new amount for txnID still matches its splits, if any.
+func (db *DB) checkSplitTotal(ctx context.Context, txnID int, amount float64) error {
+	query := "SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM transaction_splits WHERE transaction_id = $1"
+	var count int
+	var total float64
+	if err := db.q().QueryRowContext(ctx, query, txnID).Scan(&count, &total); err != nil {
+		return fmt.Errorf("failed to fetch splits: %w", err)
+	}
+	if count > 0 && !sameAmount(total, amount) {
+		return ErrSplitSumMismatch
+	}
+	return nil
+}
+
+// sameAmount compares two money amounts at cent precision.
+func sameAmount(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
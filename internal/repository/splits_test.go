@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSplits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT s.id, s.transaction_id, s.category_id, s.amount, s.note FROM transaction_splits s").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "category_id", "amount", "note"}).
+			AddRow(1, 1, 2, -70.00, "Groceries").
+			AddRow(2, 1, nil, -30.00, nil))
+
+	splits, err := r.GetSplits(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, splits, 2)
+	assert.Equal(t, 2, splits[0].CategoryID)
+	assert.Equal(t, "Groceries", splits[0].Note)
+	assert.Equal(t, 0, splits[1].CategoryID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSplitsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(1, 2).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = r.GetSplits(context.Background(), 2, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSplitsRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT s.id, s.transaction_id, s.category_id, s.amount, s.note FROM transaction_splits s").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "category_id", "amount", "note"}).
+			AddRow(1, 1, 2, -70.00, "Groceries").
+			RowError(0, errors.New("connection reset")))
+
+	_, err = r.GetSplits(context.Background(), 1, 1)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSplits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT amount FROM transactions WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(-100.00))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO transaction_versions").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("WITH gone AS \\( DELETE FROM transaction_splits WHERE transaction_id = \\$1").
+		WithArgs(1, 1, "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO transaction_splits").
+		WithArgs(1, 2, -70.10, "Groceries").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntitySplit, 1, `{"id":1,"transaction_id":1}`)
+	expectAudit(mock, 1, EntitySplit, 1, AuditCreate)
+	mock.ExpectQuery("INSERT INTO transaction_splits").
+		WithArgs(1, 3, -29.90, "Household").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectSnapshot(mock, EntitySplit, 2, `{"id":2,"transaction_id":1}`)
+	expectAudit(mock, 1, EntitySplit, 2, AuditCreate)
+	mock.ExpectCommit()
+
+	err = r.SetSplits(context.Background(), 1, 1, []Split{
+		{CategoryID: 2, Amount: -70.10, Note: "Groceries"},
+		{CategoryID: 3, Amount: -29.90, Note: "Household"},
+	}, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSplitsSumMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT amount FROM transactions WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(-100.00))
+	mock.ExpectRollback()
+
+	err = r.SetSplits(context.Background(), 1, 1, []Split{
+		{CategoryID: 2, Amount: -70.00},
+		{CategoryID: 3, Amount: -20.00},
+	}, 0)
+	assert.ErrorIs(t, err, ErrSplitSumMismatch)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSplitsForeignCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT amount FROM transactions WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(-100.00))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(7, 1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = r.SetSplits(context.Background(), 1, 1, []Split{{CategoryID: 7, Amount: -100.00}}, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTransactionSplitMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"amount":-100}`)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(amount\\), 0\\) FROM transaction_splits WHERE transaction_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "total"}).AddRow(2, -100.00))
+	mock.ExpectRollback()
+
+	err = r.UpdateTransaction(context.Background(), Transaction{ID: 1, Amount: -120.00, UserID: 1})
+	assert.ErrorIs(t, err, ErrSplitSumMismatch)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// NewSQLiteDB opens the SQLite database in the file at path, creating it if
+// needed. Write transactions take the database lock when they begin, so the
+// row locks Postgres takes with SELECT ... FOR UPDATE are implied.
+func NewSQLiteDB(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	params := url.Values{
+		"_pragma":      {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+		"_time_format": {"sqlite"},
+		"_txlock":      {"immediate"},
+	}
+	log.Printf("Opening SQLite database: %s", path)
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database connection error: %w", err)
+	}
+
+	return &DB{Conn: db, dialect: SQLite}, nil
+}
+
+// sqliteNow is NOW() in the text format timestamps are stored in, so stored
+// and bound times compare correctly as strings.
+const sqliteNow = "strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')"
+
+// sqliteRewriter turns the Postgres spelling shared by most queries into
+// SQLite: locks are implied by the write transaction and JSON needs no casts.
+var sqliteRewriter = strings.NewReplacer(
+	" FOR UPDATE", "",
+	"::jsonb", "",
+	"::int", "",
+	"NOW()", sqliteNow,
+)
+
+// sqliteQuerier runs the queries of a DB on SQLite.
+type sqliteQuerier struct {
+	q sqlConn
+}
+
+func (s sqliteQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.q.ExecContext(ctx, sqliteRewriter.Replace(query), sqliteArgs(args)...)
+}
+
+func (s sqliteQuerier) QueryContext(ctx context.Context, query string, args ...any) (resultRows, error) {
+	return s.q.QueryContext(ctx, sqliteRewriter.Replace(query), sqliteArgs(args)...)
+}
+
+func (s sqliteQuerier) QueryRowContext(ctx context.Context, query string, args ...any) resultRow {
+	return s.q.QueryRowContext(ctx, sqliteRewriter.Replace(query), sqliteArgs(args)...)
+}
+
+// sqliteArgs moves times to UTC: SQLite compares them as text, which only
+// orders correctly within a single offset.
+func sqliteArgs(args []any) []any {
+	converted := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				converted[i] = v.Time.UTC()
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// isSQLiteUniqueViolation reports whether err comes from a UNIQUE or PRIMARY
+// KEY constraint of SQLite.
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// sqliteSnapshots are auditSnapshots for SQLite, which builds the same
+// documents with json_object.
+var sqliteSnapshots = map[string]string{
+	EntityUser:            "SELECT json_object('id', id, 'username', username, 'timezone', timezone, 'created_at', created_at) FROM users WHERE id = $1",
+	EntityCategory:        "SELECT " + sqliteCategoryDoc + " FROM categories WHERE id = $1",
+	EntityTransaction:     "SELECT " + sqliteTransactionDoc + " FROM transactions WHERE id = $1",
+	EntitySplit:           "SELECT json_object('id', id, 'transaction_id', transaction_id, 'category_id', category_id, 'amount', amount, 'note', note) FROM transaction_splits WHERE id = $1",
+	EntityTag:             "SELECT json_object('id', id, 'name', name, 'user_id', user_id) FROM tags WHERE id = $1",
+	EntityTransactionTags: "SELECT json_group_array(tag_id) FROM (SELECT tag_id FROM transaction_tags WHERE transaction_id = $1 ORDER BY tag_id)",
+	EntityAttachment:      "SELECT json_object('id', id, 'transaction_id', transaction_id, 'filename', filename, 'content_type', content_type, 'size', size, 'created_at', created_at) FROM attachments WHERE id = $1",
+}
+
+const (
+	sqliteCategoryDoc    = "json_object('id', id, 'name', name, 'user_id', user_id, 'deleted_at', deleted_at, 'version', version)"
+	sqliteTransactionDoc = "json_object('id', id, 'amount', amount, 'date', date, 'description', description, 'category_id', category_id, 'user_id', user_id, 'deleted_at', deleted_at, 'version', version)"
+)
+
+// SQLite has no data-modifying CTEs, so the statements that change many rows
+// at once on Postgres change them one by one below, each saved and audited
+// like a single-row change.
+
+// sqliteChangeTransactions runs update, which receives the transaction ID as
+// $1 followed by args, on every transaction of userID in ids.
+func (db *DB) sqliteChangeTransactions(ctx context.Context, userID int, ids []int, action, update string, args ...any) error {
+	for _, id := range ids {
+		err := db.audited(ctx, userID, EntityTransaction, id, action, func(tx *DB) error {
+			if err := tx.saveTransactionVersion(ctx, userID, id); err != nil {
+				return err
+			}
+			_, err := tx.q().ExecContext(ctx, update, append([]any{id}, args...)...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteTrashCategoryTransactions is the cascade of DeleteCategory.
+func (db *DB) sqliteTrashCategoryTransactions(ctx context.Context, userID, categoryID int) error {
+	query := `
+        SELECT id FROM transactions
+        WHERE category_id = $1 AND user_id = $2 AND deleted_at IS NULL
+        ORDER BY id
+    `
+	ids, err := db.selectIDs(ctx, query, categoryID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category transactions: %w", err)
+	}
+
+	update := "UPDATE transactions SET deleted_at = NOW(), version = version + 1 WHERE id = $1"
+	if err := db.sqliteChangeTransactions(ctx, userID, ids, AuditDelete, update); err != nil {
+		return fmt.Errorf("failed to delete category transactions: %w", err)
+	}
+	return nil
+}
+
+// sqliteMoveCategoryUsage is moveCategoryUsage for SQLite.
+func (db *DB) sqliteMoveCategoryUsage(ctx context.Context, userID, fromID, toID int) error {
+	ids, err := db.selectIDs(ctx, "SELECT id FROM transactions WHERE category_id = $1 AND user_id = $2 ORDER BY id", fromID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reassign transactions: %w", err)
+	}
+	update := "UPDATE transactions SET category_id = $2, version = version + 1 WHERE id = $1"
+	if err := db.sqliteChangeTransactions(ctx, userID, ids, AuditUpdate, update, toID); err != nil {
+		return fmt.Errorf("failed to reassign transactions: %w", err)
+	}
+
+	query := `
+        SELECT id FROM transaction_splits
+        WHERE category_id = $1 AND transaction_id IN (SELECT id FROM transactions WHERE user_id = $2)
+        ORDER BY id
+    `
+	ids, err = db.selectIDs(ctx, query, fromID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reassign splits: %w", err)
+	}
+	for _, id := range ids {
+		err := db.audited(ctx, userID, EntitySplit, id, AuditUpdate, func(tx *DB) error {
+			_, err := tx.q().ExecContext(ctx, "UPDATE transaction_splits SET category_id = $1 WHERE id = $2", toID, id)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reassign splits: %w", err)
+		}
+	}
+	return nil
+}
+
+// sqliteDeleteSplits removes the split lines of txnID, as SetSplits does
+// before writing the new ones.
+func (db *DB) sqliteDeleteSplits(ctx context.Context, userID, txnID int) error {
+	ids, err := db.selectIDs(ctx, "SELECT id FROM transaction_splits WHERE transaction_id = $1 ORDER BY id", txnID)
+	if err != nil {
+		return fmt.Errorf("failed to delete splits: %w", err)
+	}
+	for _, id := range ids {
+		err := db.audited(ctx, userID, EntitySplit, id, AuditDelete, func(tx *DB) error {
+			_, err := tx.q().ExecContext(ctx, "DELETE FROM transaction_splits WHERE id = $1", id)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete splits: %w", err)
+		}
+	}
+	return nil
+}
+
+// sqlitePurge is the second half of PurgeDeleted: purged rows are recorded
+// without an actor before they are deleted.
+func (db *DB) sqlitePurge(ctx context.Context, before time.Time) error {
+	query := `
+        INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+        SELECT t.user_id, 'attachment', a.id, 'purge',
+               json_object('id', a.id, 'transaction_id', a.transaction_id, 'filename', a.filename, 'content_type', a.content_type, 'size', a.size, 'created_at', a.created_at)
+        FROM attachments a JOIN transactions t ON a.transaction_id = t.id WHERE t.deleted_at < $1
+        UNION ALL
+        SELECT t.user_id, 'split', s.id, 'purge',
+               json_object('id', s.id, 'transaction_id', s.transaction_id, 'category_id', s.category_id, 'amount', s.amount, 'note', s.note)
+        FROM transaction_splits s JOIN transactions t ON s.transaction_id = t.id WHERE t.deleted_at < $1
+        UNION ALL
+        SELECT t.user_id, 'transaction_tags', t.id, 'purge',
+               (SELECT json_group_array(tag_id) FROM (SELECT tag_id FROM transaction_tags WHERE transaction_id = t.id ORDER BY tag_id))
+        FROM transactions t
+        WHERE t.deleted_at < $1 AND EXISTS (SELECT 1 FROM transaction_tags WHERE transaction_id = t.id)
+    `
+	if _, err := db.q().ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to purge transactions: %w", err)
+	}
+
+	query = `
+        INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+        SELECT user_id, 'transaction', id, 'purge', ` + sqliteTransactionDoc + `
+        FROM transactions WHERE deleted_at < $1
+    `
+	if _, err := db.q().ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to purge transactions: %w", err)
+	}
+	if _, err := db.q().ExecContext(ctx, "DELETE FROM transactions WHERE deleted_at < $1", before); err != nil {
+		return fmt.Errorf("failed to purge transactions: %w", err)
+	}
+
+	query = `
+        INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+        SELECT user_id, 'category', id, 'purge', json_object('id', id, 'name', name, 'user_id', user_id, 'deleted_at', deleted_at)
+        FROM categories WHERE deleted_at < $1 AND ` + categoryUnused + `
+    `
+	if _, err := db.q().ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to purge categories: %w", err)
+	}
+	if _, err := db.q().ExecContext(ctx, "DELETE FROM categories WHERE deleted_at < $1 AND "+categoryUnused, before); err != nil {
+		return fmt.Errorf("failed to purge categories: %w", err)
+	}
+	return nil
+}
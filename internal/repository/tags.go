@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+type Tag struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func (db *DB) CreateTag(ctx context.Context, userID int, name string) (int, error) {
+	var id int
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		query := "INSERT INTO tags (name, user_id) VALUES ($1, $2) RETURNING id"
+		if err := tx.q().QueryRowContext(ctx, query, name, userID).Scan(&id); err != nil {
+			if isUniqueViolation(err) {
+				return &ConflictError{Entity: EntityTag, Reason: "a tag with this name already exists"}
+			}
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+		return tx.recordCreate(ctx, userID, EntityTag, id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (db *DB) GetTags(ctx context.Context, userID int) ([]Tag, error) {
+	query := "SELECT id, name, version FROM tags WHERE user_id = $1 ORDER BY name"
+	rows, err := db.q().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Version); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// UpdateTag renames a tag. A non-zero expectedVersion must match the stored
+// version, otherwise a *VersionConflictError is returned.
+func (db *DB) UpdateTag(ctx context.Context, userID, tagID int, name string, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityTag, tagID, AuditUpdate, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTag, userID, tagID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		query := "UPDATE tags SET name = $1, version = version + 1 WHERE id = $2 AND user_id = $3"
+		res, err := tx.q().ExecContext(ctx, query, name, tagID, userID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return &ConflictError{Entity: EntityTag, Reason: "a tag with this name already exists"}
+			}
+			return fmt.Errorf("failed to update tag: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityTag}
+		}
+
+		return nil
+	})
+}
+
+// DeleteTag deletes a tag and removes it from every transaction, bumping the
+// version of the active ones. A non-zero expectedVersion must match the stored
+// version, otherwise a *VersionConflictError is returned.
+func (db *DB) DeleteTag(ctx context.Context, userID, tagID, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityTag, tagID, AuditDelete, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTag, userID, tagID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		query := `
+            SELECT t.id FROM transactions t
+            JOIN transaction_tags tt ON tt.transaction_id = t.id
+            WHERE tt.tag_id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+            ORDER BY t.id
+        `
+		tagged, err := tx.selectIDs(ctx, query, tagID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch tagged transactions: %w", err)
+		}
+		for _, txnID := range tagged {
+			if err := tx.bumpTransactionVersion(ctx, userID, txnID); err != nil {
+				return err
+			}
+		}
+
+		query = "DELETE FROM tags WHERE id = $1 AND user_id = $2"
+		res, err := tx.q().ExecContext(ctx, query, tagID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityTag}
+		}
+
+		return nil
+	})
+}
+
+// SetTransactionTags replaces the tags of a transaction and bumps its version
+// unless they stay the same. Both the transaction and every tag must belong to
+// userID. A non-zero expectedVersion must match the stored version of the
+// transaction, otherwise a *VersionConflictError is returned.
+func (db *DB) SetTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, expectedVersion int) error {
+	return db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+		return tx.replaceTransactionTags(ctx, userID, txnID, tagIDs, true)
+	})
+}
+
+// replaceTransactionTags replaces the tags of an active transaction of userID
+// and records the change in the audit log. When bump is set and the tags
+// change, the version of the transaction is bumped first; otherwise the caller
+// bumps it in the same database transaction.
+func (db *DB) replaceTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int, bump bool) error {
+	return db.audited(ctx, userID, EntityTransactionTags, txnID, AuditUpdate, func(tx *DB) error {
+		query := `
+            SELECT tag_id FROM transaction_tags
+            WHERE transaction_id = (SELECT id FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)
+            ORDER BY tag_id
+        `
+		current, err := tx.selectIDs(ctx, query, txnID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch transaction tags: %w", err)
+		}
+		wanted := slices.Clone(tagIDs)
+		slices.Sort(wanted)
+		wanted = slices.Compact(wanted)
+		if slices.Equal(current, wanted) {
+			return nil
+		}
+		if bump {
+			if err := tx.bumpTransactionVersion(ctx, userID, txnID); err != nil {
+				return err
+			}
+		}
+
+		query = "DELETE FROM transaction_tags WHERE transaction_id = (SELECT id FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)"
+		if _, err := tx.q().ExecContext(ctx, query, txnID, userID); err != nil {
+			return fmt.Errorf("failed to clear transaction tags: %w", err)
+		}
+
+		query = `
+            INSERT INTO transaction_tags (transaction_id, tag_id)
+            SELECT t.id, g.id FROM transactions t, tags g
+            WHERE t.id = $1 AND t.user_id = $3 AND t.deleted_at IS NULL AND g.id = $2 AND g.user_id = $3
+            ON CONFLICT DO NOTHING
+        `
+		for _, tagID := range wanted {
+			res, err := tx.q().ExecContext(ctx, query, txnID, tagID, userID)
+			if err != nil {
+				return fmt.Errorf("failed to tag transaction: %w", err)
+			}
+
+			rowsAffected, err := res.RowsAffected()
+			if err != nil || rowsAffected == 0 {
+				return &NotFoundError{Entity: "transaction or tag"}
+			}
+		}
+
+		return nil
+	})
+}
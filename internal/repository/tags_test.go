@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO tags").
+		WithArgs("vacation-2025", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityTag, 1, `{"id":1,"name":"vacation-2025"}`)
+	expectAudit(mock, 1, EntityTag, 1, AuditCreate)
+	mock.ExpectCommit()
+
+	id, err := r.CreateTag(context.Background(), 1, "vacation-2025")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectQuery("SELECT id, name, version FROM tags WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version"}).
+			AddRow(2, "reimbursable", 3).
+			AddRow(1, "vacation-2025", 1))
+
+	tags, err := r.GetTags(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "reimbursable", tags[0].Name)
+	assert.Equal(t, 3, tags[0].Version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTagsRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectQuery("SELECT id, name, version FROM tags WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version"}).
+			AddRow(2, "reimbursable", 3).
+			RowError(0, errors.New("connection reset")))
+
+	_, err = r.GetTags(context.Background(), 1)
+	assert.EqualError(t, err, "connection reset")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTagVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTag, 1, `{"id":1,"name":"trip","version":3}`)
+	mock.ExpectQuery("SELECT version FROM tags WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err = r.UpdateTag(context.Background(), 1, 1, "travel", 2)
+	var conflict *VersionConflictError
+	if assert.ErrorAs(t, err, &conflict) {
+		assert.Equal(t, 3, conflict.Current)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTagNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTag, 1, "")
+	mock.ExpectQuery("SELECT t.id FROM transactions t JOIN transaction_tags tt").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("DELETE FROM tags WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = r.DeleteTag(context.Background(), 1, 1, 0)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetTransactionTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	expectSnapshot(mock, EntityTransactionTags, 5, `[4]`)
+	mock.ExpectQuery("SELECT tag_id FROM transaction_tags").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id"}).AddRow(4))
+	mock.ExpectExec("INSERT INTO transaction_versions").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM transaction_tags").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transaction_tags").
+		WithArgs(5, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transaction_tags").
+		WithArgs(5, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransactionTags, 5, `[2, 3]`)
+	expectAudit(mock, 1, EntityTransactionTags, 5, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.SetTransactionTags(context.Background(), 1, 5, []int{2, 3, 2}, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetTransactionTagsForeignTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransactionTags, 5, `[]`)
+	mock.ExpectQuery("SELECT tag_id FROM transaction_tags").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id"}))
+	mock.ExpectExec("INSERT INTO transaction_versions").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transactions SET version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM transaction_tags").
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO transaction_tags").
+		WithArgs(5, 9, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = r.SetTransactionTags(context.Background(), 1, 5, []int{9}, 0)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTransactionsFilteredAllTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE user_id = \\$1 AND deleted_at IS NULL AND id IN \\(SELECT transaction_id FROM transaction_tags WHERE tag_id IN \\(\\$2, \\$3\\) GROUP BY transaction_id HAVING COUNT\\(DISTINCT tag_id\\) = \\$4\\)").
+		WithArgs(1, 2, 3, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version"}).
+			AddRow(1, -250.00, time.Now(), "Hotel", 1, 1, 1))
+	mock.ExpectQuery("SELECT tt.transaction_id, tt.tag_id FROM transaction_tags tt").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "tag_id"}).
+			AddRow(1, 2).
+			AddRow(1, 3))
+
+	transactions, err := r.GetTransactionsFiltered(context.Background(), 1, TransactionFilter{TagIDs: []int{2, 3}, MatchAllTags: true})
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, []int{2, 3}, transactions[0].TagIDs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
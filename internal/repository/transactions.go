@@ -2,32 +2,135 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
 type Transaction struct {
 	ID          int       `json:"id"`
 	Amount      float64   `json:"amount"`
-	Date        time.Time `json:"time"`
+	Date        time.Time `json:"date"`
 	Description string    `json:"description"`
 	CategoryID  int       `json:"category_id"`
 	UserID      int       `json:"user_id"`
+	TagIDs      []int     `json:"tag_ids,omitempty"`
+	Version     int       `json:"version"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TransactionPatch holds the fields of a partial transaction update; nil fields
+// are left untouched. ClearCategory removes the category of the transaction and
+// a non-nil empty TagIDs removes its tags.
+type TransactionPatch struct {
+	Amount        *float64
+	Date          *time.Time
+	Description   *string
+	CategoryID    *int
+	ClearCategory bool
+	TagIDs        []int
+}
+
+// TransactionFilter narrows down the transactions returned by GetTransactionsFiltered.
+type TransactionFilter struct {
+	// TagIDs keeps transactions carrying any of the tags, or all of them when MatchAllTags is set.
+	TagIDs       []int
+	MatchAllTags bool
+}
+
+// tagIDs returns the filter tags without duplicates, so that matching all of
+// them counts each tag once.
+func (f TransactionFilter) tagIDs() []int {
+	ids := slices.Clone(f.TagIDs)
+	slices.Sort(ids)
+	return slices.Compact(ids)
 }
 
+const insertTransactionQuery = "INSERT INTO transactions (amount, date, description, category_id, user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+
+// CreateTransaction inserts a transaction with the tags in txn.TagIDs, which
+// must belong to txn.UserID.
 func (db *DB) CreateTransaction(ctx context.Context, txn Transaction) (int, error) {
-	query := "INSERT INTO transactions (amount, date, description, category_id, user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id"
 	var id int
-	err := db.Conn.QueryRowContext(ctx, query, txn.Amount, txn.Date, txn.Description, txn.CategoryID, txn.UserID).Scan(&id)
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		if err := tx.q().QueryRowContext(ctx, insertTransactionQuery, txn.Amount, txn.Date, txn.Description, categoryArg(txn.CategoryID), txn.UserID).Scan(&id); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		if err := tx.recordCreate(ctx, txn.UserID, EntityTransaction, id); err != nil {
+			return err
+		}
+		if len(txn.TagIDs) > 0 {
+			return tx.replaceTransactionTags(ctx, txn.UserID, id, txn.TagIDs, false)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to create transaction: %w", err)
+		return 0, err
 	}
 	return id, nil
 }
 
 func (db *DB) GetTransactions(ctx context.Context, userID int) ([]Transaction, error) {
-	query := "SELECT id, amount, date, description, category_id, user_id FROM transactions WHERE user_id = $1"
-	rows, err := db.Conn.QueryContext(ctx, query, userID)
+	return db.GetTransactionsFiltered(ctx, userID, TransactionFilter{})
+}
+
+// GetTransaction returns an active transaction with its tags, or nil if it does
+// not exist or belongs to another user.
+func (db *DB) GetTransaction(ctx context.Context, userID, txnID int) (*Transaction, error) {
+	query := "SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+	var txn Transaction
+	var categoryID sql.NullInt64
+	err := db.q().QueryRowContext(ctx, query, txnID, userID).
+		Scan(&txn.ID, &txn.Amount, &txn.Date, &txn.Description, &categoryID, &txn.UserID, &txn.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	txn.CategoryID = int(categoryID.Int64)
+
+	rows, err := db.q().QueryContext(ctx, "SELECT tag_id FROM transaction_tags WHERE transaction_id = $1 ORDER BY tag_id", txnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tagID int
+		if err := rows.Scan(&tagID); err != nil {
+			return nil, err
+		}
+		txn.TagIDs = append(txn.TagIDs, tagID)
+	}
+	return &txn, rows.Err()
+}
+
+func (db *DB) GetTransactionsFiltered(ctx context.Context, userID int, filter TransactionFilter) ([]Transaction, error) {
+	query := "SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE user_id = $1 AND deleted_at IS NULL"
+	args := []any{userID}
+
+	if tagIDs := filter.tagIDs(); len(tagIDs) > 0 {
+		placeholders := make([]string, len(tagIDs))
+		for i, tagID := range tagIDs {
+			args = append(args, tagID)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+
+		sub := "SELECT transaction_id FROM transaction_tags WHERE tag_id IN (" + strings.Join(placeholders, ", ") + ")"
+		if filter.MatchAllTags {
+			args = append(args, len(tagIDs))
+			sub += fmt.Sprintf(" GROUP BY transaction_id HAVING COUNT(DISTINCT tag_id) = $%d", len(args))
+		}
+		query += " AND id IN (" + sub + ")"
+	}
+
+	rows, err := db.q().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
 	}
@@ -36,40 +139,244 @@ func (db *DB) GetTransactions(ctx context.Context, userID int) ([]Transaction, e
 	var transactions []Transaction
 	for rows.Next() {
 		var txn Transaction
-		if err := rows.Scan(&txn.ID, &txn.Amount, &txn.Date, &txn.Description, &txn.CategoryID, &txn.UserID); err != nil {
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&txn.ID, &txn.Amount, &txn.Date, &txn.Description, &categoryID, &txn.UserID, &txn.Version); err != nil {
 			return nil, err
 		}
+		txn.CategoryID = int(categoryID.Int64)
 		transactions = append(transactions, txn)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := db.loadTransactionTags(ctx, userID, transactions); err != nil {
+		return nil, err
+	}
 	return transactions, nil
 }
 
-func (db *DB) UpdateTransaction(ctx context.Context, txn Transaction) error {
-	query := "UPDATE transactions SET amount = $1, date = $2, description = $3, category_id = $4 WHERE id = $5 AND user_id = $6"
-	res, err := db.Conn.ExecContext(ctx, query, txn.Amount, txn.Date, txn.Description, txn.CategoryID, txn.ID, txn.UserID)
+// loadTransactionTags fills TagIDs of the given transactions.
+func (db *DB) loadTransactionTags(ctx context.Context, userID int, transactions []Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	query := `
+        SELECT tt.transaction_id, tt.tag_id
+        FROM transaction_tags tt
+        JOIN transactions t ON tt.transaction_id = t.id
+        WHERE t.user_id = $1 AND t.deleted_at IS NULL
+        ORDER BY tt.transaction_id, tt.tag_id
+    `
+	rows, err := db.q().QueryContext(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("failed to update transaction: %w", err)
+		return fmt.Errorf("failed to fetch transaction tags: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return fmt.Errorf("no transaction found or not authorized")
+	index := make(map[int]int, len(transactions))
+	for i, txn := range transactions {
+		index[txn.ID] = i
 	}
 
-	return nil
+	for rows.Next() {
+		var txnID, tagID int
+		if err := rows.Scan(&txnID, &tagID); err != nil {
+			return err
+		}
+		if i, ok := index[txnID]; ok {
+			transactions[i].TagIDs = append(transactions[i].TagIDs, tagID)
+		}
+	}
+	return rows.Err()
 }
 
-func (db *DB) DeleteTransaction(ctx context.Context, userID, txnID int) error {
-	query := "DELETE FROM transactions WHERE id = $1 AND user_id = $2"
-	res, err := db.Conn.ExecContext(ctx, query, txnID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to delete transaction: %w", err)
+// UpdateTransaction overwrites a transaction, keeping the stored date when
+// txn.Date is zero and the stored tags when txn.TagIDs is nil. A non-zero txn.Version must match the stored version,
+// otherwise a *VersionConflictError is returned.
+func (db *DB) UpdateTransaction(ctx context.Context, txn Transaction) error {
+	return db.audited(ctx, txn.UserID, EntityTransaction, txn.ID, AuditUpdate, func(tx *DB) error {
+		if txn.Version != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, txn.UserID, txn.ID, txn.Version); err != nil {
+				return err
+			}
+		}
+		if err := tx.checkSplitTotal(ctx, txn.ID, txn.Amount); err != nil {
+			return err
+		}
+		if err := tx.saveTransactionVersion(ctx, txn.UserID, txn.ID); err != nil {
+			return err
+		}
+
+		query := "UPDATE transactions SET amount = $1, date = COALESCE($2, date), description = $3, category_id = $4, version = version + 1 WHERE id = $5 AND user_id = $6 AND deleted_at IS NULL"
+		res, err := tx.q().ExecContext(ctx, query, txn.Amount, timeArg(txn.Date), txn.Description, categoryArg(txn.CategoryID), txn.ID, txn.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+
+		if txn.TagIDs != nil {
+			return tx.replaceTransactionTags(ctx, txn.UserID, txn.ID, txn.TagIDs, false)
+		}
+		return nil
+	})
+}
+
+// PatchTransaction changes only the fields set in patch. A non-zero
+// expectedVersion must match the stored version, otherwise a
+// *VersionConflictError is returned. An empty patch changes nothing.
+func (db *DB) PatchTransaction(ctx context.Context, userID, txnID int, patch TransactionPatch, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityTransaction, txnID, AuditUpdate, func(tx *DB) error {
+		if err := tx.lockTransaction(ctx, userID, txnID); err != nil {
+			return err
+		}
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+
+		var set []string
+		var args []any
+		add := func(column string, value any) {
+			args = append(args, value)
+			set = append(set, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+
+		if patch.Amount != nil {
+			if err := tx.checkSplitTotal(ctx, txnID, *patch.Amount); err != nil {
+				return err
+			}
+			add("amount", *patch.Amount)
+		}
+		if patch.Date != nil {
+			add("date", *patch.Date)
+		}
+		if patch.Description != nil {
+			add("description", *patch.Description)
+		}
+		if patch.ClearCategory {
+			set = append(set, "category_id = NULL")
+		} else if patch.CategoryID != nil {
+			add("category_id", *patch.CategoryID)
+		}
+		if len(set) > 0 {
+			if err := tx.saveTransactionVersion(ctx, userID, txnID); err != nil {
+				return err
+			}
+
+			args = append(args, txnID, userID)
+			query := fmt.Sprintf("UPDATE transactions SET %s, version = version + 1 WHERE id = $%d AND user_id = $%d AND deleted_at IS NULL",
+				strings.Join(set, ", "), len(args)-1, len(args))
+			if _, err := tx.q().ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("failed to update transaction: %w", err)
+			}
+		}
+
+		// Changing only the tags bumps the version on its own
+		if patch.TagIDs != nil {
+			return tx.replaceTransactionTags(ctx, userID, txnID, patch.TagIDs, len(set) == 0)
+		}
+		return nil
+	})
+}
+
+// timeArg passes a time as a query argument, mapping the zero time to NULL.
+func timeArg(t time.Time) any {
+	if t.IsZero() {
+		return nil
 	}
+	return t
+}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return fmt.Errorf("no transaction found or not authorized")
+// categoryArg passes a category ID as a query argument, mapping 0 (no
+// category) to NULL.
+func categoryArg(categoryID int) any {
+	if categoryID == 0 {
+		return nil
 	}
+	return categoryID
+}
 
+// lockTransaction makes sure an active transaction belongs to userID and locks
+// it until the surrounding transaction ends.
+func (db *DB) lockTransaction(ctx context.Context, userID, txnID int) error {
+	query := "SELECT id FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE"
+	var id int
+	err := db.q().QueryRowContext(ctx, query, txnID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Entity: EntityTransaction}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
 	return nil
 }
+
+// DeleteTransaction moves a transaction to the trash. A non-zero expectedVersion
+// must match the stored version, otherwise a *VersionConflictError is returned.
+func (db *DB) DeleteTransaction(ctx context.Context, userID, txnID, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityTransaction, txnID, AuditDelete, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+		if err := tx.saveTransactionVersion(ctx, userID, txnID); err != nil {
+			return err
+		}
+
+		query := "UPDATE transactions SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+		res, err := tx.q().ExecContext(ctx, query, txnID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete transaction: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+
+		return nil
+	})
+}
+
+// RecategoriseTransaction moves a transaction to categoryID, which must be an
+// active category of userID, or clears its category when categoryID is 0. A
+// non-zero expectedVersion must match the stored version, otherwise a
+// *VersionConflictError is returned.
+func (db *DB) RecategoriseTransaction(ctx context.Context, userID, txnID, categoryID, expectedVersion int) error {
+	return db.audited(ctx, userID, EntityTransaction, txnID, AuditUpdate, func(tx *DB) error {
+		if expectedVersion != 0 {
+			if err := tx.checkVersion(ctx, EntityTransaction, userID, txnID, expectedVersion); err != nil {
+				return err
+			}
+		}
+		if categoryID != 0 {
+			if err := tx.lockCategory(ctx, userID, categoryID); err != nil {
+				return err
+			}
+		}
+		if err := tx.saveTransactionVersion(ctx, userID, txnID); err != nil {
+			return err
+		}
+
+		query := "UPDATE transactions SET category_id = $1, version = version + 1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL"
+		res, err := tx.q().ExecContext(ctx, query, categoryArg(categoryID), txnID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to recategorise transaction: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityTransaction}
+		}
+
+		return nil
+	})
+}
@@ -24,9 +24,13 @@ func TestCreateTransaction(t *testing.T) {
 		UserID:      1,
 	}
 
+	mock.ExpectBegin()
 	mock.ExpectQuery("INSERT INTO transactions").
 		WithArgs(mockTransaction.Amount, mockTransaction.Date, mockTransaction.Description, mockTransaction.CategoryID, mockTransaction.UserID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"amount":100.5}`)
+	expectAudit(mock, 1, EntityTransaction, 1, AuditCreate)
+	mock.ExpectCommit()
 
 	id, err := r.CreateTransaction(context.Background(), mockTransaction)
 	assert.NoError(t, err)
@@ -42,17 +46,24 @@ func TestGetTransactions(t *testing.T) {
 
 	r := &DB{Conn: db}
 
-	mock.ExpectQuery("SELECT id, amount, date, description, category_id, user_id FROM transactions WHERE user_id = \\$1").
+	mock.ExpectQuery("SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE user_id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id"}).
-			AddRow(1, 100.50, time.Now(), "Groceries", 1, 1).
-			AddRow(2, -50.00, time.Now(), "Entertainment", 2, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version"}).
+			AddRow(1, 100.50, time.Now(), "Groceries", 1, 1, 1).
+			AddRow(2, -50.00, time.Now(), "Entertainment", 2, 1, 3))
+	mock.ExpectQuery("SELECT tt.transaction_id, tt.tag_id FROM transaction_tags tt").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_id", "tag_id"}).
+			AddRow(1, 3).
+			AddRow(1, 4))
 
 	transactions, err := r.GetTransactions(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.Len(t, transactions, 2)
 	assert.Equal(t, 100.50, transactions[0].Amount)
 	assert.Equal(t, "Groceries", transactions[0].Description)
+	assert.Equal(t, []int{3, 4}, transactions[0].TagIDs)
+	assert.Empty(t, transactions[1].TagIDs)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -73,9 +84,18 @@ func TestUpdateTransaction(t *testing.T) {
 		UserID:      1,
 	}
 
-	mock.ExpectExec("UPDATE transactions SET amount = \\$1, date = \\$2, description = \\$3, category_id = \\$4 WHERE id = \\$5 AND user_id = \\$6").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"amount":100.5}`)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(amount\\), 0\\) FROM transaction_splits WHERE transaction_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "total"}).AddRow(0, 0))
+	expectSaveVersion(mock, mockTransaction.ID, mockTransaction.UserID)
+	mock.ExpectExec("UPDATE transactions SET amount = \\$1, date = COALESCE\\(\\$2, date\\), description = \\$3, category_id = \\$4, version = version \\+ 1 WHERE id = \\$5 AND user_id = \\$6").
 		WithArgs(mockTransaction.Amount, mockTransaction.Date, mockTransaction.Description, mockTransaction.CategoryID, mockTransaction.ID, mockTransaction.UserID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"amount":150.75}`)
+	expectAudit(mock, 1, EntityTransaction, 1, AuditUpdate)
+	mock.ExpectCommit()
 
 	err = r.UpdateTransaction(context.Background(), mockTransaction)
 	assert.NoError(t, err)
@@ -90,11 +110,133 @@ func TestDeleteTransaction(t *testing.T) {
 
 	r := &DB{Conn: db}
 
-	mock.ExpectExec("DELETE FROM transactions WHERE id = \\$1 AND user_id = \\$2").
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"deleted_at":null}`)
+	expectSaveVersion(mock, 1, 1)
+	mock.ExpectExec("UPDATE transactions SET deleted_at = NOW\\(\\), version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"deleted_at":"2026-10-19T10:00:00"}`)
+	expectAudit(mock, 1, EntityTransaction, 1, AuditDelete)
+	mock.ExpectCommit()
+
+	err = r.DeleteTransaction(context.Background(), 1, 1, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecategoriseTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"category_id":1}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectSaveVersion(mock, 1, 1)
+	mock.ExpectExec("UPDATE transactions SET category_id = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
+		WithArgs(2, 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"category_id":2}`)
+	expectAudit(mock, 1, EntityTransaction, 1, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.RecategoriseTransaction(context.Background(), 1, 1, 2, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version"}).
+			AddRow(1, 100.50, time.Now(), "Groceries", 1, 1, 2))
+	mock.ExpectQuery("SELECT tag_id FROM transaction_tags WHERE transaction_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id"}).AddRow(3).AddRow(4))
+
+	txn, err := r.GetTransaction(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, txn)
+	assert.Equal(t, 2, txn.Version)
+	assert.Equal(t, []int{3, 4}, txn.TagIDs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTransactionNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT id, amount, date, description, category_id, user_id, version FROM transactions WHERE id = \\$1").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version"}))
+
+	txn, err := r.GetTransaction(context.Background(), 1, 9)
+	assert.NoError(t, err)
+	assert.Nil(t, txn)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	description := "Dinner"
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"description":"Lunch","category_id":2}`)
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL FOR UPDATE").
 		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSaveVersion(mock, 1, 1)
+	mock.ExpectExec("UPDATE transactions SET description = \\$1, category_id = NULL, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3 AND deleted_at IS NULL").
+		WithArgs("Dinner", 1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1,"description":"Dinner","category_id":null}`)
+	expectAudit(mock, 1, EntityTransaction, 1, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.PatchTransaction(context.Background(), 1, 1, TransactionPatch{Description: &description, ClearCategory: true}, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchTransactionEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1}`)
+	mock.ExpectQuery("SELECT id FROM transactions WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityTransaction, 1, `{"id":1}`)
+	mock.ExpectCommit()
 
-	err = r.DeleteTransaction(context.Background(), 1, 1)
+	err = r.PatchTransaction(context.Background(), 1, 1, TransactionPatch{}, 0)
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
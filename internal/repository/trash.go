@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Trash holds the soft-deleted records of a user.
+type Trash struct {
+	Transactions []Transaction `json:"transactions"`
+	Categories   []Category    `json:"categories"`
+}
+
+func (db *DB) GetTrash(ctx context.Context, userID int) (*Trash, error) {
+	trash := &Trash{Transactions: []Transaction{}, Categories: []Category{}}
+
+	query := `
+        SELECT id, amount, date, description, category_id, user_id, version, deleted_at
+        FROM transactions
+        WHERE user_id = $1 AND deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC
+    `
+	rows, err := db.q().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txn Transaction
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&txn.ID, &txn.Amount, &txn.Date, &txn.Description, &categoryID, &txn.UserID, &txn.Version, &txn.DeletedAt); err != nil {
+			return nil, err
+		}
+		txn.CategoryID = int(categoryID.Int64)
+		trash.Transactions = append(trash.Transactions, txn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = "SELECT id, name, version, deleted_at FROM categories WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC"
+	rows, err = db.q().QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.Version, &category.DeletedAt); err != nil {
+			return nil, err
+		}
+		trash.Categories = append(trash.Categories, category)
+	}
+	return trash, rows.Err()
+}
+
+// RestoreTransaction moves a transaction out of the trash. It fails with a
+// *ConflictError while its category, or the category of one of its split
+// lines, is still in the trash.
+func (db *DB) RestoreTransaction(ctx context.Context, userID, txnID int) error {
+	return db.audited(ctx, userID, EntityTransaction, txnID, AuditRestore, func(tx *DB) error {
+		if err := tx.checkCategoriesRestored(ctx, userID, txnID); err != nil {
+			return err
+		}
+		if err := tx.saveTransactionVersion(ctx, userID, txnID); err != nil {
+			return err
+		}
+
+		query := "UPDATE transactions SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL"
+		res, err := tx.q().ExecContext(ctx, query, txnID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to restore transaction: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: "deleted transaction"}
+		}
+
+		return nil
+	})
+}
+
+// checkCategoriesRestored locks the categories a transaction of userID uses
+// directly or through its split lines and fails if any of them is in the trash.
+func (db *DB) checkCategoriesRestored(ctx context.Context, userID, txnID int) error {
+	query := `
+        SELECT deleted_at IS NOT NULL FROM categories
+        WHERE user_id = $2
+          AND id IN (SELECT category_id FROM transactions WHERE id = $1
+                     UNION SELECT category_id FROM transaction_splits WHERE transaction_id = $1)
+        FOR UPDATE
+    `
+	rows, err := db.q().QueryContext(ctx, query, txnID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trashed bool
+		if err := rows.Scan(&trashed); err != nil {
+			return err
+		}
+		if trashed {
+			return errCategoryInTrash
+		}
+	}
+	return rows.Err()
+}
+
+func (db *DB) RestoreCategory(ctx context.Context, userID, categoryID int) error {
+	return db.audited(ctx, userID, EntityCategory, categoryID, AuditRestore, func(tx *DB) error {
+		query := "UPDATE categories SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL"
+		res, err := tx.q().ExecContext(ctx, query, categoryID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to restore category: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: "deleted category"}
+		}
+
+		return nil
+	})
+}
+
+// errCategoryInTrash is returned when restoring a transaction whose category
+// has not been restored yet.
+var errCategoryInTrash = &ConflictError{Entity: EntityTransaction, Reason: "the category of the transaction is in the trash, restore it first"}
+
+// PurgeDeleted permanently removes transactions and categories of all users
+// that were moved to the trash before the cutoff, recording their attachments,
+// split lines and tags in the audit log too. Categories still used by a
+// remaining transaction or split line stay in the trash until it is purged.
+// It returns the attachments of the purged transactions so their files can be
+// removed from storage.
+func (db *DB) PurgeDeleted(ctx context.Context, before time.Time) ([]Attachment, error) {
+	var attachments []Attachment
+
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		query := `
+            SELECT a.id, a.transaction_id, a.user_id, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+            FROM attachments a
+            JOIN transactions t ON a.transaction_id = t.id
+            WHERE t.deleted_at < $1
+        `
+		rows, err := tx.q().QueryContext(ctx, query, before)
+		if err != nil {
+			return fmt.Errorf("failed to fetch purged attachments: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var a Attachment
+			if err := rows.Scan(&a.ID, &a.TransactionID, &a.UserID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+				return err
+			}
+			attachments = append(attachments, a)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if tx.dialect == SQLite {
+			return tx.sqlitePurge(ctx, before)
+		}
+
+		// Rows removed along with the transactions by ON DELETE CASCADE
+		query = `
+            INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+            SELECT t.user_id, 'attachment', x.id, 'purge', row_to_json(x)
+            FROM (SELECT id, transaction_id, filename, content_type, size, created_at FROM attachments) x
+            JOIN transactions t ON x.transaction_id = t.id WHERE t.deleted_at < $1
+            UNION ALL
+            SELECT t.user_id, 'split', x.id, 'purge', row_to_json(x)
+            FROM (SELECT id, transaction_id, category_id, amount, note FROM transaction_splits) x
+            JOIN transactions t ON x.transaction_id = t.id WHERE t.deleted_at < $1
+            UNION ALL
+            SELECT t.user_id, 'transaction_tags', t.id, 'purge', json_agg(tt.tag_id ORDER BY tt.tag_id)
+            FROM transaction_tags tt JOIN transactions t ON tt.transaction_id = t.id WHERE t.deleted_at < $1
+            GROUP BY t.id, t.user_id
+        `
+		if _, err := tx.q().ExecContext(ctx, query, before); err != nil {
+			return fmt.Errorf("failed to purge transactions: %w", err)
+		}
+
+		query = `
+            WITH gone AS (
+                DELETE FROM transactions WHERE deleted_at < $1
+                RETURNING id, amount, date, description, category_id, user_id, deleted_at, version
+            )
+            INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+            SELECT gone.user_id, 'transaction', gone.id, 'purge', row_to_json(gone) FROM gone
+        `
+		if _, err := tx.q().ExecContext(ctx, query, before); err != nil {
+			return fmt.Errorf("failed to purge transactions: %w", err)
+		}
+
+		query = `
+            WITH gone AS (
+                DELETE FROM categories WHERE deleted_at < $1 AND ` + categoryUnused + `
+                RETURNING id, name, user_id, deleted_at
+            )
+            INSERT INTO audit_log (user_id, entity, entity_id, action, before)
+            SELECT gone.user_id, 'category', gone.id, 'purge', row_to_json(gone) FROM gone
+        `
+		if _, err := tx.q().ExecContext(ctx, query, before); err != nil {
+			return fmt.Errorf("failed to purge categories: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// categoryUnused keeps categories that no transaction or split line refers to,
+// so purging never clears the category of a row behind the audit log's back.
+const categoryUnused = `
+    NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.category_id = categories.id)
+    AND NOT EXISTS (SELECT 1 FROM transaction_splits WHERE transaction_splits.category_id = categories.id)
+`
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	deletedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM transactions WHERE user_id = \\$1 AND deleted_at IS NOT NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version", "deleted_at"}).
+			AddRow(7, 42.0, deletedAt, "Coffee", 2, 1, 2, deletedAt))
+	mock.ExpectQuery("SELECT id, name, version, deleted_at FROM categories WHERE user_id = \\$1 AND deleted_at IS NOT NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "version", "deleted_at"}))
+
+	trash, err := r.GetTrash(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Transactions, 1)
+	assert.True(t, deletedAt.Equal(*trash.Transactions[0].DeletedAt))
+	assert.Empty(t, trash.Categories)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 7, `{"id":7,"deleted_at":"2026-10-01T12:00:00"}`)
+	mock.ExpectQuery("SELECT deleted_at IS NOT NULL FROM categories").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"trashed"}).AddRow(false))
+	expectSaveVersion(mock, 7, 1)
+	mock.ExpectExec("UPDATE transactions SET deleted_at = NULL, version = version \\+ 1 WHERE id = \\$1 AND user_id = \\$2 AND deleted_at IS NOT NULL").
+		WithArgs(7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 7, `{"id":7,"deleted_at":null}`)
+	expectAudit(mock, 1, EntityTransaction, 7, AuditRestore)
+	mock.ExpectCommit()
+
+	err = r.RestoreTransaction(context.Background(), 1, 7)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTransactionCategoryInTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 7, `{"id":7,"deleted_at":"2026-10-01T12:00:00"}`)
+	mock.ExpectQuery("SELECT deleted_at IS NOT NULL FROM categories").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"trashed"}).AddRow(false).AddRow(true))
+	mock.ExpectRollback()
+
+	err = r.RestoreTransaction(context.Background(), 1, 7)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreCategoryNotInTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 3, `{"id":3,"deleted_at":null}`)
+	mock.ExpectExec("UPDATE categories SET deleted_at = NULL").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = r.RestoreCategory(context.Background(), 1, 3)
+	assert.EqualError(t, err, "no deleted category found or not authorized")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	before := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM attachments a JOIN transactions t ON a.transaction_id = t.id WHERE t.deleted_at < \\$1").
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "user_id", "filename", "content_type", "size", "storage_key", "created_at"}).
+			AddRow(3, 7, 1, "receipt.pdf", "application/pdf", 1024, "users/1/transactions/7/abc", before))
+	mock.ExpectExec("INSERT INTO audit_log (.+) 'attachment'(.+) UNION ALL (.+) 'split'(.+) UNION ALL (.+) 'transaction_tags'").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("WITH gone AS \\( DELETE FROM transactions WHERE deleted_at < \\$1 (.+) INSERT INTO audit_log").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("WITH gone AS \\( DELETE FROM categories WHERE deleted_at < \\$1 (.+) INSERT INTO audit_log").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	attachments, err := r.PurgeDeleted(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, "users/1/transactions/7/abc", attachments[0].StorageKey)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
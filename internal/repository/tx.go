@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithTx runs fn inside a database transaction. The Repository passed to fn is
+// bound to that transaction: the transaction is committed if fn returns nil and
+// rolled back otherwise. Calling WithTx on a Repository that is already bound to
+// a transaction reuses it, so fn may itself call helpers that use WithTx.
+func (db *DB) WithTx(ctx context.Context, fn func(Repository) error) error {
+	if db.inTx() {
+		return fn(db)
+	}
+
+	txDB, err := db.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer txDB.rollback(ctx)
+
+	if err := fn(txDB); err != nil {
+		return err
+	}
+
+	if err := txDB.commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTxCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO categories").
+		WithArgs("Groceries", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditCreate)
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"category_id":null}`)
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSaveVersion(mock, 5, 1)
+	mock.ExpectExec("UPDATE transactions SET category_id = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3").
+		WithArgs(1, 5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"category_id":1}`)
+	expectAudit(mock, 1, EntityTransaction, 5, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.WithTx(context.Background(), func(tx Repository) error {
+		id, err := tx.CreateCategory(context.Background(), 1, "Groceries")
+		if err != nil {
+			return err
+		}
+		return tx.RecategoriseTransaction(context.Background(), 1, 5, id, 0)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	failure := errors.New("boom")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO categories").
+		WithArgs("Groceries", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityCategory, 1, `{"id":1,"name":"Groceries"}`)
+	expectAudit(mock, 1, EntityCategory, 1, AuditCreate)
+	mock.ExpectRollback()
+
+	err = r.WithTx(context.Background(), func(tx Repository) error {
+		if _, err := tx.CreateCategory(context.Background(), 1, "Groceries"); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxNested(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityCategory, 2, `{"id":2,"name":"Groceries"}`)
+	mock.ExpectExec("UPDATE categories SET name = \\$1, version = version \\+ 1 WHERE id = \\$2 AND user_id = \\$3 AND deleted_at IS NULL").
+		WithArgs("Food", 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityCategory, 2, `{"id":2,"name":"Food"}`)
+	expectAudit(mock, 1, EntityCategory, 2, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.WithTx(context.Background(), func(tx Repository) error {
+		return tx.WithTx(context.Background(), func(inner Repository) error {
+			return inner.UpdateCategory(context.Background(), 1, 2, "Food", 0)
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -14,19 +14,30 @@ type User struct {
 }
 
 func (db *DB) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
-	query := "INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id"
 	var id int
-	err := db.Conn.QueryRowContext(ctx, query, username, passwordHash).Scan(&id)
+	err := db.WithTx(ctx, func(r Repository) error {
+		tx := r.(*DB)
+
+		query := "INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id"
+		if err := tx.q().QueryRowContext(ctx, query, username, passwordHash).Scan(&id); err != nil {
+			if isUniqueViolation(err) {
+				return &ConflictError{Entity: EntityUser, Reason: "username is already taken"}
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return tx.recordCreate(ctx, id, EntityUser, id)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to create user: %w", err)
+		return 0, err
 	}
 	return id, nil
 }
 
+const userByUsernameQuery = "SELECT id, username, password_hash, created_at FROM users WHERE username = $1"
+
 func (db *DB) GetUserByUsername(ctx context.Context, username string) (*User, error) {
-	query := "SELECT id, username, password_hash, created_at FROM users WHERE username = $1"
 	var user User
-	err := db.Conn.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.CreatedAt)
+	err := db.q().QueryRowContext(ctx, userByUsernameQuery, username).Scan(&user.ID, &user.Username, &user.Password, (*timeText)(&user.CreatedAt))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -35,3 +46,33 @@ func (db *DB) GetUserByUsername(ctx context.Context, username string) (*User, er
 	}
 	return &user, nil
 }
+
+const userTimezoneQuery = "SELECT timezone FROM users WHERE id = $1"
+
+// GetUserTimezone returns the IANA timezone name the user's dates are read in.
+func (db *DB) GetUserTimezone(ctx context.Context, userID int) (string, error) {
+	var timezone string
+	if err := db.q().QueryRowContext(ctx, userTimezoneQuery, userID).Scan(&timezone); err != nil {
+		return "", fmt.Errorf("failed to get user timezone: %w", err)
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone stores the IANA timezone name of the user. The name is not
+// validated here.
+func (db *DB) SetUserTimezone(ctx context.Context, userID int, timezone string) error {
+	return db.audited(ctx, userID, EntityUser, userID, AuditUpdate, func(tx *DB) error {
+		query := "UPDATE users SET timezone = $1 WHERE id = $2"
+		res, err := tx.q().ExecContext(ctx, query, timezone, userID)
+		if err != nil {
+			return fmt.Errorf("failed to set user timezone: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return &NotFoundError{Entity: EntityUser}
+		}
+
+		return nil
+	})
+}
@@ -17,9 +17,13 @@ func TestCreateUser(t *testing.T) {
 
 	r := &DB{Conn: db}
 
+	mock.ExpectBegin()
 	mock.ExpectQuery("INSERT INTO users").
 		WithArgs("testuser", "hashedpassword").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectSnapshot(mock, EntityUser, 1, `{"id":1,"username":"testuser"}`)
+	expectAudit(mock, 1, EntityUser, 1, AuditCreate)
+	mock.ExpectCommit()
 
 	id, err := r.CreateUser(context.Background(), "testuser", "hashedpassword")
 	assert.NoError(t, err)
@@ -35,9 +39,11 @@ func TestCreateUserError(t *testing.T) {
 
 	r := &DB{Conn: db}
 
+	mock.ExpectBegin()
 	mock.ExpectQuery("INSERT INTO users").
 		WithArgs("testuser", "hashedpassword").
 		WillReturnError(fmt.Errorf("db error"))
+	mock.ExpectRollback()
 
 	id, err := r.CreateUser(context.Background(), "testuser", "hashedpassword")
 	assert.Error(t, err)
@@ -106,3 +112,43 @@ func TestGetUserByUsernameError(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetUserTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectQuery("SELECT timezone FROM users WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("Europe/Moscow"))
+
+	timezone, err := r.GetUserTimezone(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", timezone)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetUserTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityUser, 1, `{"id":1,"timezone":"UTC"}`)
+	mock.ExpectExec("UPDATE users SET timezone = \\$1 WHERE id = \\$2").
+		WithArgs("Europe/Moscow", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityUser, 1, `{"id":1,"timezone":"Europe/Moscow"}`)
+	expectAudit(mock, 1, EntityUser, 1, AuditUpdate)
+	mock.ExpectCommit()
+
+	err = r.SetUserTimezone(context.Background(), 1, "Europe/Moscow")
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
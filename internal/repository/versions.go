@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrVersionNotFound is returned when undoing to a version that does not exist.
+var ErrVersionNotFound = &NotFoundError{Entity: "transaction version"}
+
+// TransactionVersion is a previous state of a transaction. ReplacedAt is the
+// moment the version was superseded. Splits and TagIDs are the split lines and
+// tags the transaction had then; both are nil for versions saved before they
+// were kept.
+type TransactionVersion struct {
+	Version     int        `json:"version"`
+	Amount      float64    `json:"amount"`
+	Date        time.Time  `json:"date"`
+	Description string     `json:"description"`
+	CategoryID  int        `json:"category_id"`
+	Splits      []Split    `json:"splits,omitempty"`
+	TagIDs      []int      `json:"tag_ids,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ReplacedAt  time.Time  `json:"replaced_at"`
+}
+
+// decodeSnapshot fills Splits and TagIDs from the JSON documents saved with
+// the version, leaving them nil when the documents are NULL.
+func (v *TransactionVersion) decodeSnapshot(splits, tagIDs []byte) error {
+	if splits != nil {
+		v.Splits = []Split{}
+		if err := json.Unmarshal(splits, &v.Splits); err != nil {
+			return fmt.Errorf("failed to decode version splits: %w", err)
+		}
+		slices.SortFunc(v.Splits, func(a, b Split) int { return a.ID - b.ID })
+	}
+	if tagIDs != nil {
+		v.TagIDs = []int{}
+		if err := json.Unmarshal(tagIDs, &v.TagIDs); err != nil {
+			return fmt.Errorf("failed to decode version tags: %w", err)
+		}
+		slices.Sort(v.TagIDs)
+	}
+	return nil
+}
+
+// TransactionHistory is the current state of a transaction, trashed or not,
+// together with its previous versions, newest first.
+type TransactionHistory struct {
+	Current  Transaction          `json:"current"`
+	Versions []TransactionVersion `json:"versions"`
+}
+
+// GetTransactionHistory returns the history of a transaction, or nil if it does
+// not exist or belongs to another user.
+func (db *DB) GetTransactionHistory(ctx context.Context, userID, txnID int) (*TransactionHistory, error) {
+	history := &TransactionHistory{Versions: []TransactionVersion{}}
+
+	query := `
+        SELECT id, amount, date, COALESCE(description, ''), COALESCE(category_id, 0), user_id, version, deleted_at
+        FROM transactions
+        WHERE id = $1 AND user_id = $2
+    `
+	cur := &history.Current
+	err := db.q().QueryRowContext(ctx, query, txnID, userID).
+		Scan(&cur.ID, &cur.Amount, &cur.Date, &cur.Description, &cur.CategoryID, &cur.UserID, &cur.Version, &cur.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+
+	query = `
+        SELECT version, amount, date, COALESCE(description, ''), COALESCE(category_id, 0), splits, tag_ids, deleted_at, created_at
+        FROM transaction_versions
+        WHERE transaction_id = $1
+        ORDER BY version DESC
+    `
+	rows, err := db.q().QueryContext(ctx, query, txnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction versions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v TransactionVersion
+		var splits, tagIDs []byte
+		if err := rows.Scan(&v.Version, &v.Amount, &v.Date, &v.Description, &v.CategoryID, &splits, &tagIDs, &v.DeletedAt, &v.ReplacedAt); err != nil {
+			return nil, err
+		}
+		if err := v.decodeSnapshot(splits, tagIDs); err != nil {
+			return nil, err
+		}
+		history.Versions = append(history.Versions, v)
+	}
+	return history, rows.Err()
+}
+
+// Errors of UndoTransaction for versions it cannot bring back as they were.
+var (
+	errUndoNoSnapshot   = &ConflictError{Entity: EntityTransaction, Reason: "that version was saved without its split lines and tags and cannot be undone"}
+	errUndoCategoryGone = &ConflictError{Entity: EntityTransaction, Reason: "a category of that version is in the trash or no longer exists"}
+	errUndoTagGone      = &ConflictError{Entity: EntityTransaction, Reason: "a tag of that version no longer exists"}
+)
+
+// UndoTransaction brings a transaction back to a previous version, including
+// its split lines and tags, which also undeletes it when that version was not
+// trashed. A non-zero expectedVersion must match the current version, otherwise
+// a *VersionConflictError is returned; toVersion 0 means the version right
+// before the current one. A *ConflictError is returned when a category or tag of
+// toVersion is no longer active, or when toVersion was saved without its split
+// lines and tags. The restored state is saved as a new version, whose number is
+// returned.
+func (db *DB) UndoTransaction(ctx context.Context, userID, txnID, expectedVersion, toVersion int) (int, error) {
+	var newVersion int
+	err := db.audited(ctx, userID, EntityTransaction, txnID, AuditUndo, func(tx *DB) error {
+		current, err := tx.currentVersion(ctx, EntityTransaction, userID, txnID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion == 0 {
+			expectedVersion = current
+		}
+		if current != expectedVersion {
+			return &VersionConflictError{Entity: EntityTransaction, ID: txnID, Expected: expectedVersion, Current: current}
+		}
+
+		if toVersion == 0 {
+			toVersion = expectedVersion - 1
+		}
+		if toVersion < 1 || toVersion >= expectedVersion {
+			return ErrVersionNotFound
+		}
+
+		var amount float64
+		var date sql.NullTime
+		var description sql.NullString
+		var categoryID sql.NullInt64
+		var deletedAt sql.NullTime
+		var splits, tagIDs []byte
+		query := `
+            SELECT amount, date, description, category_id, deleted_at, splits, tag_ids
+            FROM transaction_versions
+            WHERE transaction_id = $1 AND version = $2
+        `
+		err = tx.q().QueryRowContext(ctx, query, txnID, toVersion).Scan(&amount, &date, &description, &categoryID, &deletedAt, &splits, &tagIDs)
+		if err == sql.ErrNoRows {
+			return ErrVersionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch transaction version: %w", err)
+		}
+		if splits == nil || tagIDs == nil {
+			return errUndoNoSnapshot
+		}
+		var target TransactionVersion
+		if err := target.decodeSnapshot(splits, tagIDs); err != nil {
+			return err
+		}
+
+		categories := []int{int(categoryID.Int64)}
+		for _, split := range target.Splits {
+			categories = append(categories, split.CategoryID)
+		}
+		for _, id := range categories {
+			if id == 0 {
+				continue
+			}
+			if err := tx.lockCategory(ctx, userID, id); errors.Is(err, ErrNotFound) {
+				return errUndoCategoryGone
+			} else if err != nil {
+				return err
+			}
+		}
+		if err := tx.saveTransactionVersion(ctx, userID, txnID); err != nil {
+			return err
+		}
+
+		query = `
+            UPDATE transactions
+            SET amount = $1, date = $2, description = $3, category_id = $4, deleted_at = $5, version = version + 1
+            WHERE id = $6 AND user_id = $7
+            RETURNING version
+        `
+		err = tx.q().QueryRowContext(ctx, query, amount, date, description, categoryID, deletedAt, txnID, userID).Scan(&newVersion)
+		if err != nil {
+			return fmt.Errorf("failed to undo transaction: %w", err)
+		}
+
+		lines, err := tx.splitLines(ctx, txnID)
+		if err != nil {
+			return err
+		}
+		if !sameSplits(lines, target.Splits) {
+			if err := tx.writeSplits(ctx, userID, txnID, target.Splits); err != nil {
+				return err
+			}
+		}
+		return tx.restoreTransactionTags(ctx, userID, txnID, target.TagIDs)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return newVersion, nil
+}
+
+// splitLines returns the split lines of txnID, whatever its state.
+func (db *DB) splitLines(ctx context.Context, txnID int) ([]Split, error) {
+	query := "SELECT id, transaction_id, category_id, amount, note FROM transaction_splits WHERE transaction_id = $1 ORDER BY id"
+	rows, err := db.q().QueryContext(ctx, query, txnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch splits: %w", err)
+	}
+	defer rows.Close()
+
+	var splits []Split
+	for rows.Next() {
+		var split Split
+		var categoryID sql.NullInt64
+		var note sql.NullString
+		if err := rows.Scan(&split.ID, &split.TransactionID, &categoryID, &split.Amount, &note); err != nil {
+			return nil, err
+		}
+		split.CategoryID = int(categoryID.Int64)
+		split.Note = note.String
+		splits = append(splits, split)
+	}
+	return splits, rows.Err()
+}
+
+// sameSplits reports whether two lists of split lines assign the same amounts
+// to the same categories, in the same order, ignoring their IDs.
+func sameSplits(a, b []Split) bool {
+	return slices.EqualFunc(a, b, func(x, y Split) bool {
+		return x.CategoryID == y.CategoryID && sameAmount(x.Amount, y.Amount) && x.Note == y.Note
+	})
+}
+
+// restoreTransactionTags sets the tags of txnID, whatever its state, to those
+// of a version, failing with a *ConflictError if one of them was deleted since.
+func (db *DB) restoreTransactionTags(ctx context.Context, userID, txnID int, tagIDs []int) error {
+	return db.audited(ctx, userID, EntityTransactionTags, txnID, AuditUpdate, func(tx *DB) error {
+		if _, err := tx.q().ExecContext(ctx, "DELETE FROM transaction_tags WHERE transaction_id = $1", txnID); err != nil {
+			return fmt.Errorf("failed to clear transaction tags: %w", err)
+		}
+
+		query := "INSERT INTO transaction_tags (transaction_id, tag_id) SELECT $1, id FROM tags WHERE id = $2 AND user_id = $3"
+		for _, tagID := range tagIDs {
+			res, err := tx.q().ExecContext(ctx, query, txnID, tagID, userID)
+			if err != nil {
+				return fmt.Errorf("failed to tag transaction: %w", err)
+			}
+			rowsAffected, err := res.RowsAffected()
+			if err != nil || rowsAffected == 0 {
+				return errUndoTagGone
+			}
+		}
+		return nil
+	})
+}
+
+// bumpTransactionVersion saves the current state of an active transaction in
+// its history and raises its version. It is called before changing the split
+// lines or tags of the transaction, which are served under its version too.
+func (db *DB) bumpTransactionVersion(ctx context.Context, userID, txnID int) error {
+	if err := db.saveTransactionVersion(ctx, userID, txnID); err != nil {
+		return err
+	}
+
+	query := "UPDATE transactions SET version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+	res, err := db.q().ExecContext(ctx, query, txnID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to bump transaction version: %w", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return &NotFoundError{Entity: EntityTransaction}
+	}
+	return nil
+}
+
+// saveTransactionVersion copies the current state of a transaction, with its
+// split lines and tags, into its history. Callers bump transactions.version in
+// the same database transaction.
+func (db *DB) saveTransactionVersion(ctx context.Context, userID, txnID int) error {
+	splits, tagIDs := splitsSnapshot, tagsSnapshot
+	if db.dialect == SQLite {
+		splits, tagIDs = sqliteSplitsSnapshot, sqliteTagsSnapshot
+	}
+
+	query := fmt.Sprintf(`
+        INSERT INTO transaction_versions (transaction_id, version, amount, date, description, category_id, deleted_at, splits, tag_ids)
+        SELECT t.id, t.version, t.amount, t.date, t.description, t.category_id, t.deleted_at, %s, %s
+        FROM transactions t
+        WHERE t.id = $1 AND t.user_id = $2
+    `, fmt.Sprintf(splits, "t.id"), fmt.Sprintf(tagIDs, "t.id"))
+	if _, err := db.q().ExecContext(ctx, query, txnID, userID); err != nil {
+		return fmt.Errorf("failed to save transaction version: %w", err)
+	}
+	return nil
+}
+
+// Subqueries building the split lines and tags saved with a version of the
+// transaction whose ID is in the column given as their only verb.
+const (
+	splitsSnapshot       = "(SELECT COALESCE(jsonb_agg(jsonb_build_object('id', s.id, 'transaction_id', s.transaction_id, 'category_id', s.category_id, 'amount', s.amount, 'note', s.note) ORDER BY s.id), '[]') FROM transaction_splits s WHERE s.transaction_id = %[1]s)"
+	tagsSnapshot         = "(SELECT COALESCE(jsonb_agg(g.tag_id ORDER BY g.tag_id), '[]') FROM transaction_tags g WHERE g.transaction_id = %[1]s)"
+	sqliteSplitsSnapshot = "(SELECT json_group_array(json_object('id', s.id, 'transaction_id', s.transaction_id, 'category_id', s.category_id, 'amount', s.amount, 'note', s.note)) FROM transaction_splits s WHERE s.transaction_id = %[1]s)"
+	sqliteTagsSnapshot   = "(SELECT json_group_array(g.tag_id) FROM transaction_tags g WHERE g.transaction_id = %[1]s)"
+)
+
+// oldSnapshots are the split lines and tags of the rows of an "old" CTE, for
+// the statements that save versions of many transactions at once on Postgres.
+var oldSnapshots = fmt.Sprintf(splitsSnapshot, "old.id") + ", " + fmt.Sprintf(tagsSnapshot, "old.id")
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectSaveVersion expects the current state of a transaction to be copied into its history.
+func expectSaveVersion(mock sqlmock.Sqlmock, txnID, userID int) {
+	mock.ExpectExec("INSERT INTO transaction_versions").
+		WithArgs(txnID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestGetTransactionHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "date", "description", "category_id", "user_id", "version", "deleted_at"}).
+			AddRow(5, -30.00, now, "Lunch", 2, 1, 3, nil))
+	mock.ExpectQuery("SELECT (.+) FROM transaction_versions WHERE transaction_id = \\$1 ORDER BY version DESC").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "amount", "date", "description", "category_id", "splits", "tag_ids", "deleted_at", "created_at"}).
+			AddRow(2, -25.00, now, "Lunch", 2, []byte(`[{"id":9,"transaction_id":5,"category_id":2,"amount":-25,"note":"Meal"}]`), []byte(`[4,3]`), now, now).
+			AddRow(1, -25.00, now, "Lunch", 1, nil, nil, nil, now))
+
+	history, err := r.GetTransactionHistory(context.Background(), 1, 5)
+	assert.NoError(t, err)
+	assert.NotNil(t, history)
+	assert.Equal(t, 3, history.Current.Version)
+	assert.Len(t, history.Versions, 2)
+	assert.NotNil(t, history.Versions[0].DeletedAt)
+	assert.Equal(t, []Split{{ID: 9, TransactionID: 5, CategoryID: 2, Amount: -25, Note: "Meal"}}, history.Versions[0].Splits)
+	assert.Equal(t, []int{3, 4}, history.Versions[0].TagIDs)
+	assert.Equal(t, 1, history.Versions[1].CategoryID)
+	assert.Nil(t, history.Versions[1].Splits, "versions saved before splits were kept have none")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUndoTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"version":3,"deleted_at":"2026-10-19T10:00:00"}`)
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectQuery("SELECT amount, date, description, category_id, deleted_at, splits, tag_ids FROM transaction_versions WHERE transaction_id = \\$1 AND version = \\$2").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "date", "description", "category_id", "deleted_at", "splits", "tag_ids"}).
+			AddRow(-25.00, now, "Lunch", 2, nil, []byte(`[]`), []byte(`[1]`)))
+	mock.ExpectQuery("SELECT id FROM categories WHERE id = \\$1").
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectSaveVersion(mock, 5, 1)
+	mock.ExpectQuery("UPDATE transactions SET amount = \\$1, date = \\$2, description = \\$3, category_id = \\$4, deleted_at = \\$5, version = version \\+ 1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectQuery("SELECT id, transaction_id, category_id, amount, note FROM transaction_splits WHERE transaction_id = \\$1").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "category_id", "amount", "note"}))
+	expectSnapshot(mock, EntityTransactionTags, 5, `[]`)
+	mock.ExpectExec("DELETE FROM transaction_tags WHERE transaction_id = \\$1").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO transaction_tags").
+		WithArgs(5, 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSnapshot(mock, EntityTransactionTags, 5, `[1]`)
+	expectAudit(mock, 1, EntityTransactionTags, 5, AuditUpdate)
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"version":4,"deleted_at":null}`)
+	expectAudit(mock, 1, EntityTransaction, 5, AuditUndo)
+	mock.ExpectCommit()
+
+	version, err := r.UndoTransaction(context.Background(), 1, 5, 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUndoTransactionWithoutSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"version":3}`)
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectQuery("SELECT amount, date, description, category_id, deleted_at, splits, tag_ids FROM transaction_versions").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "date", "description", "category_id", "deleted_at", "splits", "tag_ids"}).
+			AddRow(-25.00, time.Now(), "Lunch", 2, nil, nil, nil))
+	mock.ExpectRollback()
+
+	_, err = r.UndoTransaction(context.Background(), 1, 5, 3, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUndoTransactionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"version":4}`)
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectRollback()
+
+	_, err = r.UndoTransaction(context.Background(), 1, 5, 3, 0)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUndoTransactionUnknownVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	r := &DB{Conn: db}
+
+	mock.ExpectBegin()
+	expectSnapshot(mock, EntityTransaction, 5, `{"id":5,"version":1}`)
+	mock.ExpectQuery("SELECT version FROM transactions WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err = r.UndoTransaction(context.Background(), 1, 5, 1, 0)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,9 +5,44 @@ import (
 	"net/http"
 )
 
-type ErrorResponse struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"massage"`
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Every error response of the
+// API has this shape; the members after Instance are extensions that are only
+// present for the problems they describe.
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"no transaction found or not authorized"`
+	Instance string `json:"instance,omitempty" example:"/api/v1/transactions/7"`
+
+	InvalidParams  []InvalidParam `json:"invalid_params,omitempty"`
+	CurrentVersion int            `json:"current_version,omitempty"`
+	UsageCount     int            `json:"usage_count,omitempty"`
+}
+
+// InvalidParam names an input field that was refused and why.
+type InvalidParam struct {
+	Name   string `json:"name" example:"amount"`
+	Reason string `json:"reason" example:"must not be zero"`
+}
+
+// NewProblem builds a problem of the generic about:blank type, titled after
+// the status code.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Error lets a Problem travel as an error until it is written.
+func (p *Problem) Error() string {
+	return p.Detail
 }
 
 type SuccessResponse struct {
@@ -15,14 +50,18 @@ type SuccessResponse struct {
 	Data       interface{} `json:"data"`
 }
 
+// Error writes a problem with the given status and detail.
 func Error(w http.ResponseWriter, statusCode int, message string) {
-	w.WriteHeader(statusCode)
+	WriteProblem(w, NewProblem(statusCode, message))
+}
+
+// WriteProblem writes p as application/problem+json.
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
-	encoder.Encode(ErrorResponse{
-		StatusCode: statusCode,
-		Message:    message,
-	})
+	encoder.Encode(p)
 }
 
 func Success(w http.ResponseWriter, statusCode int, data interface{}) {
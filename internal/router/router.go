@@ -0,0 +1,171 @@
+// Package router wires the HTTP handlers to the routes of the API.
+package router
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/nemopss/financial-tracker/docs" // Swagger docs
+	"github.com/nemopss/financial-tracker/internal/handlers"
+	"github.com/nemopss/financial-tracker/internal/metrics"
+	"github.com/nemopss/financial-tracker/internal/middleware"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/response"
+	"github.com/nemopss/financial-tracker/internal/storage"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// Config holds the dependencies of the handlers.
+type Config struct {
+	Repo              repository.Repository
+	Storage           storage.BlobStorage
+	JWTSecret         string
+	MaxAttachmentSize int64
+
+	// DebugVars serves the expvar variables at /debug/vars
+	DebugVars bool
+
+	// HealthChecks decide whether /readyz reports the instance as ready
+	HealthChecks []handlers.HealthCheck
+}
+
+// New returns the router serving the API under /api/v1 and its Swagger UI.
+func New(cfg Config) *gin.Engine {
+	// Initialize handlers
+	authHandler := &handlers.AuthHandler{Repo: cfg.Repo, JWTSecret: cfg.JWTSecret}
+	categoryHandler := &handlers.CategoryHandler{Repo: cfg.Repo}
+	transactionHandler := &handlers.TransactionHandler{Repo: cfg.Repo}
+	analyticsHandler := &handlers.AnalyticsHandler{Repo: cfg.Repo}
+	tagHandler := &handlers.TagHandler{Repo: cfg.Repo}
+	attachmentHandler := &handlers.AttachmentHandler{Repo: cfg.Repo, Storage: cfg.Storage, MaxSize: cfg.MaxAttachmentSize}
+	trashHandler := &handlers.TrashHandler{Repo: cfg.Repo}
+	auditHandler := &handlers.AuditHandler{Repo: cfg.Repo}
+	settingsHandler := &handlers.SettingsHandler{Repo: cfg.Repo}
+	healthHandler := &handlers.HealthHandler{Checks: cfg.HealthChecks}
+
+	// Legacy routes that take IDs in the query or body are deprecated since the
+	// resource routes were introduced
+	legacySince := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	deprecated := func(successor string) gin.HandlerFunc {
+		return middleware.Deprecated(legacySince, successor)
+	}
+
+	// Initialize Gin
+	// Probes and scrapes are polled every few seconds and would drown the request log
+	r := gin.New()
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/healthz", "/readyz", "/metrics"}}))
+	r.Use(gin.Recovery())
+	r.Use(metrics.Middleware())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Errors())
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(response.NewProblem(http.StatusNotFound, "Route not found"))
+	})
+
+	// Health probes for the orchestrator, outside the API and its auth
+	r.GET("/healthz", healthHandler.LivenessGin)
+	r.GET("/readyz", healthHandler.ReadinessGin)
+
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Swagger
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	if cfg.DebugVars {
+		r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	// API routes
+	api := r.Group("/api/v1")
+	{
+		// Auth routes
+		api.POST("/auth/register", authHandler.RegisterGin)
+		api.POST("/auth/login", authHandler.LoginGin)
+
+		// Protected routes
+		protected := api.Group("/", middleware.AuthGin(cfg.JWTSecret))
+		{
+			// Categories
+			protected.POST("/categories", categoryHandler.CreateCategoryGin)
+			protected.GET("/categories", categoryHandler.GetCategoriesGin)
+			protected.GET("/categories/:id", categoryHandler.GetCategoryGin)
+			protected.PUT("/categories/:id", categoryHandler.UpdateCategoryGin)
+			protected.PATCH("/categories/:id", categoryHandler.PatchCategoryGin)
+			protected.DELETE("/categories/:id", categoryHandler.DeleteCategoryGin)
+			protected.POST("/categories/:id/restore", trashHandler.RestoreCategoryGin)
+			protected.POST("/categories/merge", categoryHandler.MergeCategoriesGin)
+
+			// Tags
+			protected.POST("/tags", tagHandler.CreateTagGin)
+			protected.GET("/tags", tagHandler.GetTagsGin)
+			protected.PUT("/tags/:id", tagHandler.UpdateTagGin)
+			protected.DELETE("/tags/:id", tagHandler.DeleteTagGin)
+
+			// Transactions
+			protected.POST("/transactions", transactionHandler.CreateTransactionGin)
+			protected.GET("/transactions", transactionHandler.GetTransactionsGin)
+			protected.GET("/transactions/:id", transactionHandler.GetTransactionGin)
+			protected.PUT("/transactions/:id", transactionHandler.UpdateTransactionGin)
+			protected.PATCH("/transactions/:id", transactionHandler.PatchTransactionGin)
+			protected.DELETE("/transactions/:id", transactionHandler.DeleteTransactionGin)
+			protected.POST("/transactions/:id/restore", trashHandler.RestoreTransactionGin)
+			protected.GET("/transactions/:id/audit", auditHandler.GetTransactionAuditGin)
+			protected.GET("/transactions/:id/history", transactionHandler.GetTransactionHistoryGin)
+			protected.POST("/transactions/:id/undo", transactionHandler.UndoTransactionGin)
+			protected.POST("/transactions/bulk", transactionHandler.BulkTransactionsGin)
+			protected.GET("/transactions/:id/splits", transactionHandler.GetSplitsGin)
+			protected.PUT("/transactions/:id/splits", transactionHandler.SetSplitsGin)
+			protected.DELETE("/transactions/:id/splits", transactionHandler.DeleteSplitsGin)
+
+			// Attachments
+			protected.POST("/transactions/:id/attachments", attachmentHandler.UploadAttachmentGin)
+			protected.GET("/transactions/:id/attachments", attachmentHandler.GetAttachmentsGin)
+			protected.GET("/attachments/:id", attachmentHandler.DownloadAttachmentGin)
+			protected.DELETE("/attachments/:id", attachmentHandler.DeleteAttachmentGin)
+
+			// Trash
+			protected.GET("/trash", trashHandler.GetTrashGin)
+
+			// Audit log
+			protected.GET("/audit", auditHandler.GetAuditLogGin)
+
+			// Settings
+			protected.GET("/settings", settingsHandler.GetSettingsGin)
+			protected.PUT("/settings", settingsHandler.UpdateSettingsGin)
+
+			// Deprecated aliases of the resource routes, kept until clients migrate
+			protected.GET("/categories/list", deprecated("/api/v1/categories"), categoryHandler.GetCategoriesGin)
+			protected.PUT("/categories/update", deprecated("/api/v1/categories/{id}"), categoryHandler.UpdateCategoryGin)
+			protected.DELETE("/categories/delete", deprecated("/api/v1/categories/{id}"), categoryHandler.DeleteCategoryGin)
+			protected.GET("/transactions/list", deprecated("/api/v1/transactions"), transactionHandler.GetTransactionsGin)
+			protected.PUT("/transactions/update", deprecated("/api/v1/transactions/{id}"), transactionHandler.UpdateTransactionGin)
+			protected.DELETE("/transactions/delete", deprecated("/api/v1/transactions/{id}"), transactionHandler.DeleteTransactionGin)
+			protected.POST("/categories/restore", deprecated("/api/v1/categories/{id}/restore"), trashHandler.RestoreCategoryGin)
+			protected.POST("/transactions/restore", deprecated("/api/v1/transactions/{id}/restore"), trashHandler.RestoreTransactionGin)
+			protected.GET("/transactions/audit", deprecated("/api/v1/transactions/{id}/audit"), auditHandler.GetTransactionAuditGin)
+			protected.GET("/tags/list", deprecated("/api/v1/tags"), tagHandler.GetTagsGin)
+			protected.PUT("/tags/update", deprecated("/api/v1/tags/{id}"), tagHandler.UpdateTagGin)
+			protected.DELETE("/tags/delete", deprecated("/api/v1/tags/{id}"), tagHandler.DeleteTagGin)
+			protected.GET("/transactions/splits", deprecated("/api/v1/transactions/{id}/splits"), transactionHandler.GetSplitsGin)
+			protected.PUT("/transactions/splits", deprecated("/api/v1/transactions/{id}/splits"), transactionHandler.SetSplitsGin)
+			protected.DELETE("/transactions/splits", deprecated("/api/v1/transactions/{id}/splits"), transactionHandler.DeleteSplitsGin)
+			protected.POST("/transactions/attachments", deprecated("/api/v1/transactions/{id}/attachments"), attachmentHandler.UploadAttachmentGin)
+			protected.GET("/transactions/attachments", deprecated("/api/v1/transactions/{id}/attachments"), attachmentHandler.GetAttachmentsGin)
+			protected.GET("/attachments/download", deprecated("/api/v1/attachments/{id}"), attachmentHandler.DownloadAttachmentGin)
+			protected.DELETE("/attachments/delete", deprecated("/api/v1/attachments/{id}"), attachmentHandler.DeleteAttachmentGin)
+
+			// Analytics
+			protected.GET("/analytics/income-expenses", analyticsHandler.GetIncomeAndExpensesGin)
+			protected.GET("/analytics/categories", analyticsHandler.GetCategoryAnalyticsGin)
+			protected.GET("/analytics/income-expenses-filtered", analyticsHandler.GetIncomeAndExpensesFilteredGin)
+			protected.GET("/analytics/categories-filtered", analyticsHandler.GetCategoryAnalyticsFilteredGin)
+			protected.GET("/analytics/tags", analyticsHandler.GetTagAnalyticsFilteredGin)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	err = s.Put(ctx, "users/1/receipt.pdf", strings.NewReader("%PDF-1.4"), 8, "application/pdf")
+	assert.NoError(t, err)
+
+	r, err := s.Get(ctx, "users/1/receipt.pdf")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "%PDF-1.4", string(data))
+
+	assert.NoError(t, s.Delete(ctx, "users/1/receipt.pdf"))
+	_, err = s.Get(ctx, "users/1/receipt.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting a missing blob is not an error
+	assert.NoError(t, s.Delete(ctx, "users/1/receipt.pdf"))
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	assert.NoError(t, err)
+
+	err = s.Put(context.Background(), "../outside", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible server that checks request signatures.
+type fakeS3 struct {
+	signer  *S3Storage
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	signed, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	check := r.Clone(context.Background())
+	check.URL.Host = r.Host
+	f.signer.sign(check, signed)
+	if check.Header.Get("Authorization") != r.Header.Get("Authorization") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := NewS3Storage(server.URL, "receipts", "", "access", "secret")
+	fake.signer = s
+
+	ctx := context.Background()
+	err := s.Put(ctx, "users/1/receipt.png", strings.NewReader("png-bytes"), 9, "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("png-bytes"), fake.objects["/receipts/users/1/receipt.png"])
+
+	r, err := s.Get(ctx, "users/1/receipt.png")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "png-bytes", string(data))
+
+	assert.NoError(t, s.Delete(ctx, "users/1/receipt.png"))
+	_, err = s.Get(ctx, "users/1/receipt.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3StorageBadCredentials(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	fake.signer = NewS3Storage(server.URL, "receipts", "", "access", "secret")
+	s := NewS3Storage(server.URL, "receipts", "", "access", "wrong")
+
+	err := s.Put(context.Background(), "users/1/receipt.png", strings.NewReader("png"), 3, "image/png")
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+// Package validation checks request input field by field and reports every
+// violation at once as a *repository.ValidationError.
+package validation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/nemopss/financial-tracker/internal/repository"
+)
+
+// MaxNameLength is the size of the VARCHAR name columns of users, categories
+// and tags.
+const MaxNameLength = 50
+
+// DateLayout is the layout of calendar dates in requests.
+const DateLayout = "2006-01-02"
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		Register(v)
+	}
+}
+
+// Register adds the custom tags to v and makes it report fields under their
+// JSON names:
+//
+//	nonzero  - a number other than zero
+//	name     - a non-blank string of at most MaxNameLength characters
+//	date     - an RFC 3339 timestamp or a YYYY-MM-DD date
+//	timezone - an IANA timezone name
+func Register(v *validator.Validate) {
+	v.RegisterTagNameFunc(jsonName)
+	v.RegisterValidation("nonzero", func(fl validator.FieldLevel) bool {
+		return !fl.Field().IsZero()
+	})
+	v.RegisterValidation("name", func(fl validator.FieldLevel) bool {
+		return ValidName(fl.Field().String())
+	})
+	v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		return ValidDate(fl.Field().String())
+	})
+	v.RegisterValidation("timezone", func(fl validator.FieldLevel) bool {
+		return ValidTimezone(fl.Field().String())
+	})
+}
+
+// ValidName reports whether name fits a name column.
+func ValidName(name string) bool {
+	return strings.TrimSpace(name) != "" && utf8.RuneCountInString(name) <= MaxNameLength
+}
+
+// ValidDate reports whether value is an RFC 3339 timestamp or a YYYY-MM-DD date.
+func ValidDate(value string) bool {
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		return true
+	}
+	_, err := time.Parse(DateLayout, value)
+	return err == nil
+}
+
+// ValidTimezone reports whether name is an IANA timezone name. "Local" is
+// refused as it names the zone of the server.
+func ValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// Validator collects the violations of one request. Checks that need the
+// repository record its failures separately, as they are not the fault of
+// the input.
+type Validator struct {
+	fields     []repository.FieldError
+	err        error
+	categories map[int]bool
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Add records a violation of field.
+func (v *Validator) Add(field, message string) {
+	v.fields = append(v.fields, repository.FieldError{Field: field, Message: message})
+}
+
+// Check records a violation of field unless ok.
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.Add(field, message)
+	}
+}
+
+// Struct checks obj against its binding tags.
+func (v *Validator) Struct(obj any) {
+	v.addRuleErrors(binding.Validator.ValidateStruct(obj), "")
+}
+
+// Var checks a single value against tags, reporting violations under field.
+func (v *Validator) Var(field string, value any, tags string) {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.addRuleErrors(engine.Var(value, tags), field)
+}
+
+// Category checks that categoryID is an active category of userID. Zero means
+// uncategorised and passes. The categories are loaded once per Validator.
+func (v *Validator) Category(ctx context.Context, repo repository.Repository, userID int, field string, categoryID int) {
+	if categoryID == 0 || v.err != nil {
+		return
+	}
+
+	if v.categories == nil {
+		categories, err := repo.GetCategories(ctx, userID)
+		if err != nil {
+			v.err = err
+			return
+		}
+		v.categories = make(map[int]bool, len(categories))
+		for _, category := range categories {
+			v.categories[category.ID] = true
+		}
+	}
+
+	v.Check(v.categories[categoryID], field, "category does not exist")
+}
+
+// Err returns the first repository failure, otherwise a
+// *repository.ValidationError listing every violation, or nil.
+func (v *Validator) Err() error {
+	if v.err != nil {
+		return v.err
+	}
+	if len(v.fields) > 0 {
+		return &repository.ValidationError{Fields: v.fields}
+	}
+	return nil
+}
+
+func (v *Validator) addRuleErrors(err error, field string) {
+	var rules validator.ValidationErrors
+	if !errors.As(err, &rules) {
+		if err != nil {
+			v.Add(field, err.Error())
+		}
+		return
+	}
+
+	for _, rule := range rules {
+		name := field
+		if name == "" {
+			// Drop the name of the top-level struct
+			_, name, _ = strings.Cut(rule.Namespace(), ".")
+		}
+		v.Add(name, message(rule))
+	}
+}
+
+// message describes a failed rule for the client.
+func message(rule validator.FieldError) string {
+	switch rule.Tag() {
+	case "required":
+		return "is required"
+	case "nonzero":
+		return "must not be zero"
+	case "name":
+		return "must be 1 to " + strconv.Itoa(MaxNameLength) + " characters"
+	case "date":
+		return "must be an RFC 3339 timestamp or a YYYY-MM-DD date"
+	case "timezone":
+		return "must be an IANA timezone name"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(rule.Param(), " ", ", ")
+	case "min":
+		if rule.Kind() == reflect.Slice {
+			return "must have at least " + rule.Param() + " items"
+		}
+		return "must be at least " + rule.Param()
+	case "max":
+		if rule.Kind() == reflect.Slice {
+			return "must have at most " + rule.Param() + " items"
+		}
+		return "must be at most " + rule.Param()
+	case "gt":
+		return "must be greater than " + rule.Param()
+	}
+	return "failed the " + rule.Tag() + " rule"
+}
@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type transactionInput struct {
+	Amount float64 `json:"amount" binding:"nonzero"`
+	Date   string  `json:"date,omitempty" binding:"omitempty,date"`
+	Name   string  `json:"name" binding:"required,name"`
+	Zone   string  `json:"zone,omitempty" binding:"omitempty,timezone"`
+	Items  []item  `json:"items" binding:"dive"`
+}
+
+type item struct {
+	Op string `json:"op" binding:"required,oneof=create delete"`
+}
+
+func violations(err error) []repository.FieldError {
+	var invalid *repository.ValidationError
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	return invalid.Fields
+}
+
+func TestStructReportsEveryViolation(t *testing.T) {
+	v := New()
+	v.Struct(&transactionInput{
+		Date:  "01.12.2024",
+		Name:  strings.Repeat("x", MaxNameLength+1),
+		Zone:  "Mars/Olympus_Mons",
+		Items: []item{{Op: "create"}, {Op: "merge"}},
+	})
+
+	assert.Equal(t, []repository.FieldError{
+		{Field: "amount", Message: "must not be zero"},
+		{Field: "date", Message: "must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{Field: "name", Message: "must be 1 to 50 characters"},
+		{Field: "zone", Message: "must be an IANA timezone name"},
+		{Field: "items[1].op", Message: "must be one of create, delete"},
+	}, violations(v.Err()))
+}
+
+func TestStructValid(t *testing.T) {
+	v := New()
+	v.Struct(&transactionInput{Amount: -5, Date: "2024-12-01", Name: "Кофе", Zone: "Europe/Moscow"})
+
+	assert.NoError(t, v.Err())
+}
+
+func TestValidName(t *testing.T) {
+	assert.True(t, ValidName(strings.Repeat("я", MaxNameLength)))
+	assert.False(t, ValidName(strings.Repeat("я", MaxNameLength+1)))
+	assert.False(t, ValidName("   "))
+}
+
+func TestValidDate(t *testing.T) {
+	assert.True(t, ValidDate("2024-12-01"))
+	assert.True(t, ValidDate("2024-12-01T15:04:05+03:00"))
+	assert.False(t, ValidDate("2024-13-01"))
+}
+
+func TestValidTimezone(t *testing.T) {
+	assert.True(t, ValidTimezone("UTC"))
+	assert.False(t, ValidTimezone("Local"))
+	assert.False(t, ValidTimezone(""))
+}
+
+func TestCategoryOwnership(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetCategories", mock.Anything, 1).Return([]repository.Category{{ID: 2}}, nil).Once()
+
+	ctx := context.Background()
+	v := New()
+	v.Category(ctx, mockRepo, 1, "splits[0].category_id", 2)
+	v.Category(ctx, mockRepo, 1, "splits[1].category_id", 9)
+	v.Category(ctx, mockRepo, 1, "splits[2].category_id", 0)
+
+	assert.Equal(t, []repository.FieldError{
+		{Field: "splits[1].category_id", Message: "category does not exist"},
+	}, violations(v.Err()))
+
+	// The categories were loaded once for all checks
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCategoryRepositoryFailure(t *testing.T) {
+	mockRepo := &repository.MockRepo{}
+	mockRepo.On("GetCategories", mock.Anything, 1).Return(nil, errors.New("connection refused"))
+
+	v := New()
+	v.Add("amount", "must not be zero")
+	v.Category(context.Background(), mockRepo, 1, "category_id", 2)
+
+	err := v.Err()
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, repository.ErrValidation)
+}
+
+func TestVar(t *testing.T) {
+	v := New()
+	v.Var("name", "", "name")
+	v.Check(false, "tag_ids", "must be a list of tag IDs or null")
+
+	assert.Equal(t, []repository.FieldError{
+		{Field: "name", Message: "must be 1 to 50 characters"},
+		{Field: "tag_ids", Message: "must be a list of tag IDs or null"},
+	}, violations(v.Err()))
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE transaction_tags (
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX idx_transaction_tags_tag_id ON transaction_tags(tag_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS transaction_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_transaction_id ON attachments(transaction_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE transaction_versions (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    date TIMESTAMP,
+    description TEXT,
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (transaction_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS transaction_versions;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS version;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE categories ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE transactions ALTER COLUMN date TYPE TIMESTAMPTZ USING date AT TIME ZONE 'UTC';
+ALTER TABLE transaction_versions ALTER COLUMN date TYPE TIMESTAMPTZ USING date AT TIME ZONE 'UTC';
+
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+
+ALTER TABLE transaction_versions ALTER COLUMN date TYPE TIMESTAMP USING date AT TIME ZONE 'UTC';
+ALTER TABLE transactions ALTER COLUMN date TYPE TIMESTAMP USING date AT TIME ZONE 'UTC';
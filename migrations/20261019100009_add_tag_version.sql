@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE tags ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE tags DROP COLUMN IF EXISTS version;
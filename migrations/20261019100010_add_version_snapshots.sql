@@ -0,0 +1,17 @@
+-- +goose Up
+-- Versions keep the category of a purged category instead of losing it, so
+-- that undoing to them can tell it is gone
+ALTER TABLE transaction_versions DROP CONSTRAINT IF EXISTS transaction_versions_category_id_fkey;
+
+-- Split lines and tags of the transaction when the version was saved; NULL
+-- for versions saved before they were kept
+ALTER TABLE transaction_versions ADD COLUMN splits JSONB;
+ALTER TABLE transaction_versions ADD COLUMN tag_ids JSONB;
+
+-- +goose Down
+ALTER TABLE transaction_versions DROP COLUMN IF EXISTS tag_ids;
+ALTER TABLE transaction_versions DROP COLUMN IF EXISTS splits;
+
+UPDATE transaction_versions SET category_id = NULL WHERE category_id NOT IN (SELECT id FROM categories);
+ALTER TABLE transaction_versions ADD CONSTRAINT transaction_versions_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
@@ -0,0 +1,19 @@
+// Package migrations embeds the goose-annotated SQL migrations into the binary.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds every migration file, named <version>_<description>.sql.
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS holds the SQLite flavour of every migration in FS, under the same
+// versions. A schema change adds a file to both.
+var SQLiteFS, _ = fs.Sub(sqliteFiles, "sqlite")
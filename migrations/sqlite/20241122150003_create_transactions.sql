@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    amount NUMERIC(10, 2) NOT NULL,
+    date TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    description TEXT,
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS transactions;
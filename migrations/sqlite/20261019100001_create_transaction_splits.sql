@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE transaction_splits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    note TEXT
+);
+
+CREATE INDEX idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS transaction_splits;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_transactions_deleted_at ON transactions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+DROP INDEX IF EXISTS idx_transactions_deleted_at;
+
+ALTER TABLE categories DROP COLUMN deleted_at;
+ALTER TABLE transactions DROP COLUMN deleted_at;
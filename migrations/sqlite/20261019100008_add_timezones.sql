@@ -0,0 +1,6 @@
+-- +goose Up
+-- SQLite keeps dates as UTC text already, only the user setting is new
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN timezone;
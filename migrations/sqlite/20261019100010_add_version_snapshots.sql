@@ -0,0 +1,49 @@
+-- +goose Up
+-- SQLite cannot drop a foreign key, so the table is rebuilt without the one on
+-- category_id. Versions keep the category of a purged category instead of
+-- losing it, so that undoing to them can tell it is gone.
+CREATE TABLE transaction_versions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    date TIMESTAMP,
+    description TEXT,
+    category_id INT,
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    -- Split lines and tags of the transaction when the version was saved; NULL
+    -- for versions saved before they were kept
+    splits TEXT,
+    tag_ids TEXT,
+    UNIQUE (transaction_id, version)
+);
+
+INSERT INTO transaction_versions_new (id, transaction_id, version, amount, date, description, category_id, deleted_at, created_at)
+SELECT id, transaction_id, version, amount, date, description, category_id, deleted_at, created_at FROM transaction_versions;
+
+DROP TABLE transaction_versions;
+ALTER TABLE transaction_versions_new RENAME TO transaction_versions;
+
+-- +goose Down
+CREATE TABLE transaction_versions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    date TIMESTAMP,
+    description TEXT,
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    deleted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    UNIQUE (transaction_id, version)
+);
+
+INSERT INTO transaction_versions_old (id, transaction_id, version, amount, date, description, category_id, deleted_at, created_at)
+SELECT id, transaction_id, version, amount, date, description,
+       CASE WHEN category_id IN (SELECT id FROM categories) THEN category_id END,
+       deleted_at, created_at
+FROM transaction_versions;
+
+DROP TABLE transaction_versions;
+ALTER TABLE transaction_versions_old RENAME TO transaction_versions;
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Settings
+
+// Settings holds the per-user settings. Timezone is an IANA timezone name used
+// to read date-only input and analytics date ranges.
+type Settings struct {
+	Timezone string `json:"timezone"`
+}
+
+// Settings fetches the settings of the user.
+func (c *Client) Settings(ctx context.Context) (*Settings, error) {
+	var settings Settings
+	if err := c.do(ctx, http.MethodGet, "/settings", nil, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateSettings replaces the settings of the user.
+func (c *Client) UpdateSettings(ctx context.Context, settings Settings) error {
+	return c.do(ctx, http.MethodPut, "/settings", nil, settings, nil)
+}
+
+// Trash
+
+// Trash holds the records the user moved to the trash.
+type Trash struct {
+	Transactions []Transaction `json:"transactions"`
+	Categories   []Category    `json:"categories"`
+}
+
+// Trash lists the trashed transactions and categories, most recently deleted first.
+func (c *Client) Trash(ctx context.Context) (*Trash, error) {
+	var trash Trash
+	if err := c.do(ctx, http.MethodGet, "/trash", nil, nil, &trash); err != nil {
+		return nil, err
+	}
+	return &trash, nil
+}
+
+// Audit log
+
+// AuditEntry is a change made to the data of the user. Before and After hold
+// the changed record as JSON and are null when it did not exist.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ActorID   *int            `json:"actor_id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows down the entries returned by AuditLog. Zero values are
+// ignored; From and To are days in YYYY-MM-DD format, both inclusive.
+type AuditFilter struct {
+	Entity    string
+	EntityID  int
+	Action    string
+	RequestID string
+	From      string
+	To        string
+	Limit     int
+	Offset    int
+}
+
+func (f AuditFilter) request() *request {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	setInt := func(key string, value int) {
+		if value != 0 {
+			query.Set(key, strconv.Itoa(value))
+		}
+	}
+
+	set("entity", f.Entity)
+	setInt("entity_id", f.EntityID)
+	set("action", f.Action)
+	set("request_id", f.RequestID)
+	set("from", f.From)
+	set("to", f.To)
+	setInt("limit", f.Limit)
+	setInt("offset", f.Offset)
+	return &request{query: query}
+}
+
+// AuditLog lists the changes made to the data of the user, newest first.
+func (c *Client) AuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var resp struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	err := c.do(ctx, http.MethodGet, "/audit", filter.request(), nil, &resp)
+	return resp.Entries, err
+}
+
+// TransactionAudit lists the changes made to a transaction and to its splits,
+// tags and attachments, newest first.
+func (c *Client) TransactionAudit(ctx context.Context, txnID int) ([]AuditEntry, error) {
+	var resp struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	err := c.do(ctx, http.MethodGet, "/transactions/"+strconv.Itoa(txnID)+"/audit", nil, nil, &resp)
+	return resp.Entries, err
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Analytics holds the totals of income and expenses; expenses are negative.
+type Analytics struct {
+	TotalIncome  float64 `json:"total_income"`
+	TotalExpense float64 `json:"total_expense"`
+}
+
+// CategoryAnalytics holds the expenses of one category.
+type CategoryAnalytics struct {
+	CategoryName string  `json:"category_name"`
+	TotalAmount  float64 `json:"total_amount"`
+}
+
+// TagAnalytics holds the total amount of one tag.
+type TagAnalytics struct {
+	TagName     string  `json:"tag_name"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// DateRange selects whole days, both inclusive and in YYYY-MM-DD format, in
+// the timezone of the user.
+type DateRange struct {
+	Start string
+	End   string
+}
+
+func (r DateRange) request() *request {
+	return &request{query: url.Values{"start_date": {r.Start}, "end_date": {r.End}}}
+}
+
+// IncomeAndExpenses returns the totals of all transactions, or only of those
+// within days when it is not nil.
+func (c *Client) IncomeAndExpenses(ctx context.Context, days *DateRange) (*Analytics, error) {
+	path, r := "/analytics/income-expenses", (*request)(nil)
+	if days != nil {
+		path, r = "/analytics/income-expenses-filtered", days.request()
+	}
+
+	var analytics Analytics
+	if err := c.do(ctx, http.MethodGet, path, r, nil, &analytics); err != nil {
+		return nil, err
+	}
+	return &analytics, nil
+}
+
+// CategoryAnalytics returns the expenses per category, of all transactions or
+// only of those within days when it is not nil.
+func (c *Client) CategoryAnalytics(ctx context.Context, days *DateRange) ([]CategoryAnalytics, error) {
+	path, r := "/analytics/categories", (*request)(nil)
+	if days != nil {
+		path, r = "/analytics/categories-filtered", days.request()
+	}
+
+	var analytics []CategoryAnalytics
+	err := c.do(ctx, http.MethodGet, path, r, nil, &analytics)
+	return analytics, err
+}
+
+// TagAnalytics returns the total amount per tag within days.
+func (c *Client) TagAnalytics(ctx context.Context, days DateRange) ([]TagAnalytics, error) {
+	var analytics []TagAnalytics
+	err := c.do(ctx, http.MethodGet, "/analytics/tags", days.request(), nil, &analytics)
+	return analytics, err
+}
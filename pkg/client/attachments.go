@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Attachment describes a file attached to a transaction.
+type Attachment struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UploadAttachment attaches the contents of file to a transaction under
+// filename. The server detects the content type and refuses files that are
+// too large or of a type it does not accept.
+func (c *Client) UploadAttachment(ctx context.Context, txnID int, filename string, file io.Reader) (*Attachment, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var attachment Attachment
+	body := &rawBody{data: buf.Bytes(), contentType: form.FormDataContentType()}
+	if err := c.do(ctx, http.MethodPost, "/transactions/"+strconv.Itoa(txnID)+"/attachments", nil, body, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// Attachments lists the attachments of a transaction.
+func (c *Client) Attachments(ctx context.Context, txnID int) ([]Attachment, error) {
+	var resp struct {
+		Attachments []Attachment `json:"attachments"`
+	}
+	err := c.do(ctx, http.MethodGet, "/transactions/"+strconv.Itoa(txnID)+"/attachments", nil, nil, &resp)
+	return resp.Attachments, err
+}
+
+// DownloadAttachment copies the file of an attachment to w.
+func (c *Client) DownloadAttachment(ctx context.Context, id int, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, "/attachments/"+strconv.Itoa(id), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download attachment: %w", err)
+	}
+	return nil
+}
+
+// DeleteAttachment deletes an attachment and its file.
+func (c *Client) DeleteAttachment(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/attachments/"+strconv.Itoa(id), nil, nil, nil)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Strategies for the transactions of a deleted category.
+const (
+	DeleteBlock    = "block"
+	DeleteReassign = "reassign"
+	DeleteCascade  = "cascade"
+)
+
+// Category is a category of the authenticated user.
+type Category struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Version   int        `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CreateCategory creates a category and returns its ID.
+func (c *Client) CreateCategory(ctx context.Context, name string) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/categories", nil, map[string]string{"name": name}, &resp)
+	return resp.ID, err
+}
+
+// Categories lists the categories of the user.
+func (c *Client) Categories(ctx context.Context) ([]Category, error) {
+	var resp struct {
+		Categories []Category `json:"categories"`
+	}
+	err := c.do(ctx, http.MethodGet, "/categories", nil, nil, &resp)
+	return resp.Categories, err
+}
+
+// Category fetches a single category.
+func (c *Client) Category(ctx context.Context, id int) (*Category, error) {
+	var category Category
+	if err := c.do(ctx, http.MethodGet, "/categories/"+strconv.Itoa(id), nil, nil, &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// UpdateCategory renames a category. version is the version the change is
+// based on; 0 overwrites whatever is stored.
+func (c *Client) UpdateCategory(ctx context.Context, id int, name string, version int) error {
+	return c.do(ctx, http.MethodPut, "/categories/"+strconv.Itoa(id), ifMatch(version), map[string]string{"name": name}, nil)
+}
+
+// PatchCategory renames a category through a merge patch.
+func (c *Client) PatchCategory(ctx context.Context, id int, name string, version int) error {
+	r := ifMatch(version)
+	r.header.Set("Content-Type", "application/merge-patch+json")
+	return c.do(ctx, http.MethodPatch, "/categories/"+strconv.Itoa(id), r, map[string]string{"name": name}, nil)
+}
+
+// DeleteCategory moves a category to the trash. It fails with ErrConflict
+// while transactions still use the category.
+func (c *Client) DeleteCategory(ctx context.Context, id, version int) error {
+	return c.DeleteCategoryWith(ctx, id, version, DeleteBlock, 0)
+}
+
+// DeleteCategoryWith moves a category to the trash and handles its
+// transactions according to strategy: DeleteReassign moves them to targetID,
+// DeleteCascade moves them to the trash as well.
+func (c *Client) DeleteCategoryWith(ctx context.Context, id, version int, strategy string, targetID int) error {
+	r := ifMatch(version)
+	r.query = url.Values{"strategy": {strategy}}
+	if strategy == DeleteReassign {
+		r.query.Set("target_id", strconv.Itoa(targetID))
+	}
+	return c.do(ctx, http.MethodDelete, "/categories/"+strconv.Itoa(id), r, nil, nil)
+}
+
+// MergeCategories moves every transaction of sourceID, including trashed ones,
+// into targetID and moves sourceID to the trash. version is the version of
+// sourceID the merge is based on; 0 merges whatever is stored.
+func (c *Client) MergeCategories(ctx context.Context, sourceID, targetID, version int) error {
+	body := map[string]int{"source_id": sourceID, "target_id": targetID}
+	return c.do(ctx, http.MethodPost, "/categories/merge", ifMatch(version), body, nil)
+}
+
+// RestoreCategory brings a category back from the trash.
+func (c *Client) RestoreCategory(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPost, "/categories/"+strconv.Itoa(id)+"/restore", nil, nil, nil)
+}
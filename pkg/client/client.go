@@ -0,0 +1,293 @@
+// Package client is a Go client for the Financial Tracker API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL points at a server started locally with the default port.
+const DefaultBaseURL = "http://localhost:8080/api/v1"
+
+// Kinds of failed requests. Every *Error matches the one of its status with
+// errors.Is, so callers can branch without comparing status codes.
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrTooLarge             = errors.New("request entity too large")
+	ErrUnsupportedMedia     = errors.New("unsupported media type")
+	ErrValidation           = errors.New("validation failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusPreconditionFailed:    ErrPreconditionFailed,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnsupportedMediaType:  ErrUnsupportedMedia,
+	http.StatusUnprocessableEntity:   ErrValidation,
+	http.StatusPreconditionRequired:  ErrPreconditionRequired,
+}
+
+// Client calls the API on behalf of one user. It is safe for concurrent use
+// once Token is set.
+type Client struct {
+	// BaseURL is the API root including the version prefix, e.g. DefaultBaseURL
+	BaseURL string
+	// Token is the JWT sent as a bearer token, set by Login
+	Token      string
+	HTTPClient *http.Client
+
+	// mu guards Token and the credentials kept by Login, refreshing makes
+	// concurrent calls that were refused wait for a single new login
+	mu         sync.Mutex
+	refreshing sync.Mutex
+	username   string
+	password   string
+}
+
+// New returns a client for the API at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is a failed request, decoded from the application/problem+json body.
+type Error struct {
+	Status        int            `json:"status"`
+	Title         string         `json:"title"`
+	Detail        string         `json:"detail,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+	// CurrentVersion is the stored version of a record that failed an
+	// If-Match precondition
+	CurrentVersion int `json:"current_version,omitempty"`
+	// UsageCount is the number of transactions that keep a category in use
+	UsageCount int `json:"usage_count,omitempty"`
+
+	// body is kept for endpoints that describe failures in their own format
+	body []byte
+}
+
+// InvalidParam names a request field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	for _, p := range e.InvalidParams {
+		msg += fmt.Sprintf("; %s: %s", p.Name, p.Reason)
+	}
+	return fmt.Sprintf("%d %s", e.Status, msg)
+}
+
+func (e *Error) Is(target error) bool {
+	return statusErrors[e.Status] == target
+}
+
+// Auth
+
+// Register creates a user and returns its ID.
+func (c *Client) Register(ctx context.Context, username, password string) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	body := map[string]string{"username": username, "password": password}
+	err := c.do(ctx, http.MethodPost, "/auth/register", nil, body, &resp)
+	return resp.ID, err
+}
+
+// Login authenticates the user and keeps the token for later calls. The
+// credentials are kept as well: when the token expires, the next call that is
+// refused with 401 logs in again and is retried once.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	token, err := c.login(ctx, username, password)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Token, c.username, c.password = token, username, password
+	return token, nil
+}
+
+func (c *Client) login(ctx context.Context, username, password string) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"username": username, "password": password}
+	if err := c.do(ctx, http.MethodPost, "/auth/login", nil, body, &resp); err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// refresh logs in again with the credentials of Login, unless another call
+// already replaced the rejected token. It reports whether a retry may help.
+func (c *Client) refresh(ctx context.Context, rejected string) bool {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+
+	c.mu.Lock()
+	token, username, password := c.Token, c.username, c.password
+	c.mu.Unlock()
+	if username == "" {
+		return false
+	}
+	if token != rejected {
+		return true
+	}
+
+	token, err := c.login(ctx, username, password)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	c.Token = token
+	c.mu.Unlock()
+	return true
+}
+
+func (c *Client) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Token
+}
+
+// Requests
+
+type request struct {
+	header http.Header
+	query  url.Values
+}
+
+// rawBody is a request body sent as is instead of being encoded as JSON.
+type rawBody struct {
+	data        []byte
+	contentType string
+}
+
+// do sends a JSON request and decodes a JSON response into out, if given.
+func (c *Client) do(ctx context.Context, method, path string, r *request, in, out any) error {
+	resp, err := c.send(ctx, method, path, r, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send performs the request and turns error statuses into *Error. The caller
+// closes the body of a successful response.
+func (c *Client) send(ctx context.Context, method, path string, r *request, in any) (*http.Response, error) {
+	target := c.BaseURL + path
+	if r != nil && len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	var body []byte
+	contentType := "application/json"
+	switch in := in.(type) {
+	case nil:
+	case *rawBody:
+		body, contentType = in.data, in.contentType
+	default:
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = data
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if in != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
+		token := c.token()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if r != nil {
+			for key, values := range r.header {
+				req.Header[key] = values
+			}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && !retried && !strings.HasPrefix(path, "/auth/") && c.refresh(ctx, token) {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		}
+		return resp, nil
+	}
+}
+
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	apiErr := &Error{Status: resp.StatusCode, body: data}
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Status == 0 {
+		apiErr.Status = resp.StatusCode
+	}
+	if apiErr.Title == "" {
+		apiErr.Title = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// ifMatch builds the If-Match header for version, or "*" when version is 0.
+func ifMatch(version int) *request {
+	tag := "*"
+	if version > 0 {
+		tag = strconv.Quote(strconv.Itoa(version))
+	}
+	return &request{header: http.Header{"If-Match": {tag}}}
+}
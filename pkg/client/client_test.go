@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginKeepsToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/auth/login":
+			json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+		case "/api/v1/categories":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"categories":[{"id":1,"name":"Food","version":2}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL + "/api/v1/")
+	token, err := c.Login(context.Background(), "testuser", "password123")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	categories, err := c.Categories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Category{{ID: 1, Name: "Food", Version: 2}}, categories)
+}
+
+func TestProblemIsDecoded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"3"`, r.Header.Get("If-Match"))
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+
+		var members map[string]any
+		json.NewDecoder(r.Body).Decode(&members)
+		assert.Equal(t, map[string]any{"amount": 0.0, "category_id": nil}, members)
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"Validation failed","invalid_params":[{"name":"amount","reason":"must not be zero"}]}`))
+	}))
+	defer srv.Close()
+
+	amount := 0.0
+	c := New(srv.URL)
+	err := c.PatchTransaction(context.Background(), 7, TransactionPatch{Amount: &amount, ClearCategory: true}, 3)
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+	assert.Equal(t, []InvalidParam{{Name: "amount", Reason: "must not be zero"}}, apiErr.InvalidParams)
+	assert.EqualError(t, err, "422 Validation failed; amount: must not be zero")
+}
+
+func TestBulkRolledBack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"mode":"atomic","rolled_back":true,"results":[{"index":0,"op":"create","id":5},{"index":1,"op":"delete","error":"no transaction found or not authorized"}]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	resp, err := c.Bulk(context.Background(), BulkAtomic, []BulkOperation{
+		{Op: BulkCreate, Amount: 10},
+		{Op: BulkDelete, ID: 99},
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.RolledBack)
+	assert.Equal(t, "no transaction found or not authorized", resp.Results[1].Error)
+}
+
+func TestExpiredTokenIsRefreshed(t *testing.T) {
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/login":
+			logins++
+			json.NewEncoder(w).Encode(map[string]string{"token": fmt.Sprintf("token-%d", logins)})
+		case "/tags":
+			if r.Header.Get("Authorization") != "Bearer token-2" {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"title":"Unauthorized","status":401,"detail":"Invalid token"}`))
+				return
+			}
+			w.Write([]byte(`{"tags":[{"id":1,"name":"travel"}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	_, err := c.Login(context.Background(), "testuser", "password123")
+	assert.NoError(t, err)
+
+	// The refused call logs in again and is retried with the new token
+	tags, err := c.Tags(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: 1, Name: "travel"}}, tags)
+	assert.Equal(t, 2, logins)
+	assert.Equal(t, "token-2", c.Token)
+}
+
+func TestUnauthorizedWithoutLogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.Token = "expired"
+	_, err := c.Tags(context.Background())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+		check  func(t *testing.T, apiErr *Error)
+	}{
+		{
+			name:   "Stale version",
+			status: http.StatusPreconditionFailed,
+			body:   `{"title":"Precondition Failed","status":412,"detail":"Category was modified","current_version":4}`,
+			want:   ErrPreconditionFailed,
+			check:  func(t *testing.T, apiErr *Error) { assert.Equal(t, 4, apiErr.CurrentVersion) },
+		},
+		{
+			name:   "Category in use",
+			status: http.StatusConflict,
+			body:   `{"title":"Conflict","status":409,"detail":"Category is in use","usage_count":3}`,
+			want:   ErrConflict,
+			check:  func(t *testing.T, apiErr *Error) { assert.Equal(t, 3, apiErr.UsageCount) },
+		},
+		{
+			name:   "Plain text body",
+			status: http.StatusNotFound,
+			body:   `404 page not found`,
+			want:   ErrNotFound,
+			check:  func(t *testing.T, apiErr *Error) { assert.Equal(t, "Not Found", apiErr.Title) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := New(srv.URL).DeleteCategory(context.Background(), 1, 2)
+			assert.ErrorIs(t, err, tt.want)
+
+			var apiErr *Error
+			if assert.ErrorAs(t, err, &apiErr) {
+				assert.Equal(t, tt.status, apiErr.Status)
+				tt.check(t, apiErr)
+			}
+		})
+	}
+}
+
+func TestUploadAttachment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/transactions/7/attachments", r.URL.Path)
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Failed to read form file: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "receipt.txt", header.Filename)
+		assert.Equal(t, "paid", string(data))
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":3,"transaction_id":7,"filename":"receipt.txt","content_type":"text/plain","size":4}`))
+	}))
+	defer srv.Close()
+
+	attachment, err := New(srv.URL).UploadAttachment(context.Background(), 7, "receipt.txt", strings.NewReader("paid"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Attachment{ID: 3, TransactionID: 7, Filename: "receipt.txt", ContentType: "text/plain", Size: 4}, attachment)
+}
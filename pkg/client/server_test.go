@@ -0,0 +1,268 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nemopss/financial-tracker/internal/repository"
+	"github.com/nemopss/financial-tracker/internal/router"
+	"github.com/nemopss/financial-tracker/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "test-secret"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// newServer starts the API on an in-memory repository and returns a client
+// logged in as a fresh user.
+func newServer(t *testing.T) *Client {
+	blobs, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	srv := httptest.NewServer(router.New(router.Config{
+		Repo:              repository.NewMemoryRepo(),
+		Storage:           blobs,
+		JWTSecret:         testSecret,
+		MaxAttachmentSize: 1 << 20,
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	c := New(srv.URL + "/api/v1")
+	if _, err := c.Register(ctx, "testuser", "password123"); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if _, err := c.Login(ctx, "testuser", "password123"); err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	return c
+}
+
+func TestServerCategories(t *testing.T) {
+	c := newServer(t)
+	ctx := context.Background()
+
+	food, err := c.CreateCategory(ctx, "Food")
+	assert.NoError(t, err)
+	travel, err := c.CreateCategory(ctx, "Travel")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.UpdateCategory(ctx, food, "Groceries", 1))
+	err = c.PatchCategory(ctx, food, "Eating out", 1)
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.Equal(t, 2, apiErr.CurrentVersion)
+
+	category, err := c.Category(ctx, food)
+	assert.NoError(t, err)
+	assert.Equal(t, &Category{ID: food, Name: "Groceries", Version: 2}, category)
+
+	_, err = c.CreateTransaction(ctx, TransactionInput{Amount: -20, Description: "Bread", CategoryID: food})
+	assert.NoError(t, err)
+
+	err = c.DeleteCategory(ctx, food, 0)
+	assert.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, apiErr.UsageCount)
+
+	err = c.MergeCategories(ctx, food, travel, 1)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.NoError(t, c.MergeCategories(ctx, food, travel, 2))
+	_, err = c.Category(ctx, food)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, c.DeleteCategoryWith(ctx, travel, 0, DeleteCascade, 0))
+	trash, err := c.Trash(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, trash.Transactions, 1)
+	assert.Len(t, trash.Categories, 2, "the merged category is in the trash too")
+
+	assert.NoError(t, c.RestoreCategory(ctx, travel))
+	categories, err := c.Categories(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Category{{ID: travel, Name: "Travel", Version: 3}}, categories)
+}
+
+func TestServerTransactions(t *testing.T) {
+	c := newServer(t)
+	ctx := context.Background()
+
+	category, err := c.CreateCategory(ctx, "Food")
+	assert.NoError(t, err)
+	tag, err := c.CreateTag(ctx, "work")
+	assert.NoError(t, err)
+
+	id, err := c.CreateTransaction(ctx, TransactionInput{
+		Amount:      -40,
+		Date:        "2026-10-01",
+		Description: "Lunch",
+		CategoryID:  category,
+		TagIDs:      []int{tag},
+	})
+	assert.NoError(t, err)
+	_, err = c.CreateTransaction(ctx, TransactionInput{Amount: 100, Date: "2026-10-02", Description: "Salary"})
+	assert.NoError(t, err)
+
+	tagged, err := c.TransactionsWithTags(ctx, []int{tag}, true)
+	assert.NoError(t, err)
+	assert.Len(t, tagged, 1)
+	assert.Equal(t, id, tagged[0].ID)
+	assert.Equal(t, []int{tag}, tagged[0].TagIDs)
+
+	amount := -50.0
+	assert.NoError(t, c.PatchTransaction(ctx, id, TransactionPatch{Amount: &amount}, 1))
+	err = c.SetSplits(ctx, id, []Split{{CategoryID: category, Amount: -50}}, 1)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.NoError(t, c.SetSplits(ctx, id, []Split{
+		{CategoryID: category, Amount: -30, Note: "Meal"},
+		{CategoryID: category, Amount: -20},
+	}, 2))
+	err = c.SetSplits(ctx, id, []Split{{CategoryID: category, Amount: -10}}, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	splits, err := c.Splits(ctx, id)
+	assert.NoError(t, err)
+	assert.Len(t, splits, 2)
+	assert.NoError(t, c.DeleteSplits(ctx, id, 0))
+
+	// Writing and deleting the splits bumped the version too
+	history, err := c.TransactionHistory(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, history.Current.Version)
+	if assert.Len(t, history.Versions, 3) {
+		assert.Equal(t, -40.0, history.Versions[2].Amount)
+	}
+
+	_, err = c.UndoTransaction(ctx, id, 2, 1)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	version, err := c.UndoTransaction(ctx, id, 4, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, version)
+
+	summary, err := c.IncomeAndExpenses(ctx, &DateRange{Start: "2026-10-01", End: "2026-10-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Analytics{TotalExpense: -40}, summary)
+	tags, err := c.TagAnalytics(ctx, DateRange{Start: "2026-10-01", End: "2026-10-31"})
+	assert.NoError(t, err)
+	assert.Equal(t, []TagAnalytics{{TagName: "work", TotalAmount: -40}}, tags)
+
+	assert.NoError(t, c.DeleteTransaction(ctx, id, 5))
+	_, err = c.Transaction(ctx, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, c.RestoreTransaction(ctx, id))
+
+	entries, err := c.TransactionAudit(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "restore", entries[0].Action)
+	entries, err = c.AuditLog(ctx, AuditFilter{Entity: "tag", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "create", entries[0].Action)
+}
+
+func TestServerBulk(t *testing.T) {
+	c := newServer(t)
+	ctx := context.Background()
+
+	resp, err := c.Bulk(ctx, BulkAtomic, []BulkOperation{
+		{Op: BulkCreate, Amount: 10, Description: "Refund"},
+		{Op: BulkDelete, ID: 99},
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.RolledBack)
+	assert.NotEmpty(t, resp.Results[1].Error)
+
+	transactions, err := c.Transactions(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, transactions)
+
+	resp, err = c.Bulk(ctx, BulkBestEffort, []BulkOperation{
+		{Op: BulkCreate, Amount: 10, Description: "Refund"},
+		{Op: BulkDelete, ID: 99},
+	})
+	assert.NoError(t, err)
+	assert.False(t, resp.RolledBack)
+
+	transactions, err = c.Transactions(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 1)
+}
+
+func TestServerTagsAttachmentsAndSettings(t *testing.T) {
+	c := newServer(t)
+	ctx := context.Background()
+
+	tag, err := c.CreateTag(ctx, "home")
+	assert.NoError(t, err)
+	_, err = c.CreateTag(ctx, "home")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NoError(t, c.UpdateTag(ctx, tag, "house", 1))
+	tags, err := c.Tags(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: tag, Name: "house", Version: 2}}, tags)
+	err = c.DeleteTag(ctx, tag, 1)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.NoError(t, c.DeleteTag(ctx, tag, 2))
+
+	id, err := c.CreateTransaction(ctx, TransactionInput{Amount: -5, Description: "Coffee"})
+	assert.NoError(t, err)
+
+	attachment, err := c.UploadAttachment(ctx, id, "receipt.txt", strings.NewReader("coffee 5.00"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", attachment.ContentType)
+
+	attachments, err := c.Attachments(ctx, id)
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+
+	var file bytes.Buffer
+	assert.NoError(t, c.DownloadAttachment(ctx, attachment.ID, &file))
+	assert.Equal(t, "coffee 5.00", file.String())
+	assert.NoError(t, c.DeleteAttachment(ctx, attachment.ID))
+
+	assert.NoError(t, c.UpdateSettings(ctx, Settings{Timezone: "Europe/Moscow"}))
+	settings, err := c.Settings(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", settings.Timezone)
+	err = c.UpdateSettings(ctx, Settings{Timezone: "Mars/Olympus"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestTokenRefresh(t *testing.T) {
+	c := newServer(t)
+	ctx := context.Background()
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(-time.Minute).Unix(),
+	})
+	token, err := expired.SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	c.Token = token
+
+	_, err = c.Categories(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, c.Token)
+
+	// Without credentials the refusal is returned as is
+	anonymous := New(c.BaseURL)
+	anonymous.Token = token
+	_, err = anonymous.Categories(ctx)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Tag is a tag of the authenticated user.
+type Tag struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// CreateTag creates a tag and returns its ID.
+func (c *Client) CreateTag(ctx context.Context, name string) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/tags", nil, map[string]string{"name": name}, &resp)
+	return resp.ID, err
+}
+
+// Tags lists the tags of the user, ordered by name.
+func (c *Client) Tags(ctx context.Context) ([]Tag, error) {
+	var resp struct {
+		Tags []Tag `json:"tags"`
+	}
+	err := c.do(ctx, http.MethodGet, "/tags", nil, nil, &resp)
+	return resp.Tags, err
+}
+
+// UpdateTag renames a tag. version is the version the change is based on; 0
+// overwrites whatever is stored.
+func (c *Client) UpdateTag(ctx context.Context, id int, name string, version int) error {
+	return c.do(ctx, http.MethodPut, "/tags/"+strconv.Itoa(id), ifMatch(version), map[string]string{"name": name}, nil)
+}
+
+// DeleteTag deletes a tag and removes it from every transaction.
+func (c *Client) DeleteTag(ctx context.Context, id, version int) error {
+	return c.do(ctx, http.MethodDelete, "/tags/"+strconv.Itoa(id), ifMatch(version), nil, nil)
+}
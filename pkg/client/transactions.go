@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transaction is a transaction of the authenticated user.
+type Transaction struct {
+	ID          int        `json:"id"`
+	Amount      float64    `json:"amount"`
+	Date        time.Time  `json:"date"`
+	Description string     `json:"description"`
+	CategoryID  int        `json:"category_id"`
+	UserID      int        `json:"user_id"`
+	TagIDs      []int      `json:"tag_ids,omitempty"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// TransactionInput holds the fields of a new or replaced transaction. Date is
+// an RFC 3339 timestamp or a YYYY-MM-DD day in the timezone of the user; empty
+// means now for new transactions and unchanged for updates.
+type TransactionInput struct {
+	Amount      float64 `json:"amount"`
+	Date        string  `json:"date,omitempty"`
+	Description string  `json:"description"`
+	CategoryID  int     `json:"category_id,omitempty"`
+	TagIDs      []int   `json:"tag_ids,omitempty"`
+}
+
+// TransactionPatch holds the fields to change on a transaction; nil fields are
+// left untouched and ClearCategory removes the category.
+type TransactionPatch struct {
+	Amount        *float64
+	Date          *string
+	Description   *string
+	CategoryID    *int
+	ClearCategory bool
+}
+
+func (p TransactionPatch) members() map[string]any {
+	members := make(map[string]any)
+	if p.Amount != nil {
+		members["amount"] = *p.Amount
+	}
+	if p.Date != nil {
+		members["date"] = *p.Date
+	}
+	if p.Description != nil {
+		members["description"] = *p.Description
+	}
+	if p.CategoryID != nil {
+		members["category_id"] = *p.CategoryID
+	}
+	if p.ClearCategory {
+		members["category_id"] = nil
+	}
+	return members
+}
+
+// CreateTransaction creates a transaction and returns its ID.
+func (c *Client) CreateTransaction(ctx context.Context, in TransactionInput) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/transactions", nil, in, &resp)
+	return resp.ID, err
+}
+
+// Transactions lists the transactions of the user.
+func (c *Client) Transactions(ctx context.Context) ([]Transaction, error) {
+	var transactions []Transaction
+	err := c.do(ctx, http.MethodGet, "/transactions", nil, nil, &transactions)
+	return transactions, err
+}
+
+// TransactionsWithTags lists the transactions carrying any of tagIDs, or all
+// of them when matchAll is set.
+func (c *Client) TransactionsWithTags(ctx context.Context, tagIDs []int, matchAll bool) ([]Transaction, error) {
+	ids := make([]string, len(tagIDs))
+	for i, id := range tagIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	r := &request{query: url.Values{"tags": {strings.Join(ids, ",")}}}
+	if matchAll {
+		r.query.Set("tag_match", "all")
+	}
+
+	var transactions []Transaction
+	err := c.do(ctx, http.MethodGet, "/transactions", r, nil, &transactions)
+	return transactions, err
+}
+
+// Transaction fetches a single transaction.
+func (c *Client) Transaction(ctx context.Context, id int) (*Transaction, error) {
+	var txn Transaction
+	if err := c.do(ctx, http.MethodGet, "/transactions/"+strconv.Itoa(id), nil, nil, &txn); err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// UpdateTransaction replaces a transaction. version is the version the change
+// is based on; 0 overwrites whatever is stored.
+func (c *Client) UpdateTransaction(ctx context.Context, id int, in TransactionInput, version int) error {
+	return c.do(ctx, http.MethodPut, "/transactions/"+strconv.Itoa(id), ifMatch(version), in, nil)
+}
+
+// PatchTransaction changes only the fields set in patch.
+func (c *Client) PatchTransaction(ctx context.Context, id int, patch TransactionPatch, version int) error {
+	r := ifMatch(version)
+	r.header.Set("Content-Type", "application/merge-patch+json")
+	return c.do(ctx, http.MethodPatch, "/transactions/"+strconv.Itoa(id), r, patch.members(), nil)
+}
+
+// DeleteTransaction moves a transaction to the trash.
+func (c *Client) DeleteTransaction(ctx context.Context, id, version int) error {
+	return c.do(ctx, http.MethodDelete, "/transactions/"+strconv.Itoa(id), ifMatch(version), nil, nil)
+}
+
+// RestoreTransaction brings a transaction back from the trash.
+func (c *Client) RestoreTransaction(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPost, "/transactions/"+strconv.Itoa(id)+"/restore", nil, nil, nil)
+}
+
+// History
+
+// TransactionVersion is a previous state of a transaction. ReplacedAt is the
+// moment the version was superseded. Splits and TagIDs are empty for versions
+// saved before the server kept them.
+type TransactionVersion struct {
+	Version     int        `json:"version"`
+	Amount      float64    `json:"amount"`
+	Date        time.Time  `json:"date"`
+	Description string     `json:"description"`
+	CategoryID  int        `json:"category_id"`
+	Splits      []Split    `json:"splits,omitempty"`
+	TagIDs      []int      `json:"tag_ids,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ReplacedAt  time.Time  `json:"replaced_at"`
+}
+
+// TransactionHistory is the current state of a transaction, trashed or not,
+// together with its previous versions, newest first.
+type TransactionHistory struct {
+	Current  Transaction          `json:"current"`
+	Versions []TransactionVersion `json:"versions"`
+}
+
+// TransactionHistory fetches the previous versions of a transaction.
+func (c *Client) TransactionHistory(ctx context.Context, id int) (*TransactionHistory, error) {
+	var history TransactionHistory
+	if err := c.do(ctx, http.MethodGet, "/transactions/"+strconv.Itoa(id)+"/history", nil, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// UndoTransaction brings a transaction back to toVersion, or to the version
+// before version when toVersion is 0, and returns the new version. version is
+// the current version of the transaction as the caller knows it; 0 undoes
+// whatever is stored. It fails with ErrPreconditionFailed when the transaction
+// is no longer at version.
+func (c *Client) UndoTransaction(ctx context.Context, id, version, toVersion int) (int, error) {
+	body := map[string]int{"version": toVersion}
+	var resp struct {
+		Version int `json:"version"`
+	}
+	err := c.do(ctx, http.MethodPost, "/transactions/"+strconv.Itoa(id)+"/undo", ifMatch(version), body, &resp)
+	return resp.Version, err
+}
+
+// Splits
+
+// Split is a part of a transaction assigned to its own category.
+type Split struct {
+	ID            int     `json:"id,omitempty"`
+	TransactionID int     `json:"transaction_id,omitempty"`
+	CategoryID    int     `json:"category_id"`
+	Amount        float64 `json:"amount"`
+	Note          string  `json:"note,omitempty"`
+}
+
+// Splits lists the split lines of a transaction.
+func (c *Client) Splits(ctx context.Context, txnID int) ([]Split, error) {
+	var resp struct {
+		Splits []Split `json:"splits"`
+	}
+	err := c.do(ctx, http.MethodGet, "/transactions/"+strconv.Itoa(txnID)+"/splits", nil, nil, &resp)
+	return resp.Splits, err
+}
+
+// SetSplits replaces the split lines of a transaction. Their amounts must add
+// up to the amount of the transaction; only CategoryID, Amount and Note are
+// sent. version is the version of the transaction the change is based on; 0
+// overwrites whatever is stored.
+func (c *Client) SetSplits(ctx context.Context, txnID int, splits []Split, version int) error {
+	lines := make([]Split, len(splits))
+	for i, s := range splits {
+		lines[i] = Split{CategoryID: s.CategoryID, Amount: s.Amount, Note: s.Note}
+	}
+	body := map[string][]Split{"splits": lines}
+	return c.do(ctx, http.MethodPut, "/transactions/"+strconv.Itoa(txnID)+"/splits", ifMatch(version), body, nil)
+}
+
+// DeleteSplits removes all split lines of a transaction.
+func (c *Client) DeleteSplits(ctx context.Context, txnID, version int) error {
+	return c.do(ctx, http.MethodDelete, "/transactions/"+strconv.Itoa(txnID)+"/splits", ifMatch(version), nil, nil)
+}
+
+// Bulk operations
+
+// MaxBulkOperations is the most operations the server accepts in one request.
+const MaxBulkOperations = 1000
+
+// Bulk operation kinds.
+const (
+	BulkCreate       = "create"
+	BulkUpdate       = "update"
+	BulkDelete       = "delete"
+	BulkRecategorise = "recategorise"
+)
+
+// Bulk modes: atomic applies all operations or none, best_effort applies
+// those that succeed.
+const (
+	BulkAtomic     = "atomic"
+	BulkBestEffort = "best_effort"
+)
+
+// BulkOperation is one operation of a bulk request.
+type BulkOperation struct {
+	Op          string  `json:"op"`
+	ID          int     `json:"id,omitempty"`
+	Amount      float64 `json:"amount,omitempty"`
+	Date        string  `json:"date,omitempty"`
+	Description string  `json:"description,omitempty"`
+	CategoryID  int     `json:"category_id,omitempty"`
+	// ExpectedVersion makes update, delete and recategorise operations fail
+	// unless the transaction is still at that version; 0 matches any version.
+	ExpectedVersion int `json:"expected_version,omitempty"`
+}
+
+// BulkResult reports the outcome of one operation of a bulk request.
+type BulkResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BulkResponse is the outcome of a bulk request.
+type BulkResponse struct {
+	Mode       string       `json:"mode"`
+	RolledBack bool         `json:"rolled_back"`
+	Results    []BulkResult `json:"results"`
+}
+
+// Bulk applies up to MaxBulkOperations operations in one request. mode is
+// BulkAtomic or BulkBestEffort; empty means atomic. An atomic request that was
+// rolled back is not an error: RolledBack is set and Results say which
+// operations failed.
+func (c *Client) Bulk(ctx context.Context, mode string, ops []BulkOperation) (*BulkResponse, error) {
+	body := struct {
+		Mode       string          `json:"mode,omitempty"`
+		Operations []BulkOperation `json:"operations"`
+	}{Mode: mode, Operations: ops}
+
+	var resp BulkResponse
+	err := c.do(ctx, http.MethodPost, "/transactions/bulk", nil, body, &resp)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnprocessableEntity {
+		if json.Unmarshal(apiErr.body, &resp) == nil && resp.RolledBack {
+			return &resp, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}